@@ -0,0 +1,175 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/apache/incubator-milagro-dta/libs/audit"
+	"github.com/apache/incubator-milagro-dta/libs/ipfs"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	cmdAuditExport = "export"
+	cmdAuditVerify = "verify"
+)
+
+func auditCommand(args []string) error {
+	if len(args) < 1 {
+		return errors.New("usage: milagro audit export|verify [options]")
+	}
+	switch args[0] {
+	case cmdAuditExport:
+		return exportAudit(args[1:])
+	case cmdAuditVerify:
+		return verifyAudit(args[1:])
+	default:
+		return errors.Errorf("invalid audit command: %s", args[0])
+	}
+}
+
+// exportAudit downloads the audit log from the node
+func exportAudit(args []string) error {
+	server, client, err := nodeServer()
+	if err != nil {
+		return err
+	}
+
+	var token, output string
+	fs := flag.NewFlagSet("audit export", flag.ExitOnError)
+	fs.StringVar(&server, "server", server, "Node address")
+	fs.StringVar(&token, "token", "", "Bearer token")
+	fs.StringVar(&output, "o", "", "Output file. Defaults to stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(server, "/")+"/v1/audit", nil)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "export audit log")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("export audit log: %s %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// verifyAudit checks an exported audit log offline
+// The key embedded in the export is not trusted, the node key is resolved from its IDDoc
+func verifyAudit(args []string) error {
+	ipfsAddr := "http://localhost:5001"
+	if cfg, err := config.ParseConfig(configFolder()); err == nil && cfg.IPFS.APIAddress != "" {
+		ipfsAddr = cfg.IPFS.APIAddress
+	}
+
+	var pkHex string
+	fs := flag.NewFlagSet("audit verify", flag.ExitOnError)
+	fs.StringVar(&ipfsAddr, "ipfs", ipfsAddr, "IPFS API address to retrieve the node IDDoc")
+	fs.StringVar(&pkHex, "pk", "", "Expected BLS public key of the node. Defaults to the key of the node IDDoc")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: milagro audit verify [-pk key] [-ipfs address] <file>")
+	}
+
+	b, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	export := &api.ExportAuditResponse{}
+	if err := json.Unmarshal(b, export); err != nil {
+		return errors.Wrap(err, "invalid audit export")
+	}
+
+	var blsPK []byte
+	if pkHex != "" {
+		if blsPK, err = hex.DecodeString(pkHex); err != nil {
+			return errors.Wrap(err, "invalid public key")
+		}
+	} else {
+		if blsPK, err = nodeBLSPublicKey(ipfsAddr, export.NodeID); err != nil {
+			return errors.Wrap(err, "resolve the node key, use -pk to verify offline")
+		}
+	}
+	if export.BLSPublicKey != "" && export.BLSPublicKey != hex.EncodeToString(blsPK) {
+		return errors.New("the export key doesn't match the node key")
+	}
+
+	signedSeq, err := audit.Verify(export.Entries, export.Checkpoints, blsPK)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Node: %s\n", export.NodeID)
+	fmt.Printf("Entries: %v\n", len(export.Entries))
+	fmt.Printf("Checkpoints: %v\n", len(export.Checkpoints))
+	fmt.Printf("Signed up to entry: %v\n", signedSeq)
+	if unsigned := uint64(len(export.Entries)) - signedSeq; unsigned > 0 {
+		fmt.Printf("WARNING: %v entries not covered by a checkpoint\n", unsigned)
+	}
+	return nil
+}
+
+// nodeBLSPublicKey retrieves the BLS public key from the node IDDoc
+func nodeBLSPublicKey(ipfsAddr, nodeID string) ([]byte, error) {
+	if nodeID == "" {
+		return nil, errors.New("no node ID in the export")
+	}
+	connector, err := ipfs.NewAPIConnector(ipfs.NodeAddr(ipfsAddr))
+	if err != nil {
+		return nil, err
+	}
+	idDoc, err := common.RetrieveIDDocFromIPFS(connector, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	return idDoc.BLSPublicKey, nil
+}
@@ -0,0 +1,363 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/libs/keystore"
+	"github.com/apache/incubator-milagro-dta/pkg/config"
+	"github.com/apache/incubator-milagro-dta/pkg/defaultservice"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+	"github.com/pkg/errors"
+)
+
+const (
+	envBackupPassphrase = "MILAGRO_BACKUP_PASSPHRASE"
+
+	backupVersion      = 1
+	backupManifestFile = "manifest.json"
+	backupDocsFolder   = "documents"
+)
+
+// restoredFiles are the node files replaced by a restore
+var restoredFiles = []string{configFile, keysFile, datastoreFile}
+
+// backupManifest describes the content of a backup archive
+type backupManifest struct {
+	Version   int      `json:"version"`
+	NodeID    string   `json:"nodeID"`
+	NodeName  string   `json:"nodeName"`
+	CreatedAt int64    `json:"createdAt"`
+	Documents []string `json:"documents"`
+}
+
+// backupCommand writes the node state to an archive encrypted with a passphrase
+// The datastore can't be opened while the daemon is running
+func backupCommand(args []string) error {
+	var output, passphraseFile string
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	fs.StringVar(&output, "o", fmt.Sprintf("milagro-%s.backup", time.Now().UTC().Format("20060102T150405Z")), "Output file")
+	fs.StringVar(&passphraseFile, "passphrase-file", "", "File with the backup passphrase")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.ParseConfig(configFolder())
+	if err != nil {
+		return err
+	}
+	passphrase, err := readPassphrase(passphraseFile, envBackupPassphrase, "Backup passphrase", true)
+	if err != nil {
+		return err
+	}
+
+	files := map[string][]byte{}
+	for _, name := range []string{configFile, keysFile} {
+		if files[name], err = ioutil.ReadFile(filepath.Join(configFolder(), name)); err != nil {
+			return err
+		}
+	}
+
+	keyStore, err := openKeyStore(filepath.Join(configFolder(), keysFile), cfg.Node.KeystorePassphraseFile)
+	if err != nil {
+		return errors.Wrap(err, "open keystore")
+	}
+
+	store, err := initDataStore(cfg.Node.Datastore)
+	if err != nil {
+		return errors.Wrap(err, "open datastore, stop the daemon before the backup")
+	}
+	defer store.Close()
+
+	snapshot := &bytes.Buffer{}
+	if err := store.Snapshot(snapshot); err != nil {
+		return errors.Wrap(err, "datastore snapshot")
+	}
+	files[datastoreFile] = snapshot.Bytes()
+
+	ipfsConnector, err := initIPFSConnector(cfg)
+	if err != nil {
+		return errors.Wrap(err, "init IPFS connector")
+	}
+	docs, err := defaultservice.BackupDocuments(store, ipfsConnector, keyStore, cfg.Node.NodeID)
+	if err != nil {
+		return err
+	}
+
+	manifest := &backupManifest{
+		Version:   backupVersion,
+		NodeID:    cfg.Node.NodeID,
+		NodeName:  cfg.Node.NodeName,
+		CreatedAt: time.Now().Unix(),
+		Documents: []string{},
+	}
+	for cid, rawDoc := range docs {
+		manifest.Documents = append(manifest.Documents, cid)
+		files[path.Join(backupDocsFolder, cid)] = rawDoc
+	}
+	if files[backupManifestFile], err = json.Marshal(manifest); err != nil {
+		return err
+	}
+
+	archive, err := writeArchive(files)
+	if err != nil {
+		return err
+	}
+	sealed, err := keystore.SealWithPassphrase(passphrase, archive)
+	if err != nil {
+		return errors.Wrap(err, "encrypt backup")
+	}
+	if err := ioutil.WriteFile(output, sealed, 0600); err != nil {
+		return err
+	}
+
+	fmt.Printf("Backup of node %s with %d documents written to %s\n", cfg.Node.NodeID, len(docs), output)
+	return nil
+}
+
+// checkDaemonStopped checks the datastore isn't locked by a running daemon
+func checkDaemonStopped() error {
+	filename := filepath.Join(configFolder(), datastoreFile)
+	if _, err := os.Stat(filename); err != nil {
+		return nil
+	}
+	backend, err := datastore.NewBoltBackend(filename)
+	if err != nil {
+		return err
+	}
+	return backend.Close()
+}
+
+// restoreCommand restores the node state from a backup archive
+// The restored seed must match the node IDDocument
+func restoreCommand(args []string) error {
+	var passphraseFile string
+	var force bool
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	fs.StringVar(&passphraseFile, "passphrase-file", "", "File with the backup passphrase")
+	fs.BoolVar(&force, "force", false, "Overwrite the existing node configuration")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: milagro restore [options] <file>")
+	}
+
+	folder := configFolder()
+	if _, err := os.Stat(filepath.Join(folder, configFile)); err == nil && !force {
+		return errors.Errorf("node already configured in %s, use -force to overwrite it", folder)
+	}
+
+	sealed, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	passphrase, err := readPassphrase(passphraseFile, envBackupPassphrase, "Backup passphrase", false)
+	if err != nil {
+		return err
+	}
+	archive, err := keystore.OpenWithPassphrase(passphrase, sealed)
+	if err != nil {
+		return errors.Wrap(err, "decrypt backup")
+	}
+	files, err := readArchive(archive)
+	if err != nil {
+		return errors.Wrap(err, "invalid backup")
+	}
+
+	manifest := &backupManifest{}
+	if err := json.Unmarshal(files[backupManifestFile], manifest); err != nil {
+		return errors.Wrap(err, "invalid backup manifest")
+	}
+	if manifest.Version != backupVersion {
+		return errors.Errorf("unsupported backup version %d", manifest.Version)
+	}
+
+	if err := checkDaemonStopped(); err != nil {
+		return errors.Wrap(err, "stop the daemon before the restore")
+	}
+
+	//The backup is checked in a staging folder before it replaces the node configuration
+	if err := os.MkdirAll(filepath.Dir(folder), 0700); err != nil {
+		return err
+	}
+	staging, err := ioutil.TempDir(filepath.Dir(folder), ".milagro-restore-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	for _, name := range restoredFiles {
+		if files[name] == nil {
+			return errors.Errorf("invalid backup: missing %s", name)
+		}
+		if err := ioutil.WriteFile(filepath.Join(staging, name), files[name], 0600); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := config.ParseConfig(staging)
+	if err != nil {
+		return err
+	}
+	backend, err := datastore.NewBoltBackend(filepath.Join(staging, datastoreFile))
+	if err != nil {
+		return errors.Wrap(err, "open restored datastore")
+	}
+	if err := backend.Close(); err != nil {
+		return err
+	}
+	ipfsConnector, err := initIPFSConnector(cfg)
+	if err != nil {
+		return errors.Wrap(err, "init IPFS connector")
+	}
+	for _, cid := range manifest.Documents {
+		rawDoc, ok := files[path.Join(backupDocsFolder, cid)]
+		if !ok {
+			return errors.Errorf("invalid backup: missing document %s", cid)
+		}
+		addedCID, err := ipfsConnector.Add(rawDoc)
+		if err != nil {
+			return errors.Wrapf(err, "add document %s", cid)
+		}
+		if addedCID != cid {
+			return errors.Errorf("document %s restored as %s", cid, addedCID)
+		}
+	}
+
+	keyStore, err := openKeyStore(filepath.Join(staging, keysFile), cfg.Node.KeystorePassphraseFile)
+	if err != nil {
+		return errors.Wrap(err, "open restored keystore")
+	}
+	if err := identity.CheckIdentity(cfg.Node.NodeID, cfg.Node.NodeName, ipfsConnector, keyStore); err != nil {
+		return errors.Wrap(err, "Invalid restored node identity")
+	}
+
+	if err := swapRestoredFiles(staging, folder); err != nil {
+		return errors.Wrap(err, "replace the node configuration")
+	}
+
+	fmt.Printf("Node %s restored with %d documents in %s\n", cfg.Node.NodeID, len(manifest.Documents), folder)
+	return nil
+}
+
+// swapRestoredFiles moves the restored files from the staging folder to the node folder
+// The replaced files are moved back if any restored file can't be moved
+func swapRestoredFiles(staging, folder string) error {
+	if err := os.MkdirAll(folder, 0700); err != nil {
+		return err
+	}
+
+	replaced := []string{}
+	moved := []string{}
+	rollback := func() {
+		for _, name := range moved {
+			os.Remove(filepath.Join(folder, name))
+		}
+		for _, name := range replaced {
+			os.Rename(filepath.Join(staging, name+".old"), filepath.Join(folder, name))
+		}
+	}
+
+	for _, name := range restoredFiles {
+		current := filepath.Join(folder, name)
+		if _, err := os.Stat(current); err != nil {
+			continue
+		}
+		if err := os.Rename(current, filepath.Join(staging, name+".old")); err != nil {
+			rollback()
+			return err
+		}
+		replaced = append(replaced, name)
+	}
+	for _, name := range restoredFiles {
+		if err := os.Rename(filepath.Join(staging, name), filepath.Join(folder, name)); err != nil {
+			rollback()
+			return err
+		}
+		moved = append(moved, name)
+	}
+	return nil
+}
+
+// writeArchive writes the files to a gzipped tar archive
+func writeArchive(files map[string][]byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+
+	now := time.Now()
+	for name, content := range files {
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0600,
+			Size:    int64(len(content)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readArchive reads the files of a gzipped tar archive
+func readArchive(archive []byte) (map[string][]byte, error) {
+	gr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gr)
+
+	files := map[string][]byte{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if files[header.Name], err = ioutil.ReadAll(tr); err != nil {
+			return nil, err
+		}
+	}
+}
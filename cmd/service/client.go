@@ -0,0 +1,455 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/transport"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/config"
+	"github.com/go-yaml/yaml"
+	"github.com/pkg/errors"
+)
+
+const (
+	cmdOrderCreate    = "create"
+	cmdOrderList      = "list"
+	cmdOrderGet       = "get"
+	cmdOrderSecret    = "secret"
+	cmdIdentityCreate = "create"
+	cmdIdentityGet    = "get"
+	cmdIdentityList   = "list"
+
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// nodeServer returns the address of the local node and the http client to call it
+func nodeServer() (string, *http.Client, error) {
+	server := "http://localhost:5556"
+	client := http.DefaultClient
+	cfg, err := config.ParseConfig(configFolder())
+	if err != nil {
+		return server, client, nil
+	}
+
+	scheme := "http://"
+	if cfg.HTTP.TLS.CertFile != "" {
+		tlsConfig, err := transport.LoadTLSConfig(cfg.HTTP.TLS.CertFile, cfg.HTTP.TLS.KeyFile, cfg.HTTP.TLS.CAFiles)
+		if err != nil {
+			return "", nil, err
+		}
+		scheme = "https://"
+		client = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	}
+	if strings.HasPrefix(cfg.HTTP.ListenAddr, ":") {
+		server = scheme + "localhost" + cfg.HTTP.ListenAddr
+	}
+	return server, client, nil
+}
+
+// clientCommand is a command calling a running node
+type clientCommand struct {
+	fs      *flag.FlagSet
+	server  string
+	token   string
+	output  string
+	timeout time.Duration
+	http    *http.Client
+}
+
+func newClientCommand(name string) (*clientCommand, error) {
+	server, httpClient, err := nodeServer()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &clientCommand{
+		fs:   flag.NewFlagSet(name, flag.ExitOnError),
+		http: httpClient,
+	}
+	c.fs.StringVar(&c.server, "server", server, "Node address")
+	c.fs.StringVar(&c.token, "token", "", "Bearer token")
+	c.fs.StringVar(&c.output, "o", outputTable, "Output format: table or json")
+	c.fs.DurationVar(&c.timeout, "timeout", 30*time.Second, "Request timeout")
+	return c, nil
+}
+
+// parse parses the args and returns the client of the node
+func (c *clientCommand) parse(args []string) (*api.Client, error) {
+	if err := c.fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if c.output != outputTable && c.output != outputJSON {
+		return nil, errors.Errorf("invalid output: %s", c.output)
+	}
+
+	return api.NewClient(c.server,
+		api.WithToken(c.token),
+		api.WithTimeout(c.timeout),
+		api.WithHTTPClient(c.http),
+	)
+}
+
+// print writes the response as JSON or as the table of the response fields
+func (c *clientCommand) print(response interface{}) error {
+	if c.output == outputJSON {
+		return printJSON(response)
+	}
+	return printFields(response)
+}
+
+// extensionFlag collects the extension parameters as JSON or YAML
+// A value starting with @ is read from the file
+type extensionFlag map[string]string
+
+func (e extensionFlag) String() string {
+	return ""
+}
+
+func (e extensionFlag) Set(v string) error {
+	b := []byte(v)
+	if strings.HasPrefix(v, "@") {
+		var err error
+		if b, err = ioutil.ReadFile(v[1:]); err != nil {
+			return err
+		}
+	}
+
+	ext := map[string]string{}
+	if err := yaml.Unmarshal(b, &ext); err != nil {
+		return errors.Wrap(err, "invalid extension")
+	}
+	for k, v := range ext {
+		e[k] = v
+	}
+	return nil
+}
+
+func orderCommand(args []string) error {
+	if len(args) < 1 {
+		return errors.New("usage: milagro order create|list|get|secret [options]")
+	}
+	switch args[0] {
+	case cmdOrderCreate:
+		return orderCreate(args[1:])
+	case cmdOrderList:
+		return orderList(args[1:])
+	case cmdOrderGet:
+		return orderGet(args[1:])
+	case cmdOrderSecret:
+		return orderSecret(args[1:])
+	default:
+		return errors.Errorf("invalid order command: %s", args[0])
+	}
+}
+
+func identityCommand(args []string) error {
+	if len(args) < 1 {
+		return errors.New("usage: milagro identity create|get|list [options]")
+	}
+	switch args[0] {
+	case cmdIdentityCreate:
+		return identityCreate(args[1:])
+	case cmdIdentityGet:
+		return identityGet(args[1:])
+	case cmdIdentityList:
+		return identityList(args[1:])
+	default:
+		return errors.Errorf("invalid identity command: %s", args[0])
+	}
+}
+
+func orderCreate(args []string) error {
+	cmd, err := newClientCommand("order create")
+	if err != nil {
+		return err
+	}
+	req := &api.OrderRequest{Extension: extensionFlag{}}
+	cmd.fs.StringVar(&req.BeneficiaryIDDocumentCID, "beneficiary", "", "Beneficiary IDDocument CID")
+	cmd.fs.StringVar(&req.PolicyCID, "policy", "", "Redemption policy CID")
+	cmd.fs.Int64Var(&req.NotBefore, "notBefore", 0, "Unix time the order secret can be requested from")
+	cmd.fs.Int64Var(&req.Expiry, "expiry", 0, "Unix time the order expires")
+	cmd.fs.BoolVar(&req.Async, "async", false, "Create the order in the background")
+	cmd.fs.Var(extensionFlag(req.Extension), "ext", "Extension parameters as JSON or YAML, @file to read a file")
+	client, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Order(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	return cmd.print(res)
+}
+
+func orderList(args []string) error {
+	cmd, err := newClientCommand("order list")
+	if err != nil {
+		return err
+	}
+	req := &api.OrderListRequest{}
+	cmd.fs.IntVar(&req.Page, "page", 0, "Page")
+	cmd.fs.IntVar(&req.PerPage, "perPage", 0, "Orders per page")
+	cmd.fs.StringVar(&req.SortBy, "sortBy", "", "Sort field, - prefix for descending")
+	cmd.fs.StringVar(&req.BeneficiaryCID, "beneficiary", "", "Beneficiary IDDocument CID")
+	cmd.fs.StringVar(&req.Type, "type", "", "Order type")
+	cmd.fs.StringVar(&req.Coin, "coin", "", "Coin type")
+	cmd.fs.StringVar(&req.State, "state", "", "Order state")
+	cmd.fs.Int64Var(&req.From, "from", 0, "Created from, Unix time")
+	cmd.fs.Int64Var(&req.To, "to", 0, "Created to, Unix time")
+	cmd.fs.StringVar(&req.Cursor, "cursor", "", "Cursor of the previous page")
+	cmd.fs.BoolVar(&req.Count, "count", false, "Return the total number of orders")
+	client, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.OrderList(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	if cmd.output == outputJSON {
+		return printJSON(res)
+	}
+
+	rows := [][]string{}
+	for _, o := range res.Orders {
+		rows = append(rows, []string{o.OrderReference, o.State, o.Type, strconv.FormatInt(o.Coin, 10), o.BeneficiaryCID, formatTime(o.CreatedAt), formatTime(o.UpdatedAt)})
+	}
+	printTable([]string{"REFERENCE", "STATE", "TYPE", "COIN", "BENEFICIARY", "CREATED", "UPDATED"}, rows)
+	printListFooter(res.Cursor, res.Total, req.Count)
+	return nil
+}
+
+func orderGet(args []string) error {
+	cmd, err := newClientCommand("order get")
+	if err != nil {
+		return err
+	}
+	client, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if cmd.fs.NArg() != 1 {
+		return errors.New("usage: milagro order get [options] <reference>")
+	}
+
+	res, err := client.GetOrder(context.Background(), cmd.fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return cmd.print(res)
+}
+
+func orderSecret(args []string) error {
+	cmd, err := newClientCommand("order secret")
+	if err != nil {
+		return err
+	}
+	req := &api.OrderSecretRequest{Extension: extensionFlag{}}
+	cmd.fs.StringVar(&req.BeneficiaryIDDocumentCID, "beneficiary", "", "Beneficiary IDDocument CID")
+	cmd.fs.Var(extensionFlag(req.Extension), "ext", "Extension parameters as JSON or YAML, @file to read a file")
+	client, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if cmd.fs.NArg() != 1 {
+		return errors.New("usage: milagro order secret [options] <reference>")
+	}
+	req.OrderReference = cmd.fs.Arg(0)
+
+	res, err := client.OrderSecret(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	return cmd.print(res)
+}
+
+func identityCreate(args []string) error {
+	cmd, err := newClientCommand("identity create")
+	if err != nil {
+		return err
+	}
+	req := &api.CreateIdentityRequest{Extension: extensionFlag{}}
+	cmd.fs.Var(extensionFlag(req.Extension), "ext", "Extension parameters as JSON or YAML, @file to read a file")
+	client, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if cmd.fs.NArg() != 1 {
+		return errors.New("usage: milagro identity create [options] <name>")
+	}
+	req.Name = cmd.fs.Arg(0)
+
+	res, err := client.CreateIdentity(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	return cmd.print(res)
+}
+
+func identityGet(args []string) error {
+	cmd, err := newClientCommand("identity get")
+	if err != nil {
+		return err
+	}
+	client, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if cmd.fs.NArg() != 1 {
+		return errors.New("usage: milagro identity get [options] <IDDocumentCID>")
+	}
+
+	res, err := client.GetIdentity(context.Background(), cmd.fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return cmd.print(res)
+}
+
+func identityList(args []string) error {
+	cmd, err := newClientCommand("identity list")
+	if err != nil {
+		return err
+	}
+	req := &api.IdentityListRequest{}
+	cmd.fs.IntVar(&req.Page, "page", 0, "Page")
+	cmd.fs.IntVar(&req.PerPage, "perPage", 0, "Identities per page")
+	cmd.fs.StringVar(&req.SortBy, "sortBy", "", "Sort field, - prefix for descending")
+	cmd.fs.StringVar(&req.Cursor, "cursor", "", "Cursor of the previous page")
+	cmd.fs.BoolVar(&req.Count, "count", false, "Return the total number of identities")
+	client, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.IdentityList(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	if cmd.output == outputJSON {
+		return printJSON(res)
+	}
+
+	rows := [][]string{}
+	for _, id := range res.IDDocumentList {
+		rows = append(rows, []string{id.IDDocumentCID, id.AuthenticationReference, formatTime(id.Timestamp)})
+	}
+	printTable([]string{"IDDOCUMENT CID", "NAME", "CREATED"}, rows)
+	printListFooter(res.Cursor, res.Total, req.Count)
+	return nil
+}
+
+func statusCommand(args []string) error {
+	cmd, err := newClientCommand("status")
+	if err != nil {
+		return err
+	}
+	client, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Status(context.Background())
+	if err != nil {
+		return err
+	}
+	return cmd.print(res)
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printFields prints the JSON fields of the response as a two column table
+// The nested values are printed as JSON
+func printFields(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := [][]string{}
+	for _, name := range names {
+		var value string
+		switch fv := fields[name].(type) {
+		case string:
+			value = fv
+		default:
+			b, _ := json.Marshal(fv)
+			value = string(b)
+		}
+		rows = append(rows, []string{name, value})
+	}
+	printTable(nil, rows)
+	return nil
+}
+
+func printTable(headers []string, rows [][]string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	if headers != nil {
+		fmt.Fprintln(w, strings.Join(headers, "\t"))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+func printListFooter(cursor string, total int, count bool) {
+	if count {
+		fmt.Printf("\nTotal: %v\n", total)
+	}
+	if cursor != "" {
+		fmt.Printf("Next page: -cursor %s\n", cursor)
+	}
+}
+
+func formatTime(t int64) string {
+	if t == 0 {
+		return ""
+	}
+	return time.Unix(t, 0).UTC().Format(time.RFC3339)
+}
@@ -29,9 +29,20 @@ const (
 	envMilagroHome      = "MILAGRO_HOME"
 	milagroConfigFolder = ".milagro"
 	keysFile            = "keys"
+	configFile          = "config.yaml"
+	datastoreFile       = "datastore.dat"
 
-	cmdInit   = "init"
-	cmdDaemon = "daemon"
+	cmdInit     = "init"
+	cmdDaemon   = "daemon"
+	cmdAudit    = "audit"
+	cmdInspect  = "inspect"
+	cmdBackup   = "backup"
+	cmdRestore  = "restore"
+	cmdKeystore = "keystore"
+
+	cmdOrder    = "order"
+	cmdIdentity = "identity"
+	cmdStatus   = "status"
 )
 
 func configFolder() string {
@@ -67,6 +78,27 @@ USAGE
 COMMANDS
 	init	Initialize configuration
 	daemon	Starts the milagro daemon
+	audit	Export the audit log of the node (audit export) or verify an export (audit verify)
+	inspect	Decode a signed envelope from a file, stdin (-) or an IPFS CID and print it as JSON
+	backup	Write the keys, the datastore, the config and the node documents to an archive encrypted with a passphrase
+	restore	Restore a node from a backup archive and check the restored identity
+
+	backup and restore read the passphrase from -passphrase-file, the MILAGRO_BACKUP_PASSPHRASE variable or the terminal.
+	The daemon must be stopped.
+
+	keystore	Encrypt the keys with a passphrase (keystore encrypt) or change the passphrase (keystore passphrase)
+
+	The passphrase of an encrypted keystore is read from -passphrase-file, the MILAGRO_KEYSTORE_PASSPHRASE variable or the terminal.
+	The new passphrase is read from -new-passphrase-file, the MILAGRO_KEYSTORE_NEW_PASSPHRASE variable or the terminal.
+
+CLIENT COMMANDS
+	order		Create (order create), list (order list), get (order get) an order or request its secret (order secret)
+	identity	Create (identity create), get (identity get) or list (identity list) the identities
+	status		Show the status of the node
+
+	The client commands call the node of the local config or -server with the -token bearer token.
+	-o json prints the JSON response instead of a table.
+	-ext sets the extension parameters as JSON or YAML, or from a file with -ext @file.
 	`
 }
 
@@ -78,6 +110,7 @@ func parseConfig(args []string) (*config.Config, error) {
 
 	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
 	fs.StringVar(&(cfg.Plugins.Service), "service", cfg.Plugins.Service, "Service plugin")
+	fs.StringVar(&(cfg.Node.KeystorePassphraseFile), "passphrase-file", cfg.Node.KeystorePassphraseFile, "File with the keystore passphrase")
 
 	if err := fs.Parse(args); err != nil {
 		return nil, err
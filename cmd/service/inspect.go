@@ -0,0 +1,217 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/documents"
+	"github.com/apache/incubator-milagro-dta/libs/ipfs"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/config"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+	"github.com/pkg/errors"
+)
+
+// envelopeInspection is the JSON output of the inspect command
+type envelopeInspection struct {
+	CID       string `json:"cid,omitempty"`
+	SignerCID string `json:"signerCID"`
+	// Signature is valid, invalid or unverified, followed by the reason
+	Signature         string           `json:"signature"`
+	Header            envelopeHeader   `json:"header"`
+	Recipients        []string         `json:"recipients"`
+	BodyType          envelopeBodyType `json:"bodyType"`
+	Body              interface{}      `json:"body,omitempty"`
+	EncryptedBodyType envelopeBodyType `json:"encryptedBodyType"`
+	EncryptedBody     interface{}      `json:"encryptedBody,omitempty"`
+	Decryption        string           `json:"decryption,omitempty"`
+}
+
+type envelopeHeader struct {
+	Version     float32 `json:"version"`
+	DateTime    int64   `json:"dateTime"`
+	Date        string  `json:"date"`
+	PreviousCID string  `json:"previousCID,omitempty"`
+	Nonce       string  `json:"nonce,omitempty"`
+}
+
+type envelopeBodyType struct {
+	Name     string  `json:"name"`
+	TypeCode float32 `json:"typeCode"`
+	Version  float32 `json:"version"`
+}
+
+// inspectCommand decodes a signed envelope offline and prints it as JSON
+// The signer IDDoc is resolved from IPFS to verify the signature
+func inspectCommand(args []string) error {
+	ipfsAddr := "http://localhost:5001"
+	recipientID := ""
+	if cfg, err := config.ParseConfig(configFolder()); err == nil {
+		if cfg.IPFS.APIAddress != "" {
+			ipfsAddr = cfg.IPFS.APIAddress
+		}
+		recipientID = cfg.Node.NodeID
+	}
+
+	var keysPath, pkHex string
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	fs.StringVar(&ipfsAddr, "ipfs", ipfsAddr, "IPFS API address to retrieve the envelope and the signer IDDoc")
+	fs.StringVar(&keysPath, "keys", "", "Keystore file to decrypt the encrypted body")
+	fs.StringVar(&recipientID, "recipient", recipientID, "IDDocument CID of the keystore. Defaults to the node ID")
+	fs.StringVar(&pkHex, "pk", "", "BLS public key of the signer. Defaults to the key of the signer IDDoc")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: milagro inspect [options] <file|-|CID>")
+	}
+
+	// The IPFS connector is created only when needed
+	var connector ipfs.Connector
+	getIPFS := func() (ipfs.Connector, error) {
+		if connector != nil {
+			return connector, nil
+		}
+		var err error
+		connector, err = ipfs.NewAPIConnector(ipfs.NodeAddr(ipfsAddr))
+		return connector, err
+	}
+
+	rawDoc, cid, err := readEnvelope(fs.Arg(0), getIPFS)
+	if err != nil {
+		return err
+	}
+
+	se, err := documents.SmartDecodeEnvelope(rawDoc, cid)
+	if err != nil {
+		return err
+	}
+
+	out := &envelopeInspection{
+		CID:       cid,
+		SignerCID: se.SignerCID,
+		Header: envelopeHeader{
+			Version:     se.Header.Version,
+			DateTime:    se.Header.DateTime,
+			Date:        time.Unix(se.Header.DateTime, 0).UTC().Format(time.RFC3339),
+			PreviousCID: se.Header.PreviousCID,
+			Nonce:       hex.EncodeToString(se.Header.Nonce),
+		},
+		Recipients:        []string{},
+		BodyType:          envelopeBodyType{se.BodyType.Name, se.BodyType.TypeCode, se.BodyType.Version},
+		Body:              se.Body,
+		EncryptedBodyType: envelopeBodyType{se.EncryptedBodyType.Name, se.EncryptedBodyType.TypeCode, se.EncryptedBodyType.Version},
+	}
+	for _, r := range se.Header.Recipients {
+		out.Recipients = append(out.Recipients, r.CID)
+	}
+
+	out.Signature = inspectSignature(se, pkHex, getIPFS)
+
+	if keysPath != "" && se.EncryptedBodyType.Message != nil {
+		if err := decryptEnvelope(se, keysPath, recipientID); err != nil {
+			out.Decryption = "failed: " + err.Error()
+		} else {
+			out.Decryption = "decrypted for " + recipientID
+			out.EncryptedBody = se.EncryptedBody
+		}
+	}
+
+	return printJSON(out)
+}
+
+// readEnvelope reads the raw envelope from stdin with -, from the file or from IPFS if no such file exists
+func readEnvelope(source string, getIPFS func() (ipfs.Connector, error)) (rawDoc []byte, cid string, err error) {
+	if source == "-" {
+		rawDoc, err = ioutil.ReadAll(os.Stdin)
+		return rawDoc, "", err
+	}
+	if _, err := os.Stat(source); err == nil {
+		rawDoc, err = ioutil.ReadFile(source)
+		return rawDoc, "", err
+	}
+
+	connector, err := getIPFS()
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "no file %s, connect to IPFS", source)
+	}
+	rawDoc, err = connector.Get(source)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "retrieve %s", source)
+	}
+	return rawDoc, source, nil
+}
+
+// inspectSignature verifies the signature with the signer BLS key
+// The IDDocuments are signed by their own key
+func inspectSignature(se *documents.SmartEnvelope, pkHex string, getIPFS func() (ipfs.Connector, error)) string {
+	var blsPK []byte
+	switch {
+	case pkHex != "":
+		var err error
+		if blsPK, err = hex.DecodeString(pkHex); err != nil {
+			return "unverified: invalid public key"
+		}
+	case se.SignerCID == "":
+		idDocument, ok := se.Body.(*documents.IDDocument)
+		if !ok {
+			return "unverified: no signer"
+		}
+		blsPK = idDocument.BLSPublicKey
+	default:
+		connector, err := getIPFS()
+		if err != nil {
+			return "unverified: " + err.Error()
+		}
+		signer, err := common.RetrieveIDDocFromIPFS(connector, se.SignerCID)
+		if err != nil {
+			return "unverified: retrieve signer: " + err.Error()
+		}
+		blsPK = signer.BLSPublicKey
+	}
+
+	if err := se.Verify(blsPK); err != nil {
+		return "invalid: " + err.Error()
+	}
+	return "valid"
+}
+
+// decryptEnvelope decrypts the encrypted body with the SIKE key of the keystore seed
+func decryptEnvelope(se *documents.SmartEnvelope, keysPath, recipientID string) error {
+	if _, err := os.Stat(keysPath); err != nil {
+		return err
+	}
+	keyStore, err := openKeyStore(keysPath, "")
+	if err != nil {
+		return err
+	}
+	seed, err := keyStore.Get("seed")
+	if err != nil {
+		return err
+	}
+	_, sikeSK, err := identity.GenerateSIKEKeys(seed)
+	if err != nil {
+		return err
+	}
+	return se.Decrypt(sikeSK, recipientID)
+}
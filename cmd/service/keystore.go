@@ -0,0 +1,125 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/apache/incubator-milagro-dta/libs/keystore"
+	"github.com/pkg/errors"
+)
+
+const (
+	envKeystorePassphrase    = "MILAGRO_KEYSTORE_PASSPHRASE"
+	envKeystoreNewPassphrase = "MILAGRO_KEYSTORE_NEW_PASSPHRASE"
+
+	cmdKeystoreEncrypt    = "encrypt"
+	cmdKeystorePassphrase = "passphrase"
+)
+
+// openKeyStore opens the key file, the passphrase is read only if the file is encrypted
+func openKeyStore(filePath, passphraseFile string) (keystore.Store, error) {
+	encrypted, err := keystore.IsEncryptedFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !encrypted {
+		return keystore.NewFileStore(filePath)
+	}
+
+	passphrase, err := readPassphrase(passphraseFile, envKeystorePassphrase, "Keystore passphrase", false)
+	if err != nil {
+		return nil, err
+	}
+	return keystore.NewEncryptedFileStore(filePath, passphrase)
+}
+
+func keystoreCommand(args []string) error {
+	if len(args) < 1 {
+		return errors.New("usage: milagro keystore encrypt|passphrase [options]")
+	}
+	switch args[0] {
+	case cmdKeystoreEncrypt:
+		return encryptKeystore(args[1:])
+	case cmdKeystorePassphrase:
+		return changeKeystorePassphrase(args[1:])
+	default:
+		return errors.Errorf("invalid keystore command: %s", args[0])
+	}
+}
+
+// encryptKeystore migrates the plain key file to a file encrypted with a passphrase
+func encryptKeystore(args []string) error {
+	var passphraseFile string
+	fs := flag.NewFlagSet("keystore encrypt", flag.ExitOnError)
+	fs.StringVar(&passphraseFile, "passphrase-file", "", "File with the new keystore passphrase")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// The daemon would store the keys in plain text again
+	if err := checkDaemonStopped(); err != nil {
+		return errors.Wrap(err, "stop the daemon before encrypting the keystore")
+	}
+
+	passphrase, err := readPassphrase(passphraseFile, envKeystorePassphrase, "New keystore passphrase", true)
+	if err != nil {
+		return err
+	}
+	filePath := filepath.Join(configFolder(), keysFile)
+	if err := keystore.EncryptFile(filePath, passphrase); err != nil {
+		return err
+	}
+
+	fmt.Printf("Keystore %s encrypted\n", filePath)
+	return nil
+}
+
+// changeKeystorePassphrase encrypts the key file with a new passphrase
+func changeKeystorePassphrase(args []string) error {
+	var passphraseFile, newPassphraseFile string
+	fs := flag.NewFlagSet("keystore passphrase", flag.ExitOnError)
+	fs.StringVar(&passphraseFile, "passphrase-file", "", "File with the current keystore passphrase")
+	fs.StringVar(&newPassphraseFile, "new-passphrase-file", "", "File with the new keystore passphrase")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// The daemon would store the keys with the old passphrase
+	if err := checkDaemonStopped(); err != nil {
+		return errors.Wrap(err, "stop the daemon before changing the passphrase")
+	}
+
+	passphrase, err := readPassphrase(passphraseFile, envKeystorePassphrase, "Current keystore passphrase", false)
+	if err != nil {
+		return err
+	}
+	newPassphrase, err := readPassphrase(newPassphraseFile, envKeystoreNewPassphrase, "New keystore passphrase", true)
+	if err != nil {
+		return err
+	}
+	filePath := filepath.Join(configFolder(), keysFile)
+	if err := keystore.ChangePassphrase(filePath, passphrase, newPassphrase); err != nil {
+		return err
+	}
+
+	fmt.Printf("Passphrase of keystore %s changed\n", filePath)
+	return nil
+}
@@ -37,6 +37,7 @@ import (
 	"github.com/apache/incubator-milagro-dta/libs/logger"
 	"github.com/apache/incubator-milagro-dta/libs/transport"
 	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
 	"github.com/apache/incubator-milagro-dta/pkg/config"
 	"github.com/apache/incubator-milagro-dta/pkg/defaultservice"
 	"github.com/apache/incubator-milagro-dta/pkg/endpoints"
@@ -78,17 +79,7 @@ func initConfig(args []string) error {
 	config.Init(configFolder(), cfg)
 
 	logger.Info("IPFS connector type: %s", cfg.IPFS.Connector)
-	var ipfsConnector ipfs.Connector
-	switch cfg.IPFS.Connector {
-	case "api":
-		ipfsConnector, err = ipfs.NewAPIConnector(ipfs.NodeAddr(cfg.IPFS.APIAddress))
-	case "embedded":
-		ipfsConnector, err = ipfs.NewNodeConnector(
-			ipfs.AddLocalAddress(cfg.IPFS.ListenAddress),
-			ipfs.AddBootstrapPeer(cfg.IPFS.Bootstrap...),
-			ipfs.WithLevelDatastore(filepath.Join(configFolder(), "ipfs-data")),
-		)
-	}
+	ipfsConnector, err := initIPFSConnector(cfg)
 	if err != nil {
 		return errors.Wrap(err, "init IPFS connector")
 	}
@@ -151,23 +142,13 @@ func startDaemon(args []string) error {
 	}
 
 	logger.Info("IPFS connector type: %s", cfg.IPFS.Connector)
-	var ipfsConnector ipfs.Connector
-	switch cfg.IPFS.Connector {
-	case "api":
-		ipfsConnector, err = ipfs.NewAPIConnector(ipfs.NodeAddr(cfg.IPFS.APIAddress))
-	case "embedded":
-		ipfsConnector, err = ipfs.NewNodeConnector(
-			ipfs.AddLocalAddress(cfg.IPFS.ListenAddress),
-			ipfs.AddBootstrapPeer(cfg.IPFS.Bootstrap...),
-			ipfs.WithLevelDatastore(filepath.Join(configFolder(), "ipfs-data")),
-		)
-	}
+	ipfsConnector, err := initIPFSConnector(cfg)
 	if err != nil {
 		return errors.Wrap(err, "init IPFS connector")
 	}
-	keyStore, err := keystore.NewFileStore(filepath.Join(configFolder(), keysFile))
+	keyStore, err := openKeyStore(filepath.Join(configFolder(), keysFile), cfg.Node.KeystorePassphraseFile)
 	if err != nil {
-		return err
+		return errors.Wrap(err, "open keystore")
 	}
 
 	// Setup Endpoint authorizer
@@ -187,11 +168,39 @@ func startDaemon(args []string) error {
 		}
 	}
 
-	masterFiduciaryServer, err := api.NewHTTPClient(cfg.Node.MasterFiduciaryServer, logger)
+	// Setup the node to node request signatures
+	keySeed, err := keyStore.Get("seed")
+	if err != nil {
+		return errors.Wrap(err, "load node key")
+	}
+	_, blsSK, err := identity.GenerateBLSKeys(keySeed)
+	if err != nil {
+		return errors.Wrap(err, "load node key")
+	}
+	requestSigner := common.NewNodeRequestSigner(cfg.Node.NodeID, blsSK)
+	nodeAuthorizer := common.NewNodeRequestAuthorizer(ipfsConnector, append([]string{cfg.Node.NodeID}, cfg.Node.AllowedNodes...)...)
+	nodeAuthorizer.BindTLSIdentity = cfg.HTTP.TLS.BindIdentity
+
+	// Setup TLS
+	tlsConfig, err := loadTLSConfig(cfg.HTTP.TLS, cfg.Node.NodeID)
+	if err != nil {
+		return errors.Wrap(err, "init TLS")
+	}
+
+	masterFiduciaryServer, err := api.NewSignedHTTPClient(cfg.Node.MasterFiduciaryServer, requestSigner, logger, nodeClientOptions(tlsConfig, cfg.HTTP.TLS, cfg.Node.MasterFiduciaryNodeID)...)
 	if err != nil {
 		return errors.Wrap(err, "init custody client")
 	}
 
+	fiduciaryServers := map[string]api.ClientService{}
+	for _, fiduciary := range cfg.Node.Fiduciaries {
+		fiduciaryServer, err := api.NewSignedHTTPClient(fiduciary.Server, requestSigner, logger, nodeClientOptions(tlsConfig, cfg.HTTP.TLS, fiduciary.NodeID)...)
+		if err != nil {
+			return errors.Wrapf(err, "init fiduciary client %s", fiduciary.NodeID)
+		}
+		fiduciaryServers[fiduciary.NodeID] = fiduciaryServer
+	}
+
 	//The Server must have a valid ID before starting up
 	svcPlugin := plugins.FindServicePlugin(cfg.Plugins.Service)
 	if svcPlugin == nil {
@@ -206,9 +215,10 @@ func startDaemon(args []string) error {
 		defaultservice.WithKeyStore(keyStore),
 		defaultservice.WithIPFS(ipfsConnector),
 		defaultservice.WithMasterFiduciary(masterFiduciaryServer),
+		defaultservice.WithFiduciaries(fiduciaryServers, cfg.Node.FiduciaryThreshold),
 		defaultservice.WithConfig(cfg),
 	); err != nil {
-		return errors.Errorf("init service plugin %s", cfg.Plugins.Service)
+		return errors.Wrapf(err, "init service plugin %s", cfg.Plugins.Service)
 	}
 	logger.Info("Service plugin loaded: %s", svcPlugin.Name())
 
@@ -230,15 +240,32 @@ func startDaemon(args []string) error {
 	// Stop chan
 	errChan := make(chan error)
 
+	// Start the background workers
+	stopQueue := make(chan struct{})
+	go svcPlugin.RunOrderQueue(stopQueue)
+	go svcPlugin.RunOrderReaper(stopQueue)
+	go svcPlugin.RunAuditSigner(stopQueue)
+	go svcPlugin.RunWebhookQueue(stopQueue)
+
 	logger.Info("NODE ID (IPFS):  %v", svcPlugin.NodeID())
 	logger.Info("Node Type: %v", strings.ToLower(cfg.Node.NodeType))
-	endpoints := endpoints.Endpoints(svcPlugin, cfg.HTTP.CorsAllow, authorizer, logger, cfg.Node.NodeType, svcPlugin)
+	endpoints := endpoints.Endpoints(svcPlugin, cfg.HTTP.CorsAllow, authorizer, nodeAuthorizer, logger, cfg.Node.NodeType, svcPlugin)
 	httpHandler := transport.NewHTTPHandler(endpoints, logger, duration)
 	// Start the application http server
+	server := &http.Server{
+		Addr:      cfg.HTTP.ListenAddr,
+		Handler:   httpHandler,
+		TLSConfig: tlsConfig,
+	}
 	go func() {
 		logger.Info("starting listener on %v, custody server %v", cfg.HTTP.ListenAddr, cfg.Node.MasterFiduciaryServer)
 		// httpHandler.PathPrefix("/api/").Handler(http.St:ripPrefix("/api/", http.FileServer(http.Dir("./swagger"))))
-		errChan <- http.ListenAndServe(cfg.HTTP.ListenAddr, httpHandler)
+		if tlsConfig != nil {
+			logger.Info("TLS enabled, client certificate required: %v", cfg.HTTP.TLS.ClientAuth)
+			errChan <- server.ListenAndServeTLS("", "")
+			return
+		}
+		errChan <- server.ListenAndServe()
 	}()
 
 	if cfg.HTTP.MetricsAddr != "" {
@@ -259,6 +286,7 @@ func startDaemon(args []string) error {
 
 	stopErr := <-errChan
 	_ = logger.Log("exit", stopErr.Error())
+	close(stopQueue)
 	return store.Close()
 }
 
@@ -267,7 +295,7 @@ func initDataStore(ds string) (*datastore.Store, error) {
 	var err error
 	switch ds {
 	case "embedded":
-		dsBackend, err = datastore.NewBoltBackend(filepath.Join(configFolder(), "datastore.dat"))
+		dsBackend, err = datastore.NewBoltBackend(filepath.Join(configFolder(), datastoreFile))
 	default:
 		return nil, errors.Errorf("invalid datastore: %s", ds)
 	}
@@ -279,6 +307,21 @@ func initDataStore(ds string) (*datastore.Store, error) {
 	return store, err
 }
 
+func initIPFSConnector(cfg *config.Config) (ipfs.Connector, error) {
+	switch cfg.IPFS.Connector {
+	case "api":
+		return ipfs.NewAPIConnector(ipfs.NodeAddr(cfg.IPFS.APIAddress))
+	case "embedded":
+		return ipfs.NewNodeConnector(
+			ipfs.AddLocalAddress(cfg.IPFS.ListenAddress),
+			ipfs.AddBootstrapPeer(cfg.IPFS.Bootstrap...),
+			ipfs.WithLevelDatastore(filepath.Join(configFolder(), "ipfs-data")),
+		)
+	default:
+		return nil, errors.Errorf("invalid IPFS connector: %s", cfg.IPFS.Connector)
+	}
+}
+
 func main() {
 	var err error
 	cmd, args := parseCommand()
@@ -289,6 +332,22 @@ func main() {
 		err = initConfig(args)
 	case cmdDaemon:
 		err = startDaemon(args)
+	case cmdAudit:
+		err = auditCommand(args)
+	case cmdInspect:
+		err = inspectCommand(args)
+	case cmdBackup:
+		err = backupCommand(args)
+	case cmdRestore:
+		err = restoreCommand(args)
+	case cmdKeystore:
+		err = keystoreCommand(args)
+	case cmdOrder:
+		err = orderCommand(args)
+	case cmdIdentity:
+		err = identityCommand(args)
+	case cmdStatus:
+		err = statusCommand(args)
 	}
 
 	if err != nil {
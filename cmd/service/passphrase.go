@@ -0,0 +1,76 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// readPassphrase reads the passphrase from the file, the environment variable or the terminal
+// The passphrase typed in the terminal is asked twice if confirm is set
+func readPassphrase(file, envName, prompt string, confirm bool) ([]byte, error) {
+	var passphrase []byte
+	switch {
+	case file != "":
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "read passphrase file")
+		}
+		passphrase = bytes.TrimRight(b, "\r\n")
+	case os.Getenv(envName) != "":
+		passphrase = []byte(os.Getenv(envName))
+	default:
+		var err error
+		if passphrase, err = promptPassphrase(prompt); err != nil {
+			return nil, errors.Wrapf(err, "no passphrase file or %s variable", envName)
+		}
+		if confirm {
+			again, err := promptPassphrase("Repeat the passphrase")
+			if err != nil {
+				return nil, err
+			}
+			if !bytes.Equal(passphrase, again) {
+				return nil, errors.New("passphrases don't match")
+			}
+		}
+	}
+
+	if len(passphrase) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+	return passphrase, nil
+}
+
+// promptPassphrase reads the passphrase from the terminal without echo
+func promptPassphrase(prompt string) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return nil, errors.New("stdin is not a terminal")
+	}
+
+	fmt.Fprint(os.Stderr, prompt+": ")
+	passphrase, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return passphrase, err
+}
@@ -0,0 +1,70 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	"github.com/apache/incubator-milagro-dta/libs/transport"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/config"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/pkg/errors"
+)
+
+// loadTLSConfig returns the TLS config of the node or nil if TLS isn't enabled
+// The certificate of the node is used by the server and by the clients to the other nodes
+func loadTLSConfig(cfg config.TLSConfig, nodeID string) (*tls.Config, error) {
+	if cfg.CertFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig, err := transport.LoadTLSConfig(cfg.CertFile, cfg.KeyFile, cfg.CAFiles)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ClientAuth {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	if cfg.BindIdentity {
+		cert, err := x509.ParseCertificate(tlsConfig.Certificates[0].Certificate[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "parse certificate")
+		}
+		if err := common.CheckNodeCertificate(cert, nodeID); err != nil {
+			return nil, errors.Wrapf(err, "add the URI %s to the certificate", common.NodeCertificateURI(nodeID))
+		}
+	}
+	return tlsConfig, nil
+}
+
+// nodeClientOptions returns the http client options to call the node
+// The server certificate must be bound to the node if the identity binding is enabled
+func nodeClientOptions(tlsConfig *tls.Config, cfg config.TLSConfig, nodeID string) []httptransport.ClientOption {
+	if tlsConfig == nil {
+		return nil
+	}
+	if cfg.BindIdentity {
+		tlsConfig = common.BindNodeTLSConfig(tlsConfig, nodeID)
+	}
+	return []httptransport.ClientOption{transport.ClientTLS(tlsConfig)}
+}
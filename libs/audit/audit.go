@@ -0,0 +1,299 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+/*
+Package audit - append-only, hash-chained log of the custody operations
+*/
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/crypto"
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/pkg/errors"
+)
+
+const (
+	entryDatatype      = "audit"
+	checkpointDatatype = "auditCheckpoint"
+	seqIndex           = "seq"
+
+	// OutcomeSuccess is the outcome of a successful operation
+	OutcomeSuccess = "success"
+	// OutcomeFailure is the outcome of a failed operation
+	OutcomeFailure = "failure"
+)
+
+var (
+	// ErrEmptyLog is returned when there are no entries to sign
+	ErrEmptyLog = errors.New("empty audit log")
+	// ErrBrokenChain is returned when an entry doesn't follow the previous one
+	ErrBrokenChain = errors.New("broken audit chain")
+	// ErrInvalidCheckpoint is returned when a checkpoint signature or hash doesn't match
+	ErrInvalidCheckpoint = errors.New("invalid audit checkpoint")
+)
+
+// Entry is a record of the audit log
+// Each entry is chained to the previous one by its hash
+type Entry struct {
+	Seq            uint64          `json:"seq"`
+	Timestamp      int64           `json:"timestamp"`
+	Operation      string          `json:"operation"`
+	UserClaims     json.RawMessage `json:"userClaims,omitempty"`
+	OrderReference string          `json:"orderReference,omitempty"`
+	CIDs           []string        `json:"cids,omitempty"`
+	Outcome        string          `json:"outcome"`
+	Error          string          `json:"error,omitempty"`
+	PrevHash       string          `json:"prevHash"`
+	Hash           string          `json:"hash"`
+}
+
+// ComputeHash returns the hash of the entry content and the previous hash
+func (e *Entry) ComputeHash() (string, error) {
+	content := *e
+	content.Hash = ""
+	b, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// Checkpoint is the chain head signed by the node
+type Checkpoint struct {
+	Seq       uint64 `json:"seq"`
+	Hash      string `json:"hash"`
+	Timestamp int64  `json:"timestamp"`
+	SignerCID string `json:"signerCID"`
+	Signature string `json:"signature"`
+}
+
+// message returns the signed content of the checkpoint
+func (c *Checkpoint) message() []byte {
+	return []byte(fmt.Sprintf("%s:%d:%s:%d", c.SignerCID, c.Seq, c.Hash, c.Timestamp))
+}
+
+// Verify checks the checkpoint signature with the signer BLS public key
+func (c *Checkpoint) Verify(blsPK []byte) error {
+	signature, err := hex.DecodeString(c.Signature)
+	if err != nil {
+		return ErrInvalidCheckpoint
+	}
+	if rc := crypto.BLSVerify(c.message(), blsPK, signature); rc != 0 {
+		return ErrInvalidCheckpoint
+	}
+	return nil
+}
+
+// Log is the audit log persisted in the store
+type Log struct {
+	store *datastore.Store
+	mutex sync.Mutex
+}
+
+// NewLog creates an audit log stored in the store
+func NewLog(store *datastore.Store) *Log {
+	return &Log{
+		store: store,
+	}
+}
+
+// Append adds the entry at the end of the chain
+// The sequence number and hashes of the entry are set by the log
+func (l *Log) Append(e *Entry) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	head, err := l.head()
+	if err != nil {
+		return err
+	}
+	e.Seq = 1
+	e.PrevHash = ""
+	if head != nil {
+		e.Seq = head.Seq + 1
+		e.PrevHash = head.Hash
+	}
+	if e.Timestamp == 0 {
+		e.Timestamp = time.Now().Unix()
+	}
+	if e.Hash, err = e.ComputeHash(); err != nil {
+		return err
+	}
+
+	key := seqKey(e.Seq)
+	return l.store.Set(entryDatatype, key, e, map[string]string{seqIndex: key})
+}
+
+// Entries returns the entries from the sequence number
+// limit is the maximum number of entries returned, 0 returns all of them
+func (l *Log) Entries(from uint64, limit int) ([]*Entry, error) {
+	skip := 0
+	if from > 1 {
+		skip = int(from - 1)
+	}
+	keys, err := l.store.ListKeys(entryDatatype, seqIndex, skip, limit, false)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*Entry{}
+	for _, key := range keys {
+		e := &Entry{}
+		if err := l.store.Get(entryDatatype, key, e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Sign signs the chain head with the node BLS key
+// The last checkpoint is returned if the chain didn't change
+func (l *Log) Sign(signerCID string, blsSK []byte) (*Checkpoint, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	head, err := l.head()
+	if err != nil {
+		return nil, err
+	}
+	if head == nil {
+		return nil, ErrEmptyLog
+	}
+
+	last, err := l.lastCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+	if last != nil && last.Seq == head.Seq && last.SignerCID == signerCID {
+		return last, nil
+	}
+
+	c := &Checkpoint{
+		Seq:       head.Seq,
+		Hash:      head.Hash,
+		Timestamp: time.Now().Unix(),
+		SignerCID: signerCID,
+	}
+	rc, signature := crypto.BLSSign(c.message(), blsSK)
+	if rc != 0 {
+		return nil, fmt.Errorf("Failed to sign audit checkpoint: %v", rc)
+	}
+	c.Signature = hex.EncodeToString(signature)
+
+	key := seqKey(c.Seq)
+	if err := l.store.Set(checkpointDatatype, key, c, map[string]string{seqIndex: key}); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Checkpoints returns all the signed chain heads
+func (l *Log) Checkpoints() ([]*Checkpoint, error) {
+	keys, err := l.store.ListKeys(checkpointDatatype, seqIndex, 0, 0, false)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoints := []*Checkpoint{}
+	for _, key := range keys {
+		c := &Checkpoint{}
+		if err := l.store.Get(checkpointDatatype, key, c); err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, c)
+	}
+	return checkpoints, nil
+}
+
+// head returns the last entry or nil if the log is empty
+func (l *Log) head() (*Entry, error) {
+	keys, err := l.store.ListKeys(entryDatatype, seqIndex, 0, 1, true)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	e := &Entry{}
+	if err := l.store.Get(entryDatatype, keys[0], e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// lastCheckpoint returns the last checkpoint or nil if the chain was never signed
+func (l *Log) lastCheckpoint() (*Checkpoint, error) {
+	keys, err := l.store.ListKeys(checkpointDatatype, seqIndex, 0, 1, true)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	c := &Checkpoint{}
+	if err := l.store.Get(checkpointDatatype, keys[0], c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Verify checks the chain of entries and the checkpoints signed with blsPK
+// The entries must be consecutive, starting from the first one of the log
+// Returns the sequence number of the last entry covered by a checkpoint
+func Verify(entries []*Entry, checkpoints []*Checkpoint, blsPK []byte) (signedSeq uint64, err error) {
+	hashes := map[uint64]string{}
+	prevHash := ""
+	for i, e := range entries {
+		if e.Seq != uint64(i+1) || e.PrevHash != prevHash {
+			return 0, errors.Wrapf(ErrBrokenChain, "entry %v", e.Seq)
+		}
+		hash, err := e.ComputeHash()
+		if err != nil {
+			return 0, err
+		}
+		if hash != e.Hash {
+			return 0, errors.Wrapf(ErrBrokenChain, "entry %v hash", e.Seq)
+		}
+		hashes[e.Seq] = e.Hash
+		prevHash = e.Hash
+	}
+
+	for _, c := range checkpoints {
+		if err := c.Verify(blsPK); err != nil {
+			return 0, errors.Wrapf(err, "checkpoint %v", c.Seq)
+		}
+		hash, ok := hashes[c.Seq]
+		if !ok {
+			return 0, errors.Wrapf(ErrInvalidCheckpoint, "checkpoint %v: no entry", c.Seq)
+		}
+		if hash != c.Hash {
+			return 0, errors.Wrapf(ErrInvalidCheckpoint, "checkpoint %v hash", c.Seq)
+		}
+		if c.Seq > signedSeq {
+			signedSeq = c.Seq
+		}
+	}
+	return signedSeq, nil
+}
+
+// seqKey returns a sortable key of the sequence number
+func seqKey(seq uint64) string {
+	return fmt.Sprintf("%020d", seq)
+}
@@ -0,0 +1,114 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/apache/incubator-milagro-dta/libs/crypto"
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+)
+
+func newTestLog(t *testing.T) (*Log, func()) {
+	f, err := ioutil.TempFile("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	b, err := datastore.NewBoltBackend(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := datastore.NewStore(datastore.WithBackend(b), datastore.WithCodec(datastore.NewGOBCodec()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewLog(store), func() {
+		store.Close()
+		os.Remove(f.Name())
+	}
+}
+
+func TestLog(t *testing.T) {
+	l, cleanup := newTestLog(t)
+	defer cleanup()
+
+	if _, err := l.Sign("signer", nil); err != ErrEmptyLog {
+		t.Fatalf("Expected: %v, found: %v", ErrEmptyLog, err)
+	}
+
+	for _, op := range []string{"Order", "FulfillOrder", "OrderSecret"} {
+		e := &Entry{
+			Operation:      op,
+			UserClaims:     []byte(`{"sub":"user"}`),
+			OrderReference: "ref",
+			CIDs:           []string{"cid"},
+			Outcome:        OutcomeSuccess,
+		}
+		if err := l.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, blsPK, blsSK := crypto.BLSKeys([]byte("0123456789abcdef0123456789abcdef0123456789abcdef"), nil)
+	c, err := l.Sign("signer", blsSK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Seq != 3 {
+		t.Fatalf("invalid checkpoint. Expected: 3, found: %v", c.Seq)
+	}
+
+	entries, err := l.Entries(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("invalid number of entries. Expected: 3, found: %v", len(entries))
+	}
+	checkpoints, err := l.Checkpoints()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signedSeq, err := Verify(entries, checkpoints, blsPK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signedSeq != 3 {
+		t.Fatalf("invalid signed entry. Expected: 3, found: %v", signedSeq)
+	}
+
+	entries[1].Outcome = OutcomeFailure
+	if _, err := Verify(entries, checkpoints, blsPK); err == nil {
+		t.Fatal("tampered entry not detected")
+	}
+	entries[1].Outcome = OutcomeSuccess
+
+	if _, err := Verify(entries[1:], checkpoints, blsPK); err == nil {
+		t.Fatal("missing entry not detected")
+	}
+
+	checkpoints[0].Hash = entries[0].Hash
+	if _, err := Verify(entries, checkpoints, blsPK); err == nil {
+		t.Fatal("tampered checkpoint not detected")
+	}
+}
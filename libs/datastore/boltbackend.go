@@ -19,9 +19,13 @@ package datastore
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/gob"
 	"fmt"
+	"io"
 	"math/rand"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -71,14 +75,9 @@ func (bb *BoltBackend) Set(datatype, key string, value []byte, indexData map[str
 			return errors.Wrap(err, "failed to put data")
 		}
 
-		// Delete the previous indexes if they exists
-		if err := deleteIndexes(key, bk); err != nil {
-			return errors.Wrap(err, "delete old indexes")
-		}
-
-		// Perform indexing
-		if err := createIndexes(key, indexData, bk); err != nil {
-			return errors.Wrap(err, "create indexes")
+		// Replace the indexes that changed, the unchanged ones keep their position
+		if err := updateIndexes(key, indexData, bk); err != nil {
+			return errors.Wrap(err, "update indexes")
 		}
 
 		return nil
@@ -175,47 +174,328 @@ func (bb *BoltBackend) ListKeys(datatype, index string, skip, limit int, reverse
 	return
 }
 
+// Query lists the keys selected by the index values
+// The cursor of the next page is the index value key of the last key, the
+// query seeks to it directly
+// The keys with the Where values are read from the Where index, not from the whole Index
+func (bb *BoltBackend) Query(datatype string, q *Query) (*QueryResult, error) {
+	var after []byte
+	if q.Cursor != "" {
+		var err error
+		if after, err = decodeCursor(q.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &QueryResult{
+		Keys: []string{},
+	}
+	err := bb.db.View(func(tx *bolt.Tx) error {
+		// Get the root bucket
+		bk := tx.Bucket([]byte(datatype))
+		if bk == nil {
+			return nil
+		}
+		indexBk := bk.Bucket([]byte(fmt.Sprintf("index-%s", q.Index)))
+		if indexBk == nil {
+			return nil
+		}
+
+		var k, v []byte
+		var next iterFunc
+		if len(q.Where) > 0 {
+			// The keys with the Where values are read from their index
+			entries, err := filterQuery(bk, q)
+			if err != nil {
+				return err
+			}
+			if q.Count {
+				result.Total = len(entries)
+			}
+			k, v, next = seekEntries(entries, q, after)
+		} else {
+			if q.Count {
+				result.Total = countQuery(indexBk.Cursor(), q)
+			}
+			k, v, next = seekQuery(indexBk.Cursor(), q, after)
+		}
+
+		var last []byte
+		skipped := 0
+		for ; k != nil && inQueryRange(k, q); k, v = next() {
+			// Another key follows the page
+			if last != nil {
+				result.Cursor = encodeCursor(last)
+				break
+			}
+
+			if skipped < q.Skip {
+				skipped++
+				continue
+			}
+			result.Keys = append(result.Keys, string(v))
+			if q.Limit > 0 && len(result.Keys) >= q.Limit {
+				last = append([]byte{}, k...)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// seekQuery moves the cursor to the first index value key of the query
+// after is the index value key of the last key of the previous page
+func seekQuery(c *bolt.Cursor, q *Query, after []byte) (k, v []byte, next iterFunc) {
+	from, to := []byte(q.From), []byte(q.To)
+
+	switch q.Reverse {
+	default:
+		if after != nil && bytes.Compare(after, from) >= 0 {
+			if k, v = c.Seek(after); k != nil && bytes.Equal(k, after) {
+				k, v = c.Next()
+			}
+		} else if len(from) > 0 {
+			k, v = c.Seek(from)
+		} else {
+			k, v = c.First()
+		}
+		return k, v, c.Next
+	case true:
+		bound := to
+		if after != nil && (len(to) == 0 || bytes.Compare(after, to) < 0) {
+			bound = after
+		}
+		if len(bound) > 0 {
+			if k, v = c.Seek(bound); k != nil {
+				k, v = c.Prev()
+			} else {
+				k, v = c.Last()
+			}
+		} else {
+			k, v = c.Last()
+		}
+		return k, v, c.Prev
+	}
+}
+
+// indexEntry is a key with its index value key
+type indexEntry struct {
+	valueKey []byte
+	key      []byte
+}
+
+// filterQuery returns the keys with the Where values in the From and To range
+// sorted by their index value key
+// The keys are read from the index of the Where value with the fewest keys,
+// the keys with other values aren't visited
+func filterQuery(rootBucket *bolt.Bucket, q *Query) ([]indexEntry, error) {
+	var filterBk *bolt.Bucket
+	var filterPrefix []byte
+	filterCount := -1
+	for indexName, v := range q.Where {
+		indexBk := rootBucket.Bucket([]byte(fmt.Sprintf("index-%s", indexName)))
+		if indexBk == nil {
+			// No key has the index
+			return nil, nil
+		}
+		prefix := []byte(v + "\x00")
+		count := 0
+		c := indexBk.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			count++
+		}
+		if filterCount < 0 || count < filterCount {
+			filterBk, filterPrefix, filterCount = indexBk, prefix, count
+		}
+	}
+
+	entries := []indexEntry{}
+	c := filterBk.Cursor()
+	for k, key := c.Seek(filterPrefix); k != nil && bytes.HasPrefix(k, filterPrefix); k, key = c.Next() {
+		indexData, err := keyIndexes(string(key), rootBucket)
+		if err != nil {
+			return nil, err
+		}
+		valueKey, ok := indexData[q.Index]
+		if !ok || !inQueryRange([]byte(valueKey), q) {
+			continue
+		}
+		if matchIndexData(indexData, q.Where) {
+			entries = append(entries, indexEntry{valueKey: []byte(valueKey), key: append([]byte{}, key...)})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].valueKey, entries[j].valueKey) < 0
+	})
+	return entries, nil
+}
+
+// seekEntries moves to the first sorted entry of the query
+// after is the index value key of the last key of the previous page
+func seekEntries(entries []indexEntry, q *Query, after []byte) (k, v []byte, next iterFunc) {
+	i, step := 0, 1
+	if q.Reverse {
+		i, step = len(entries)-1, -1
+	}
+	if after != nil {
+		if q.Reverse {
+			i = sort.Search(len(entries), func(j int) bool { return bytes.Compare(entries[j].valueKey, after) >= 0 }) - 1
+		} else {
+			i = sort.Search(len(entries), func(j int) bool { return bytes.Compare(entries[j].valueKey, after) > 0 })
+		}
+	}
+
+	next = func() ([]byte, []byte) {
+		if i < 0 || i >= len(entries) {
+			return nil, nil
+		}
+		e := entries[i]
+		i += step
+		return e.valueKey, e.key
+	}
+	k, v = next()
+	return k, v, next
+}
+
+// inQueryRange returns true if the index value key is in the From and To range
+func inQueryRange(k []byte, q *Query) bool {
+	if q.From != "" && bytes.Compare(k, []byte(q.From)) < 0 {
+		return false
+	}
+	if q.To != "" && bytes.Compare(k, []byte(q.To)) >= 0 {
+		return false
+	}
+	return true
+}
+
+// countQuery returns the number of keys in the query range regardless of the page
+func countQuery(c *bolt.Cursor, q *Query) int {
+	total := 0
+	k, _, next := seekQuery(c, q, nil)
+	for ; k != nil && inQueryRange(k, q); k, _ = next() {
+		total++
+	}
+	return total
+}
+
+func encodeCursor(k []byte) string {
+	return base64.RawURLEncoding.EncodeToString(k)
+}
+
+func decodeCursor(cursor string) ([]byte, error) {
+	k, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(k) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return k, nil
+}
+
 // Close closes the database
 func (bb *BoltBackend) Close() error {
 	return errors.Wrap(bb.db.Close(), "close bolt datastore backend database")
 }
 
-func createIndexes(key string, indexData map[string]string, rootBucket *bolt.Bucket) error {
-	// Iterate over index values
+// Snapshot writes a consistent copy of the bolt database file in a read transaction
+func (bb *BoltBackend) Snapshot(w io.Writer) error {
+	return bb.db.View(func(tx *bolt.Tx) error {
+		_, err := tx.WriteTo(w)
+		return errors.Wrap(err, "write bolt snapshot")
+	})
+}
+
+// updateIndexes sets the index values of the key
+// The index value key of an unchanged value is kept, so the key doesn't move
+// in the index and the query cursors stay valid
+func updateIndexes(key string, indexData map[string]string, rootBucket *bolt.Bucket) error {
+	oldIndexData, err := keyIndexes(key, rootBucket)
+	if err != nil {
+		return err
+	}
+
+	valueKeys := make(map[string]string, len(indexData))
 	for indexName, v := range indexData {
 		indexBk, err := rootBucket.CreateBucketIfNotExists([]byte(fmt.Sprintf("index-%s", indexName)))
 		if err != nil {
 			return errors.Wrapf(err, "failed to create index bucket for index %s", indexName)
 		}
 
+		if oldValueKey, ok := oldIndexData[indexName]; ok {
+			if strings.HasPrefix(oldValueKey, v+"\x00") {
+				valueKeys[indexName] = oldValueKey
+				continue
+			}
+			if err := indexBk.Delete([]byte(oldValueKey)); err != nil {
+				return errors.Wrap(err, "failed to delete index data")
+			}
+		}
+
 		// generate unique and sortable key
 		valueKey := createIndexValueKey(v)
 		if err := indexBk.Put([]byte(valueKey), []byte(key)); err != nil {
 			return errors.Wrap(err, "failed to put index data")
 		}
+		valueKeys[indexName] = valueKey
+	}
 
-		// update the index data with the actual key
-		indexData[indexName] = valueKey
+	// Remove the indexes not set anymore
+	for indexName, oldValueKey := range oldIndexData {
+		if _, ok := valueKeys[indexName]; ok {
+			continue
+		}
+		if indexBk := rootBucket.Bucket([]byte(fmt.Sprintf("index-%s", indexName))); indexBk != nil {
+			if err := indexBk.Delete([]byte(oldValueKey)); err != nil {
+				return errors.Wrap(err, "failed to delete index data")
+			}
+		}
 	}
 
 	b := new(bytes.Buffer)
-	if err := gob.NewEncoder(b).Encode(&indexData); err != nil {
+	if err := gob.NewEncoder(b).Encode(&valueKeys); err != nil {
 		return errors.Wrap(err, "failed to encode index data")
 	}
 
 	return rootBucket.Put([]byte(fmt.Sprintf("indexes-%s", key)), b.Bytes())
 }
 
-func deleteIndexes(key string, rootBucket *bolt.Bucket) error {
+// keyIndexes returns the index value keys of a key
+func keyIndexes(key string, rootBucket *bolt.Bucket) (map[string]string, error) {
 	kiData := rootBucket.Get([]byte(fmt.Sprintf("indexes-%s", key)))
 	if kiData == nil {
-		return nil
+		return nil, nil
 	}
 
 	indexData := map[string]string{}
 	b := bytes.NewBuffer(kiData)
 	if err := gob.NewDecoder(b).Decode(&indexData); err != nil {
-		return errors.Wrap(err, "invalid index data")
+		return nil, errors.Wrap(err, "invalid index data")
+	}
+	return indexData, nil
+}
+
+// matchIndexData returns true if the index value keys have the Where values
+func matchIndexData(indexData map[string]string, where map[string]string) bool {
+	for indexName, v := range where {
+		valueKey, ok := indexData[indexName]
+		if !ok || !strings.HasPrefix(valueKey, v+"\x00") {
+			return false
+		}
+	}
+	return true
+}
+
+func deleteIndexes(key string, rootBucket *bolt.Bucket) error {
+	indexData, err := keyIndexes(key, rootBucket)
+	if err != nil {
+		return err
+	}
+	if indexData == nil {
+		return nil
 	}
 
 	for indexName, v := range indexData {
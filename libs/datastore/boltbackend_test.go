@@ -22,9 +22,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 	"time"
+
+	bolt "go.etcd.io/bbolt"
 )
 
 const (
@@ -144,6 +147,287 @@ func TestBoltBackend(t *testing.T) {
 
 }
 
+func TestBoltBackendQuery(t *testing.T) {
+	seedValues := []struct {
+		key   string
+		index map[string]string
+	}{
+		{"a", map[string]string{"time": "2019-01-01", "state": "created", "coin": "0"}},
+		{"b", map[string]string{"time": "2019-02-01", "state": "fulfilled", "coin": "0"}},
+		{"c", map[string]string{"time": "2019-03-01", "state": "fulfilled", "coin": "1"}},
+		{"d", map[string]string{"time": "2019-04-01", "state": "failed", "coin": "0"}},
+		{"e", map[string]string{"time": "2019-05-01", "state": "fulfilled", "coin": "0"}},
+	}
+
+	testCases := []struct {
+		query  *Query
+		result []string
+	}{
+		{&Query{Index: "time"}, []string{"a", "b", "c", "d", "e"}},
+		{&Query{Index: "time", Reverse: true}, []string{"e", "d", "c", "b", "a"}},
+		{&Query{Index: "time", From: "2019-02-01"}, []string{"b", "c", "d", "e"}},
+		{&Query{Index: "time", To: "2019-04-01"}, []string{"a", "b", "c"}},
+		{&Query{Index: "time", From: "2019-02-01", To: "2019-04-01"}, []string{"b", "c"}},
+		{&Query{Index: "time", From: "2019-02-01", To: "2019-04-01", Reverse: true}, []string{"c", "b"}},
+		{&Query{Index: "time", To: "2019-09-01", Reverse: true}, []string{"e", "d", "c", "b", "a"}},
+		{&Query{Index: "time", Where: map[string]string{"state": "fulfilled"}}, []string{"b", "c", "e"}},
+		{&Query{Index: "time", Where: map[string]string{"state": "fulfilled", "coin": "0"}}, []string{"b", "e"}},
+		{&Query{Index: "time", Where: map[string]string{"state": "fulfilled"}, Skip: 1, Limit: 1}, []string{"c"}},
+		{&Query{Index: "time", Where: map[string]string{"state": "fulfilled"}, Reverse: true, Limit: 2}, []string{"e", "c"}},
+		{&Query{Index: "time", Where: map[string]string{"state": "cancelled"}}, []string{}},
+		{&Query{Index: "time", Where: map[string]string{"beneficiary": "x"}}, []string{}},
+		{&Query{Index: "state", From: "failed", To: "failed\x01"}, []string{"d"}},
+		{&Query{Index: "index-invalid"}, []string{}},
+	}
+
+	dbName := genTempFilename()
+	defer os.Remove(dbName)
+
+	b, err := NewBoltBackend(dbName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	for _, sv := range seedValues {
+		if err := b.Set("test", sv.key, []byte(sv.key), sv.index); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for itc, tc := range testCases {
+		t.Run(fmt.Sprintf("test case %d", itc), func(t *testing.T) {
+			result, err := b.Query("test", tc.query)
+			if err != nil {
+				t.Fatalf("Query: %v", err)
+			}
+			if strings.Join(result.Keys, ",") != strings.Join(tc.result, ",") {
+				t.Fatalf("invalid Query result. Expected: %v, found: %v", tc.result, result.Keys)
+			}
+		})
+	}
+
+	pageTestCases := []struct {
+		query *Query
+		pages [][]string
+		total int
+	}{
+		{&Query{Index: "time", Limit: 2}, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, 0},
+		{&Query{Index: "time", Limit: 5}, [][]string{{"a", "b", "c", "d", "e"}}, 0},
+		{&Query{Index: "time", Limit: 2, Reverse: true}, [][]string{{"e", "d"}, {"c", "b"}, {"a"}}, 0},
+		{&Query{Index: "time", Limit: 1, Where: map[string]string{"state": "fulfilled"}, Count: true}, [][]string{{"b"}, {"c"}, {"e"}}, 3},
+		{&Query{Index: "time", Limit: 2, Where: map[string]string{"state": "fulfilled"}, Reverse: true, Count: true}, [][]string{{"e", "c"}, {"b"}}, 3},
+		{&Query{Index: "time", Limit: 2, From: "2019-02-01", To: "2019-05-01", Count: true}, [][]string{{"b", "c"}, {"d"}}, 3},
+		{&Query{Index: "time", Limit: 2, From: "2019-02-01", To: "2019-05-01", Reverse: true}, [][]string{{"d", "c"}, {"b"}}, 0},
+	}
+
+	for itc, tc := range pageTestCases {
+		t.Run(fmt.Sprintf("page test case %d", itc), func(t *testing.T) {
+			q := *tc.query
+			for i, page := range tc.pages {
+				result, err := b.Query("test", &q)
+				if err != nil {
+					t.Fatalf("Query: %v", err)
+				}
+				if strings.Join(result.Keys, ",") != strings.Join(page, ",") {
+					t.Fatalf("invalid page %v. Expected: %v, found: %v", i, page, result.Keys)
+				}
+				if result.Total != tc.total {
+					t.Fatalf("invalid total. Expected: %v, found: %v", tc.total, result.Total)
+				}
+				if last := i == len(tc.pages)-1; last != (result.Cursor == "") {
+					t.Fatalf("invalid cursor of page %v: %q", i, result.Cursor)
+				}
+				q.Cursor = result.Cursor
+			}
+		})
+	}
+
+	if _, err := b.Query("test", &Query{Index: "time", Cursor: "!"}); err != ErrInvalidCursor {
+		t.Fatalf("invalid cursor error. Expected: %v, found: %v", ErrInvalidCursor, err)
+	}
+}
+
+func TestBoltBackendQueryWhereIndex(t *testing.T) {
+	dbName := genTempFilename()
+	defer os.Remove(dbName)
+
+	b, err := NewBoltBackend(dbName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	expected := []string{}
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("%03d", i)
+		state := "fulfilled"
+		if i%10 == 0 {
+			state = "failed"
+			expected = append(expected, key)
+		}
+		if err := b.Set("test", key, []byte(key), map[string]string{"time": key, "state": state}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//The index data of the keys not selected can't be read, a visit fails the query
+	err = b.(*BoltBackend).db.Update(func(tx *bolt.Tx) error {
+		bk := tx.Bucket([]byte("test"))
+		for i := 0; i < 100; i++ {
+			if i%10 == 0 {
+				continue
+			}
+			if err := bk.Put([]byte(fmt.Sprintf("indexes-%03d", i)), []byte("invalid")); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, reverse := range []bool{false, true} {
+		q := &Query{Index: "time", From: "010", To: "090", Where: map[string]string{"state": "failed"}, Limit: 3, Reverse: reverse, Count: true}
+		keys := []string{}
+		for {
+			result, err := b.Query("test", q)
+			if err != nil {
+				t.Fatalf("Query: %v", err)
+			}
+			if result.Total != 8 {
+				t.Fatalf("invalid total. Expected: 8, found: %v", result.Total)
+			}
+			keys = append(keys, result.Keys...)
+			if result.Cursor == "" {
+				break
+			}
+			q.Cursor = result.Cursor
+		}
+
+		want := expected[1:9]
+		if reverse {
+			want = []string{}
+			for i := 8; i >= 1; i-- {
+				want = append(want, expected[i])
+			}
+		}
+		if strings.Join(keys, ",") != strings.Join(want, ",") {
+			t.Fatalf("invalid keys. Expected: %v, found: %v", want, keys)
+		}
+	}
+}
+
+func TestBoltBackendIndexUpdate(t *testing.T) {
+	dbName := genTempFilename()
+	defer os.Remove(dbName)
+
+	b, err := NewBoltBackend(dbName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := b.Set("test", key, []byte(key), map[string]string{"time": "2019-01-01", "state": "created"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The keys with the same time are sorted by the index value key suffix
+	all, err := b.Query("test", &Query{Index: "time"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := &Query{Index: "time", Limit: 1}
+	result, err := b.Query("test", q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(result.Keys, ",") != all.Keys[0] {
+		t.Fatalf("invalid first page. Expected: %v, found: %v", all.Keys[0], result.Keys)
+	}
+
+	// The keys keep their position in the unchanged time index
+	for _, key := range []string{"a", "b"} {
+		if err := b.Set("test", key, []byte(key), map[string]string{"time": "2019-01-01", "state": "fulfilled"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.Cursor = result.Cursor
+	q.Limit = 0
+	if result, err = b.Query("test", q); err != nil {
+		t.Fatal(err)
+	}
+	if next := strings.Join(all.Keys[1:], ","); strings.Join(result.Keys, ",") != next {
+		t.Fatalf("invalid next page. Expected: %v, found: %v", next, result.Keys)
+	}
+
+	// The changed and the removed indexes are replaced
+	if err := b.Set("test", "c", []byte("c"), map[string]string{"time": "2019-01-01"}); err != nil {
+		t.Fatal(err)
+	}
+	for state, keys := range map[string]string{"fulfilled": "a,b", "created": ""} {
+		result, err := b.Query("test", &Query{Index: "state", From: state, To: state + "\x01"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(result.Keys)
+		if strings.Join(result.Keys, ",") != keys {
+			t.Fatalf("invalid %s keys. Expected: %v, found: %v", state, keys, result.Keys)
+		}
+	}
+}
+
+func TestBoltBackendSnapshot(t *testing.T) {
+	dbName := genTempFilename()
+	defer os.Remove(dbName)
+	snapshotName := genTempFilename() + ".snapshot"
+	defer os.Remove(snapshotName)
+
+	b, err := NewBoltBackend(dbName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	if err := b.Set("test", "1", []byte{1, 2, 3}, map[string]string{"time": "2019-01-01"}); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Create(snapshotName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The snapshot is taken while the database is open
+	if err := b.(Snapshotter).Snapshot(f); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	f.Close()
+
+	sb, err := NewBoltBackend(snapshotName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sb.Close()
+
+	v, err := sb.Get("test", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(v, []byte{1, 2, 3}) {
+		t.Fatalf("invalid snapshot value. Expected: %v, found: %v", []byte{1, 2, 3}, v)
+	}
+	keys, err := sb.ListKeys("test", "time", 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(keys, ",") != "1" {
+		t.Fatalf("invalid snapshot index. Expected: %v, found: %v", []string{"1"}, keys)
+	}
+}
+
 func genTempFilename() string {
 	tempDir := os.TempDir()
 	filename := fmt.Sprintf("milagro-test-bolt-%v.db", time.Now().UnixNano())
@@ -22,6 +22,7 @@ package datastore
 
 import (
 	"errors"
+	"io"
 )
 
 var (
@@ -31,6 +32,10 @@ var (
 	ErrCodecNotInitialized = errors.New("codec not initialized")
 	// ErrKeyNotFound is returned when an attempt to load a value of a missing key is made
 	ErrKeyNotFound = errors.New("key not found")
+	// ErrInvalidCursor is returned when the cursor of a query can't be decoded
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrSnapshotNotSupported is returned when the backend can't take a snapshot
+	ErrSnapshotNotSupported = errors.New("snapshot not supported")
 )
 
 // Store provides key-value data storage with
@@ -109,6 +114,28 @@ func (s *Store) ListKeys(datatype, index string, skip, limit int, reverse bool)
 	return s.backend.ListKeys(datatype, index, skip, limit, reverse)
 }
 
+// Query lists the keys selected by the index values
+func (s *Store) Query(datatype string, q *Query) (*QueryResult, error) {
+	if err := s.checkInit(); err != nil {
+		return nil, err
+	}
+
+	return s.backend.Query(datatype, q)
+}
+
+// Snapshot writes a consistent copy of the database to w
+func (s *Store) Snapshot(w io.Writer) error {
+	if err := s.checkInit(); err != nil {
+		return err
+	}
+
+	snapshotter, ok := s.backend.(Snapshotter)
+	if !ok {
+		return ErrSnapshotNotSupported
+	}
+	return snapshotter.Snapshot(w)
+}
+
 func (s *Store) checkInit() error {
 	if s.backend == nil {
 		return ErrBackendNotInitialized
@@ -144,9 +171,43 @@ type Backend interface {
 	Get(datatype, key string) ([]byte, error)
 	Del(datatype, key string) error
 	ListKeys(datatype, index string, skip, limit int, reverse bool) (keys []string, err error)
+	Query(datatype string, q *Query) (*QueryResult, error)
 	Close() error
 }
 
+// Snapshotter is implemented by the backends able to copy the database while it's in use
+type Snapshotter interface {
+	Snapshot(w io.Writer) error
+}
+
+// Query selects the keys of a datatype by their index values
+type Query struct {
+	// Index sorts the keys
+	Index string
+	// From and To are the range of the Index values
+	// From is inclusive, To is exclusive and an empty value is unbounded
+	From string
+	To   string
+	// Where selects the keys with the exact values of other indexes
+	Where   map[string]string
+	Skip    int
+	Limit   int
+	Reverse bool
+	// Cursor continues the listing after the last key of a previous page
+	Cursor string
+	// Count sets the total number of keys selected regardless of the page
+	Count bool
+}
+
+// QueryResult is a page of keys selected by a Query
+type QueryResult struct {
+	Keys []string
+	// Cursor of the next page, it's empty on the last page
+	Cursor string
+	// Total is set only if the Query Count is set
+	Total int
+}
+
 // Codec probides data serialization interface
 type Codec interface {
 	Marshal(v interface{}) ([]byte, error)
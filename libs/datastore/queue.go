@@ -0,0 +1,115 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package datastore
+
+import (
+	"time"
+)
+
+const (
+	queueIndex = "next"
+	// sortable time format for the queue index
+	queueTimeFormat = "20060102150405.000000000"
+)
+
+// QueueItem is a task in a durable queue
+type QueueItem struct {
+	Key         string
+	Payload     []byte
+	Attempts    int
+	NextAttempt time.Time
+	LastError   string
+}
+
+// Queue is a durable retry queue persisted in the store
+// Items are ordered by the time of their next attempt
+type Queue struct {
+	store    *Store
+	datatype string
+}
+
+// NewQueue creates a queue stored under the datatype
+func NewQueue(store *Store, datatype string) *Queue {
+	return &Queue{
+		store:    store,
+		datatype: datatype,
+	}
+}
+
+// Push adds an item to the queue to be processed immediately
+// An existing item with the same key is replaced
+func (q *Queue) Push(key string, payload []byte) error {
+	return q.set(&QueueItem{
+		Key:         key,
+		Payload:     payload,
+		NextAttempt: time.Now().UTC(),
+	})
+}
+
+// Get retreives a queued item
+// Returns ErrKeyNotFound if the item is not in the queue
+func (q *Queue) Get(key string) (*QueueItem, error) {
+	item := &QueueItem{}
+	if err := q.store.Get(q.datatype, key, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Due returns the items scheduled before now
+// limit is the maximum number of items returned, 0 returns all of them
+func (q *Queue) Due(now time.Time, limit int) ([]*QueueItem, error) {
+	keys, err := q.store.ListKeys(q.datatype, queueIndex, 0, limit, false)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []*QueueItem{}
+	for _, key := range keys {
+		item, err := q.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		if item.NextAttempt.After(now) {
+			break
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Retry schedules the next attempt of the item after delay
+func (q *Queue) Retry(item *QueueItem, delay time.Duration, lastErr error) error {
+	item.Attempts++
+	item.NextAttempt = time.Now().UTC().Add(delay)
+	if lastErr != nil {
+		item.LastError = lastErr.Error()
+	}
+	return q.set(item)
+}
+
+// Done removes the item from the queue
+func (q *Queue) Done(key string) error {
+	return q.store.Del(q.datatype, key)
+}
+
+func (q *Queue) set(item *QueueItem) error {
+	return q.store.Set(q.datatype, item.Key, item, map[string]string{
+		queueIndex: item.NextAttempt.UTC().Format(queueTimeFormat),
+	})
+}
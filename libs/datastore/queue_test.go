@@ -0,0 +1,106 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package datastore
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	dbName := genTempFilename()
+	defer os.Remove(dbName)
+
+	b, err := NewBoltBackend(dbName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewStore(WithBackend(b), WithCodec(NewGOBCodec()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	q := NewQueue(store, "queue")
+
+	for _, key := range []string{"1", "2", "3"} {
+		if err := q.Push(key, []byte(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	items, err := q.Due(time.Now().Add(time.Second), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("invalid number of due items. Expected: 3, found: %v", len(items))
+	}
+	for i, key := range []string{"1", "2", "3"} {
+		if items[i].Key != key || string(items[i].Payload) != key {
+			t.Fatalf("invalid item %v. Expected: %v, found: %v", i, key, items[i].Key)
+		}
+	}
+
+	// Reschedule the first item
+	if err := q.Retry(items[0], time.Hour, errors.New("retry")); err != nil {
+		t.Fatal(err)
+	}
+	items, err = q.Due(time.Now().Add(time.Second), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Key != "2" {
+		t.Fatalf("invalid due items after retry: %v", items)
+	}
+
+	item, err := q.Get("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Attempts != 1 || item.LastError != "retry" {
+		t.Fatalf("invalid retried item: %+v", item)
+	}
+
+	items, err = q.Due(time.Now().Add(2*time.Hour), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Key != "2" {
+		t.Fatalf("invalid limited due items: %v", items)
+	}
+
+	// Remove the items
+	for _, key := range []string{"1", "2", "3"} {
+		if err := q.Done(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := q.Get("1"); err != ErrKeyNotFound {
+		t.Fatalf("invalid Get error response. Expected: %v, found: %v", ErrKeyNotFound, err)
+	}
+	items, err = q.Due(time.Now().Add(2*time.Hour), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 0 {
+		t.Fatalf("invalid due items after done: %v", items)
+	}
+}
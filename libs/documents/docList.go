@@ -81,3 +81,86 @@ func detectDocType(message proto.Message) (DocType, error) {
 	}
 	return DocType{}, errors.New("Document not found")
 }
+
+//SmartEnvelope - an envelope decoded with the body types of its header
+type SmartEnvelope struct {
+	SignerCID         string
+	Header            *Header
+	BodyType          DocType
+	Body              proto.Message
+	EncryptedBodyType DocType
+	//EncryptedBody is nil until the envelope is decrypted
+	EncryptedBody proto.Message
+
+	rawDoc         []byte
+	tag            string
+	signedEnvelope SignedEnvelope
+}
+
+//SmartDecodeEnvelope - decode the header and the plaintext body of an envelope whose body types are unknown
+//The signature isn't verified and the encrypted body isn't decrypted
+func SmartDecodeEnvelope(rawDoc []byte, tag string) (*SmartEnvelope, error) {
+	signedEnvelope := SignedEnvelope{}
+	if err := proto.Unmarshal(rawDoc, &signedEnvelope); err != nil {
+		return nil, errors.New("Protobuf - Failed to unmarshal Signed Envelope")
+	}
+	envelope := Envelope{}
+	if err := proto.Unmarshal(signedEnvelope.Message, &envelope); err != nil {
+		return nil, errors.New("Protobuf - Failed to unmarshal Envelope")
+	}
+	if envelope.Header == nil {
+		return nil, errors.New("Envelope without header")
+	}
+
+	se := &SmartEnvelope{
+		SignerCID:         signedEnvelope.SignerCID,
+		BodyType:          docTypeForHeader(envelope.Header.BodyTypeCode, envelope.Header.BodyVersion),
+		EncryptedBodyType: docTypeForHeader(envelope.Header.EncryptedBodyTypeCode, envelope.Header.EncryptedBodyVersion),
+		rawDoc:            rawDoc,
+		tag:               tag,
+		signedEnvelope:    signedEnvelope,
+	}
+	se.Body = newDocMessage(se.BodyType)
+
+	header, err := Decode(rawDoc, tag, nil, "", se.Body, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	se.Header = header
+	return se, nil
+}
+
+//Verify - verify the envelope signature with the signer BLS public key
+func (se *SmartEnvelope) Verify(blsPK []byte) error {
+	return Verify(se.signedEnvelope, blsPK)
+}
+
+//Decrypt - decrypt the encrypted body for the recipient
+func (se *SmartEnvelope) Decrypt(sikeSK []byte, recipientID string) error {
+	encryptedBody := newDocMessage(se.EncryptedBodyType)
+	if encryptedBody == nil {
+		return errors.Errorf("Unknown encrypted body type %v", se.EncryptedBodyType.TypeCode)
+	}
+	if _, err := Decode(se.rawDoc, se.tag, sikeSK, recipientID, nil, encryptedBody, nil); err != nil {
+		return err
+	}
+	se.EncryptedBody = encryptedBody
+	return nil
+}
+
+//docTypeForHeader returns the DocType of the header type code, "unknown" if it isn't in DocList
+func docTypeForHeader(typeCode, version float32) DocType {
+	doc := GetDocTypeForType(typeCode)
+	if doc.TypeCode != typeCode {
+		return DocType{Name: "unknown", TypeCode: typeCode, Version: version}
+	}
+	return doc
+}
+
+//newDocMessage returns a new empty message of the DocType, nil if the type has no message
+func newDocMessage(doc DocType) proto.Message {
+	if doc.Message == nil {
+		return nil
+	}
+	return reflect.New(reflect.TypeOf(doc.Message).Elem()).Interface().(proto.Message)
+}
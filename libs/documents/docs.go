@@ -21,6 +21,9 @@ Package documents - data is signed and nested in "encrypted envelope"
 package documents
 
 import (
+	"crypto/rand"
+	"time"
+
 	"github.com/gogo/protobuf/proto"
 	"github.com/pkg/errors"
 )
@@ -31,6 +34,8 @@ import (
 var (
 	//EnvelopeVersion the versioning of the entire Envelope, (not individual documents/contents)
 	EnvelopeVersion float32 = 1.0
+	//EnvelopeNonceLength the length of the random nonce of every envelope
+	EnvelopeNonceLength = 16
 )
 
 //IDDoc wrapper to encapsulate Header & IDDocument into one object
@@ -45,6 +50,12 @@ type OrderDoc struct {
 	*OrderDocument
 }
 
+//PolicyDoc wrapper to encapsulate Header & Policy into one object
+type PolicyDoc struct {
+	*Header
+	*Policy
+}
+
 //NewIDDoc generate a new empty IDDoc
 func NewIDDoc() *IDDoc {
 	return &IDDoc{
@@ -53,6 +64,14 @@ func NewIDDoc() *IDDoc {
 	}
 }
 
+//NewPolicyDoc generate a new empty PolicyDoc
+func NewPolicyDoc() *PolicyDoc {
+	return &PolicyDoc{
+		&Header{},
+		&Policy{},
+	}
+}
+
 //NewOrderDoc generate a new order
 func NewOrderDoc() OrderDoc {
 	ret := OrderDoc{}
@@ -75,6 +94,13 @@ func EncodeOrderDocument(nodeID string, orderDoc OrderDoc, blsSK []byte, recipie
 	return rawDoc, err
 }
 
+//EncodePolicyDocument encode a PolicyDoc into a raw bytes stream for the wire
+//The policy is readable by anyone and signed by the node that publishes it
+func EncodePolicyDocument(nodeID string, policyDoc *PolicyDoc, blsSK []byte) ([]byte, error) {
+	rawDoc, err := Encode(nodeID, policyDoc.Policy, nil, policyDoc.Header, blsSK, nil)
+	return rawDoc, err
+}
+
 //DecodeIDDocument - decode a raw byte stream into an IDDocument
 func DecodeIDDocument(rawdoc []byte, tag string, idDocument *IDDoc) error {
 	plainText := IDDocument{}
@@ -93,6 +119,24 @@ func DecodeIDDocument(rawdoc []byte, tag string, idDocument *IDDoc) error {
 	return nil
 }
 
+//DecodePolicyDocument - decode a raw byte stream into a Policy
+func DecodePolicyDocument(rawdoc []byte, tag string, policyDoc *PolicyDoc, sendersBlsPK []byte) error {
+	plainText := Policy{}
+	header, err := Decode(rawdoc, tag, nil, "", &plainText, nil, sendersBlsPK)
+	if err != nil {
+		return errors.Wrap(err, "DecodePolicyDocument Failed to Decode")
+	}
+	policyDoc.Header = header
+	policyDoc.Policy = &plainText
+
+	//validate the policy document
+	err = policyDoc.Policy.Validate()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 //DecodeOrderDocument -
 func DecodeOrderDocument(rawdoc []byte, tag string, orderdoc *OrderDoc, sikeSK []byte, recipientCID string, sendersBlsPK []byte) error {
 	cipherText := OrderDocument{}
@@ -111,6 +155,16 @@ func DecodeOrderDocument(rawdoc []byte, tag string, orderdoc *OrderDoc, sikeSK [
 	return nil
 }
 
+//DecodeSignerCID - returns the CID of the IDDocument that signed the raw envelope
+//The signature isn't verified
+func DecodeSignerCID(rawDoc []byte) (string, error) {
+	signedEnvelope := SignedEnvelope{}
+	if err := proto.Unmarshal(rawDoc, &signedEnvelope); err != nil {
+		return "", errors.New("Protobuf - Failed to unmarshal Signed Envelope")
+	}
+	return signedEnvelope.SignerCID, nil
+}
+
 //Decode - Given a raw envelope, Sike Secret Key & ID - decode into plaintext, ciphertext(decrypted) and header
 func Decode(rawDoc []byte, tag string, sikeSK []byte, recipientID string, plainText proto.Message, encryptedText proto.Message, sendersBlsPK []byte) (header *Header, err error) {
 	signedEnvelope := SignedEnvelope{}
@@ -177,8 +231,12 @@ func Encode(nodeID string, plainText proto.Message, secretText proto.Message, he
 	}
 	//build Header
 	header.Version = EnvelopeVersion
-	//TODO:  change datetime to real value - its 0 to create fixed IPFS refs
-	header.DateTime = 0 //time.Now().Unix()
+	//The envelope is unique, a repeated request is deduplicated by the receiver
+	header.DateTime = time.Now().Unix()
+	header.Nonce = make([]byte, EnvelopeNonceLength)
+	if _, err := rand.Read(header.Nonce); err != nil {
+		return nil, errors.Wrap(err, "Failed to generate the envelope nonce")
+	}
 	header.BodyTypeCode = plainTextDocType.TypeCode
 	header.BodyVersion = plainTextDocType.Version
 	header.EncryptedBodyTypeCode = encryptedTextDocType.TypeCode
@@ -143,6 +143,7 @@ type Header struct {
 	EncryptedBodyVersion  float32      `protobuf:"fixed32,8,opt,name=EncryptedBodyVersion,proto3" json:"EncryptedBodyVersion,omitempty"`
 	EncryptedBodyIV       []byte       `protobuf:"bytes,9,opt,name=EncryptedBodyIV,proto3" json:"EncryptedBodyIV,omitempty"`
 	Recipients            []*Recipient `protobuf:"bytes,10,rep,name=Recipients,proto3" json:"Recipients,omitempty"`
+	Nonce                 []byte       `protobuf:"bytes,11,opt,name=Nonce,proto3" json:"Nonce,omitempty"`
 	XXX_NoUnkeyedLiteral  struct{}     `json:"-"`
 	XXX_unrecognized      []byte       `json:"-"`
 	XXX_sizecache         int32        `json:"-"`
@@ -243,6 +244,13 @@ func (m *Header) GetRecipients() []*Recipient {
 	return nil
 }
 
+func (m *Header) GetNonce() []byte {
+	if m != nil {
+		return m.Nonce
+	}
+	return nil
+}
+
 type Recipient struct {
 	Version              float32  `protobuf:"fixed32,1,opt,name=Version,proto3" json:"Version,omitempty"`
 	CID                  string   `protobuf:"bytes,2,opt,name=CID,proto3" json:"CID,omitempty"`
@@ -386,18 +394,26 @@ func (m *IDDocument) GetTimestamp() int64 {
 }
 
 type OrderDocument struct {
-	Type                 string      `protobuf:"bytes,1,opt,name=Type,proto3" json:"Type,omitempty"`
-	Coin                 int64       `protobuf:"varint,2,opt,name=Coin,proto3" json:"Coin,omitempty"`
-	PrincipalCID         string      `protobuf:"bytes,3,opt,name=PrincipalCID,proto3" json:"PrincipalCID,omitempty"`
-	BeneficiaryCID       string      `protobuf:"bytes,4,opt,name=BeneficiaryCID,proto3" json:"BeneficiaryCID,omitempty"`
-	Reference            string      `protobuf:"bytes,5,opt,name=Reference,proto3" json:"Reference,omitempty"`
-	Timestamp            int64       `protobuf:"varint,6,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
-	OrderPart2           *OrderPart2 `protobuf:"bytes,7,opt,name=OrderPart2,proto3" json:"OrderPart2,omitempty"`
-	OrderPart3           *OrderPart3 `protobuf:"bytes,8,opt,name=OrderPart3,proto3" json:"OrderPart3,omitempty"`
-	OrderPart4           *OrderPart4 `protobuf:"bytes,9,opt,name=OrderPart4,proto3" json:"OrderPart4,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
-	XXX_unrecognized     []byte      `json:"-"`
-	XXX_sizecache        int32       `json:"-"`
+	Type                 string          `protobuf:"bytes,1,opt,name=Type,proto3" json:"Type,omitempty"`
+	Coin                 int64           `protobuf:"varint,2,opt,name=Coin,proto3" json:"Coin,omitempty"`
+	PrincipalCID         string          `protobuf:"bytes,3,opt,name=PrincipalCID,proto3" json:"PrincipalCID,omitempty"`
+	BeneficiaryCID       string          `protobuf:"bytes,4,opt,name=BeneficiaryCID,proto3" json:"BeneficiaryCID,omitempty"`
+	Reference            string          `protobuf:"bytes,5,opt,name=Reference,proto3" json:"Reference,omitempty"`
+	Timestamp            int64           `protobuf:"varint,6,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
+	OrderPart2           *OrderPart2     `protobuf:"bytes,7,opt,name=OrderPart2,proto3" json:"OrderPart2,omitempty"`
+	OrderPart3           *OrderPart3     `protobuf:"bytes,8,opt,name=OrderPart3,proto3" json:"OrderPart3,omitempty"`
+	OrderPart4           *OrderPart4     `protobuf:"bytes,9,opt,name=OrderPart4,proto3" json:"OrderPart4,omitempty"`
+	Threshold            int64           `protobuf:"varint,10,opt,name=Threshold,proto3" json:"Threshold,omitempty"`
+	FiduciaryCIDs        []string        `protobuf:"bytes,11,rep,name=FiduciaryCIDs,proto3" json:"FiduciaryCIDs,omitempty"`
+	Share                *SecretShare    `protobuf:"bytes,12,opt,name=Share,proto3" json:"Share,omitempty"`
+	OrderCancel          *OrderCancel    `protobuf:"bytes,13,opt,name=OrderCancel,proto3" json:"OrderCancel,omitempty"`
+	OrderTombstone       *OrderTombstone `protobuf:"bytes,14,opt,name=OrderTombstone,proto3" json:"OrderTombstone,omitempty"`
+	NotBefore            int64           `protobuf:"varint,15,opt,name=NotBefore,proto3" json:"NotBefore,omitempty"`
+	Expiry               int64           `protobuf:"varint,16,opt,name=Expiry,proto3" json:"Expiry,omitempty"`
+	PolicyCID            string          `protobuf:"bytes,17,opt,name=PolicyCID,proto3" json:"PolicyCID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
 }
 
 func (m *OrderDocument) Reset()         { *m = OrderDocument{} }
@@ -488,20 +504,172 @@ func (m *OrderDocument) GetOrderPart4() *OrderPart4 {
 	return nil
 }
 
-type OrderPart2 struct {
-	CommitmentPublicKey  string   `protobuf:"bytes,1,opt,name=CommitmentPublicKey,proto3" json:"CommitmentPublicKey,omitempty"`
-	PreviousOrderCID     string   `protobuf:"bytes,2,opt,name=PreviousOrderCID,proto3" json:"PreviousOrderCID,omitempty"`
-	Timestamp            int64    `protobuf:"varint,3,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
+func (m *OrderDocument) GetThreshold() int64 {
+	if m != nil {
+		return m.Threshold
+	}
+	return 0
+}
+
+func (m *OrderDocument) GetFiduciaryCIDs() []string {
+	if m != nil {
+		return m.FiduciaryCIDs
+	}
+	return nil
+}
+
+func (m *OrderDocument) GetShare() *SecretShare {
+	if m != nil {
+		return m.Share
+	}
+	return nil
+}
+
+func (m *OrderDocument) GetOrderCancel() *OrderCancel {
+	if m != nil {
+		return m.OrderCancel
+	}
+	return nil
+}
+
+func (m *OrderDocument) GetOrderTombstone() *OrderTombstone {
+	if m != nil {
+		return m.OrderTombstone
+	}
+	return nil
+}
+
+func (m *OrderDocument) GetNotBefore() int64 {
+	if m != nil {
+		return m.NotBefore
+	}
+	return 0
+}
+
+func (m *OrderDocument) GetExpiry() int64 {
+	if m != nil {
+		return m.Expiry
+	}
+	return 0
+}
+
+func (m *OrderDocument) GetPolicyCID() string {
+	if m != nil {
+		return m.PolicyCID
+	}
+	return ""
+}
+
+type SecretShare struct {
+	X                    []byte   `protobuf:"bytes,1,opt,name=X,proto3" json:"X,omitempty"`
+	Y                    []byte   `protobuf:"bytes,2,opt,name=Y,proto3" json:"Y,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SecretShare) Reset()         { *m = SecretShare{} }
+func (m *SecretShare) String() string { return proto.CompactTextString(m) }
+func (*SecretShare) ProtoMessage()    {}
+func (*SecretShare) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2a25dace11219bce, []int{6}
+}
+
+func (m *SecretShare) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SecretShare.Unmarshal(m, b)
+}
+func (m *SecretShare) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SecretShare.Marshal(b, m, deterministic)
+}
+func (m *SecretShare) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SecretShare.Merge(m, src)
+}
+func (m *SecretShare) XXX_Size() int {
+	return xxx_messageInfo_SecretShare.Size(m)
+}
+func (m *SecretShare) XXX_DiscardUnknown() {
+	xxx_messageInfo_SecretShare.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SecretShare proto.InternalMessageInfo
+
+func (m *SecretShare) GetX() []byte {
+	if m != nil {
+		return m.X
+	}
+	return nil
+}
+
+func (m *SecretShare) GetY() []byte {
+	if m != nil {
+		return m.Y
+	}
+	return nil
+}
+
+type ShareDeal struct {
+	FiduciaryCID         string   `protobuf:"bytes,1,opt,name=FiduciaryCID,proto3" json:"FiduciaryCID,omitempty"`
+	DealCID              string   `protobuf:"bytes,2,opt,name=DealCID,proto3" json:"DealCID,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
+func (m *ShareDeal) Reset()         { *m = ShareDeal{} }
+func (m *ShareDeal) String() string { return proto.CompactTextString(m) }
+func (*ShareDeal) ProtoMessage()    {}
+func (*ShareDeal) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2a25dace11219bce, []int{7}
+}
+
+func (m *ShareDeal) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ShareDeal.Unmarshal(m, b)
+}
+func (m *ShareDeal) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ShareDeal.Marshal(b, m, deterministic)
+}
+func (m *ShareDeal) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ShareDeal.Merge(m, src)
+}
+func (m *ShareDeal) XXX_Size() int {
+	return xxx_messageInfo_ShareDeal.Size(m)
+}
+func (m *ShareDeal) XXX_DiscardUnknown() {
+	xxx_messageInfo_ShareDeal.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ShareDeal proto.InternalMessageInfo
+
+func (m *ShareDeal) GetFiduciaryCID() string {
+	if m != nil {
+		return m.FiduciaryCID
+	}
+	return ""
+}
+
+func (m *ShareDeal) GetDealCID() string {
+	if m != nil {
+		return m.DealCID
+	}
+	return ""
+}
+
+type OrderPart2 struct {
+	CommitmentPublicKey  string       `protobuf:"bytes,1,opt,name=CommitmentPublicKey,proto3" json:"CommitmentPublicKey,omitempty"`
+	PreviousOrderCID     string       `protobuf:"bytes,2,opt,name=PreviousOrderCID,proto3" json:"PreviousOrderCID,omitempty"`
+	Timestamp            int64        `protobuf:"varint,3,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
+	ShareCommitments     [][]byte     `protobuf:"bytes,4,rep,name=ShareCommitments,proto3" json:"ShareCommitments,omitempty"`
+	ShareDeals           []*ShareDeal `protobuf:"bytes,5,rep,name=ShareDeals,proto3" json:"ShareDeals,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
 func (m *OrderPart2) Reset()         { *m = OrderPart2{} }
 func (m *OrderPart2) String() string { return proto.CompactTextString(m) }
 func (*OrderPart2) ProtoMessage()    {}
 func (*OrderPart2) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2a25dace11219bce, []int{6}
+	return fileDescriptor_2a25dace11219bce, []int{8}
 }
 
 func (m *OrderPart2) XXX_Unmarshal(b []byte) error {
@@ -543,11 +711,26 @@ func (m *OrderPart2) GetTimestamp() int64 {
 	return 0
 }
 
+func (m *OrderPart2) GetShareCommitments() [][]byte {
+	if m != nil {
+		return m.ShareCommitments
+	}
+	return nil
+}
+
+func (m *OrderPart2) GetShareDeals() []*ShareDeal {
+	if m != nil {
+		return m.ShareDeals
+	}
+	return nil
+}
+
 type OrderPart3 struct {
 	Redemption               string   `protobuf:"bytes,1,opt,name=Redemption,proto3" json:"Redemption,omitempty"`
 	PreviousOrderCID         string   `protobuf:"bytes,2,opt,name=PreviousOrderCID,proto3" json:"PreviousOrderCID,omitempty"`
 	BeneficiaryEncryptedData []byte   `protobuf:"bytes,3,opt,name=BeneficiaryEncryptedData,proto3" json:"BeneficiaryEncryptedData,omitempty"`
 	Timestamp                int64    `protobuf:"varint,4,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
+	BeneficiaryCID           string   `protobuf:"bytes,5,opt,name=BeneficiaryCID,proto3" json:"BeneficiaryCID,omitempty"`
 	XXX_NoUnkeyedLiteral     struct{} `json:"-"`
 	XXX_unrecognized         []byte   `json:"-"`
 	XXX_sizecache            int32    `json:"-"`
@@ -557,7 +740,7 @@ func (m *OrderPart3) Reset()         { *m = OrderPart3{} }
 func (m *OrderPart3) String() string { return proto.CompactTextString(m) }
 func (*OrderPart3) ProtoMessage()    {}
 func (*OrderPart3) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2a25dace11219bce, []int{7}
+	return fileDescriptor_2a25dace11219bce, []int{9}
 }
 
 func (m *OrderPart3) XXX_Unmarshal(b []byte) error {
@@ -606,20 +789,28 @@ func (m *OrderPart3) GetTimestamp() int64 {
 	return 0
 }
 
+func (m *OrderPart3) GetBeneficiaryCID() string {
+	if m != nil {
+		return m.BeneficiaryCID
+	}
+	return ""
+}
+
 type OrderPart4 struct {
-	Secret               string   `protobuf:"bytes,1,opt,name=Secret,proto3" json:"Secret,omitempty"`
-	PreviousOrderCID     string   `protobuf:"bytes,2,opt,name=PreviousOrderCID,proto3" json:"PreviousOrderCID,omitempty"`
-	Timestamp            int64    `protobuf:"varint,3,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Secret               string       `protobuf:"bytes,1,opt,name=Secret,proto3" json:"Secret,omitempty"`
+	PreviousOrderCID     string       `protobuf:"bytes,2,opt,name=PreviousOrderCID,proto3" json:"PreviousOrderCID,omitempty"`
+	Timestamp            int64        `protobuf:"varint,3,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
+	Share                *SecretShare `protobuf:"bytes,4,opt,name=Share,proto3" json:"Share,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
 }
 
 func (m *OrderPart4) Reset()         { *m = OrderPart4{} }
 func (m *OrderPart4) String() string { return proto.CompactTextString(m) }
 func (*OrderPart4) ProtoMessage()    {}
 func (*OrderPart4) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2a25dace11219bce, []int{8}
+	return fileDescriptor_2a25dace11219bce, []int{10}
 }
 
 func (m *OrderPart4) XXX_Unmarshal(b []byte) error {
@@ -661,19 +852,136 @@ func (m *OrderPart4) GetTimestamp() int64 {
 	return 0
 }
 
-type Policy struct {
-	Version              float32  `protobuf:"fixed32,1,opt,name=Version,proto3" json:"Version,omitempty"`
-	Name                 string   `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
+func (m *OrderPart4) GetShare() *SecretShare {
+	if m != nil {
+		return m.Share
+	}
+	return nil
+}
+
+// Cancel request from the Principal, the fiduciary destroys the order secret
+type OrderCancel struct {
+	Reason               string   `protobuf:"bytes,1,opt,name=Reason,proto3" json:"Reason,omitempty"`
+	PreviousOrderCID     string   `protobuf:"bytes,2,opt,name=PreviousOrderCID,proto3" json:"PreviousOrderCID,omitempty"`
+	Timestamp            int64    `protobuf:"varint,3,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OrderCancel) Reset()         { *m = OrderCancel{} }
+func (m *OrderCancel) String() string { return proto.CompactTextString(m) }
+func (*OrderCancel) ProtoMessage()    {}
+func (*OrderCancel) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2a25dace11219bce, []int{11}
+}
+
+func (m *OrderCancel) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OrderCancel.Unmarshal(m, b)
+}
+func (m *OrderCancel) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OrderCancel.Marshal(b, m, deterministic)
+}
+func (m *OrderCancel) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OrderCancel.Merge(m, src)
+}
+func (m *OrderCancel) XXX_Size() int {
+	return xxx_messageInfo_OrderCancel.Size(m)
+}
+func (m *OrderCancel) XXX_DiscardUnknown() {
+	xxx_messageInfo_OrderCancel.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OrderCancel proto.InternalMessageInfo
+
+func (m *OrderCancel) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *OrderCancel) GetPreviousOrderCID() string {
+	if m != nil {
+		return m.PreviousOrderCID
+	}
+	return ""
+}
+
+func (m *OrderCancel) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+// Signed by the fiduciary once the order secret is destroyed
+type OrderTombstone struct {
+	PreviousOrderCID     string   `protobuf:"bytes,1,opt,name=PreviousOrderCID,proto3" json:"PreviousOrderCID,omitempty"`
+	Timestamp            int64    `protobuf:"varint,2,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
+func (m *OrderTombstone) Reset()         { *m = OrderTombstone{} }
+func (m *OrderTombstone) String() string { return proto.CompactTextString(m) }
+func (*OrderTombstone) ProtoMessage()    {}
+func (*OrderTombstone) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2a25dace11219bce, []int{12}
+}
+
+func (m *OrderTombstone) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OrderTombstone.Unmarshal(m, b)
+}
+func (m *OrderTombstone) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OrderTombstone.Marshal(b, m, deterministic)
+}
+func (m *OrderTombstone) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OrderTombstone.Merge(m, src)
+}
+func (m *OrderTombstone) XXX_Size() int {
+	return xxx_messageInfo_OrderTombstone.Size(m)
+}
+func (m *OrderTombstone) XXX_DiscardUnknown() {
+	xxx_messageInfo_OrderTombstone.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OrderTombstone proto.InternalMessageInfo
+
+func (m *OrderTombstone) GetPreviousOrderCID() string {
+	if m != nil {
+		return m.PreviousOrderCID
+	}
+	return ""
+}
+
+func (m *OrderTombstone) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+type Policy struct {
+	Version                float32  `protobuf:"fixed32,1,opt,name=Version,proto3" json:"Version,omitempty"`
+	Name                   string   `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
+	AllowedBeneficiaryCIDs []string `protobuf:"bytes,3,rep,name=AllowedBeneficiaryCIDs,proto3" json:"AllowedBeneficiaryCIDs,omitempty"`
+	MinRedemptionDelay     int64    `protobuf:"varint,4,opt,name=MinRedemptionDelay,proto3" json:"MinRedemptionDelay,omitempty"`
+	RequiredApproverCIDs   []string `protobuf:"bytes,5,rep,name=RequiredApproverCIDs,proto3" json:"RequiredApproverCIDs,omitempty"`
+	MaxRedemptions         int64    `protobuf:"varint,6,opt,name=MaxRedemptions,proto3" json:"MaxRedemptions,omitempty"`
+	Timestamp              int64    `protobuf:"varint,7,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
+	ApprovalQuorum         int64    `protobuf:"varint,8,opt,name=ApprovalQuorum,proto3" json:"ApprovalQuorum,omitempty"`
+	XXX_NoUnkeyedLiteral   struct{} `json:"-"`
+	XXX_unrecognized       []byte   `json:"-"`
+	XXX_sizecache          int32    `json:"-"`
+}
+
 func (m *Policy) Reset()         { *m = Policy{} }
 func (m *Policy) String() string { return proto.CompactTextString(m) }
 func (*Policy) ProtoMessage()    {}
 func (*Policy) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2a25dace11219bce, []int{9}
+	return fileDescriptor_2a25dace11219bce, []int{13}
 }
 
 func (m *Policy) XXX_Unmarshal(b []byte) error {
@@ -708,6 +1016,48 @@ func (m *Policy) GetName() string {
 	return ""
 }
 
+func (m *Policy) GetAllowedBeneficiaryCIDs() []string {
+	if m != nil {
+		return m.AllowedBeneficiaryCIDs
+	}
+	return nil
+}
+
+func (m *Policy) GetMinRedemptionDelay() int64 {
+	if m != nil {
+		return m.MinRedemptionDelay
+	}
+	return 0
+}
+
+func (m *Policy) GetRequiredApproverCIDs() []string {
+	if m != nil {
+		return m.RequiredApproverCIDs
+	}
+	return nil
+}
+
+func (m *Policy) GetMaxRedemptions() int64 {
+	if m != nil {
+		return m.MaxRedemptions
+	}
+	return 0
+}
+
+func (m *Policy) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *Policy) GetApprovalQuorum() int64 {
+	if m != nil {
+		return m.ApprovalQuorum
+	}
+	return 0
+}
+
 type PlainTestMessage1 struct {
 	Nametest1            string   `protobuf:"bytes,1,opt,name=Nametest1,proto3" json:"Nametest1,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -719,7 +1069,7 @@ func (m *PlainTestMessage1) Reset()         { *m = PlainTestMessage1{} }
 func (m *PlainTestMessage1) String() string { return proto.CompactTextString(m) }
 func (*PlainTestMessage1) ProtoMessage()    {}
 func (*PlainTestMessage1) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2a25dace11219bce, []int{10}
+	return fileDescriptor_2a25dace11219bce, []int{14}
 }
 
 func (m *PlainTestMessage1) XXX_Unmarshal(b []byte) error {
@@ -758,7 +1108,7 @@ func (m *EncryptTestMessage1) Reset()         { *m = EncryptTestMessage1{} }
 func (m *EncryptTestMessage1) String() string { return proto.CompactTextString(m) }
 func (*EncryptTestMessage1) ProtoMessage()    {}
 func (*EncryptTestMessage1) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2a25dace11219bce, []int{11}
+	return fileDescriptor_2a25dace11219bce, []int{15}
 }
 
 func (m *EncryptTestMessage1) XXX_Unmarshal(b []byte) error {
@@ -797,7 +1147,7 @@ func (m *SimpleString) Reset()         { *m = SimpleString{} }
 func (m *SimpleString) String() string { return proto.CompactTextString(m) }
 func (*SimpleString) ProtoMessage()    {}
 func (*SimpleString) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2a25dace11219bce, []int{12}
+	return fileDescriptor_2a25dace11219bce, []int{16}
 }
 
 func (m *SimpleString) XXX_Unmarshal(b []byte) error {
@@ -832,9 +1182,13 @@ func init() {
 	proto.RegisterType((*Recipient)(nil), "documents.Recipient")
 	proto.RegisterType((*IDDocument)(nil), "documents.IDDocument")
 	proto.RegisterType((*OrderDocument)(nil), "documents.OrderDocument")
+	proto.RegisterType((*SecretShare)(nil), "documents.SecretShare")
+	proto.RegisterType((*ShareDeal)(nil), "documents.ShareDeal")
 	proto.RegisterType((*OrderPart2)(nil), "documents.OrderPart2")
 	proto.RegisterType((*OrderPart3)(nil), "documents.OrderPart3")
 	proto.RegisterType((*OrderPart4)(nil), "documents.OrderPart4")
+	proto.RegisterType((*OrderCancel)(nil), "documents.OrderCancel")
+	proto.RegisterType((*OrderTombstone)(nil), "documents.OrderTombstone")
 	proto.RegisterType((*Policy)(nil), "documents.Policy")
 	proto.RegisterType((*PlainTestMessage1)(nil), "documents.PlainTestMessage1")
 	proto.RegisterType((*EncryptTestMessage1)(nil), "documents.EncryptTestMessage1")
@@ -844,65 +1198,91 @@ func init() {
 func init() { proto.RegisterFile("docs.proto", fileDescriptor_2a25dace11219bce) }
 
 var fileDescriptor_2a25dace11219bce = []byte{
-	// 956 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x56, 0x4f, 0x6f, 0x1b, 0x45,
-	0x14, 0xd7, 0xda, 0x8e, 0x13, 0xbf, 0xb8, 0x21, 0x9d, 0x26, 0xed, 0xaa, 0x20, 0x12, 0xad, 0x22,
-	0x64, 0x24, 0xe2, 0x10, 0xdb, 0x8d, 0x20, 0x42, 0x42, 0xd8, 0x0e, 0x60, 0xf1, 0xcf, 0x8c, 0xa3,
-	0x08, 0xa9, 0x6a, 0xa5, 0xc9, 0xee, 0x8b, 0x33, 0xaa, 0x77, 0x67, 0xb5, 0x3b, 0x4e, 0xb1, 0x80,
-	0x33, 0x07, 0x8e, 0x9c, 0xb9, 0x20, 0xf5, 0xca, 0x11, 0xbe, 0x00, 0x47, 0xbe, 0x43, 0x44, 0x0e,
-	0x20, 0xbe, 0x01, 0x37, 0xd0, 0xcc, 0xfe, 0x0f, 0x71, 0x93, 0x03, 0x52, 0x7d, 0x9a, 0xf7, 0x7e,
-	0xef, 0xf7, 0xe6, 0xfd, 0x1d, 0x2f, 0x80, 0x23, 0xec, 0xb0, 0xe9, 0x07, 0x42, 0x0a, 0x52, 0x73,
-	0x84, 0x3d, 0x75, 0xd1, 0x93, 0xe1, 0xfd, 0xbd, 0x31, 0x97, 0xa7, 0xd3, 0xe3, 0xa6, 0x2d, 0xdc,
-	0x1d, 0xf7, 0x29, 0x97, 0x4f, 0xc4, 0xd3, 0x9d, 0xb1, 0xd8, 0xd6, 0x76, 0xdb, 0x67, 0x6c, 0xc2,
-	0x1d, 0x26, 0x45, 0x10, 0xee, 0xa4, 0xc7, 0xc8, 0xc5, 0xfd, 0xed, 0x1c, 0x6f, 0x2c, 0xc6, 0x62,
-	0x47, 0xab, 0x8f, 0xa7, 0x27, 0x5a, 0xd2, 0x82, 0x3e, 0x45, 0xe6, 0xd6, 0x77, 0x06, 0xac, 0x8c,
-	0xf8, 0xd8, 0x43, 0xe7, 0xc0, 0x3b, 0xc3, 0x89, 0xf0, 0x91, 0x6c, 0x41, 0x4d, 0x69, 0x98, 0x9c,
-	0x06, 0x68, 0x1a, 0x9b, 0x46, 0xa3, 0xde, 0xad, 0x5e, 0x9c, 0x6f, 0x94, 0xfc, 0x35, 0x9a, 0x01,
-	0xe4, 0xed, 0xc8, 0x0a, 0x83, 0xde, 0xa0, 0x6f, 0x96, 0x36, 0x8d, 0x46, 0xad, 0xfb, 0xf2, 0xc5,
-	0xf9, 0xc6, 0x3d, 0x58, 0x7f, 0xfc, 0xf9, 0xc3, 0x87, 0xfb, 0x6c, 0xe2, 0x4d, 0xdd, 0xfd, 0x47,
-	0x8f, 0xbe, 0xea, 0x3c, 0xf8, 0x66, 0xeb, 0xeb, 0xc7, 0x5b, 0x34, 0xb3, 0x26, 0x26, 0x2c, 0x7e,
-	0x82, 0x61, 0xc8, 0xc6, 0x68, 0x96, 0x95, 0x7b, 0x9a, 0x88, 0x96, 0x80, 0xa5, 0x34, 0x8c, 0xd7,
-	0xa1, 0xfa, 0x21, 0x32, 0x07, 0x03, 0x1d, 0xc3, 0x72, 0xeb, 0x76, 0x33, 0x2d, 0x4e, 0x33, 0x02,
-	0x68, 0x6c, 0x40, 0x08, 0x54, 0xba, 0xc2, 0x99, 0xe9, 0x30, 0xea, 0x54, 0x9f, 0xc9, 0x16, 0xdc,
-	0x3a, 0xf0, 0xec, 0x60, 0xe6, 0x4b, 0x74, 0x34, 0x18, 0x5d, 0x55, 0x54, 0x5a, 0x3f, 0x96, 0x93,
-	0x5b, 0xc8, 0x5d, 0xa8, 0x0e, 0x86, 0xef, 0x8f, 0x06, 0x7d, 0x7d, 0x5f, 0x8d, 0xc6, 0x92, 0x8a,
-	0xf6, 0x08, 0x83, 0x90, 0x0b, 0x4f, 0xfb, 0x2f, 0xd1, 0x44, 0x24, 0x6f, 0xc0, 0x52, 0x9f, 0x49,
-	0x3c, 0xe4, 0x6e, 0x94, 0x48, 0xb9, 0xbb, 0x7a, 0x71, 0xbe, 0x51, 0x5f, 0x7d, 0xf6, 0xed, 0x1f,
-	0x7f, 0x2d, 0x98, 0xcf, 0x7e, 0xfd, 0xf9, 0xfb, 0x19, 0x4d, 0x2d, 0xc8, 0x26, 0x2c, 0x0f, 0x03,
-	0x3c, 0xe3, 0x62, 0x1a, 0xaa, 0x92, 0x55, 0xf4, 0x25, 0x79, 0x15, 0xb1, 0xa0, 0xae, 0x82, 0x3a,
-	0x9c, 0xf9, 0xd8, 0x13, 0x0e, 0x9a, 0x0b, 0xfa, 0xba, 0x82, 0x4e, 0x79, 0x51, 0x72, 0x12, 0x51,
-	0x55, 0x9b, 0xe4, 0x55, 0xa4, 0x03, 0xeb, 0x85, 0x1c, 0x53, 0x77, 0x8b, 0xda, 0xf6, 0x6a, 0x90,
-	0xb4, 0x60, 0xad, 0x00, 0x24, 0x17, 0x2c, 0x69, 0xd2, 0x95, 0x18, 0x69, 0xc0, 0x4b, 0x05, 0xfd,
-	0xe0, 0xc8, 0xac, 0xe9, 0x22, 0x5f, 0x56, 0x93, 0x77, 0x00, 0x28, 0xda, 0xdc, 0xe7, 0xaa, 0x7b,
-	0x26, 0x6c, 0x96, 0x1b, 0xcb, 0xad, 0xb5, 0x5c, 0x3f, 0x53, 0x30, 0x9a, 0xb4, 0xd3, 0x35, 0x9a,
-	0xb3, 0xb7, 0x7e, 0x32, 0xa0, 0x96, 0x8a, 0xf9, 0x7e, 0x18, 0xc5, 0x7e, 0x6c, 0x43, 0xf9, 0x86,
-	0xc3, 0xa8, 0xec, 0xe2, 0xf0, 0x99, 0x1f, 0x4e, 0x27, 0x4c, 0xa2, 0xf3, 0x11, 0x26, 0x33, 0x72,
-	0x59, 0x4d, 0x5e, 0x05, 0xe8, 0x71, 0xff, 0x14, 0x83, 0x43, 0xfc, 0x52, 0xea, 0xce, 0xd5, 0x69,
-	0x4e, 0x43, 0x56, 0xa0, 0x34, 0x38, 0xd2, 0xed, 0xaa, 0xd3, 0xd2, 0xe0, 0xc8, 0xfa, 0xdb, 0x00,
-	0x18, 0xf4, 0xfb, 0x71, 0x7a, 0xe4, 0x2d, 0xb8, 0xf7, 0xde, 0x54, 0x9e, 0xa2, 0x27, 0xb9, 0xcd,
-	0x24, 0x17, 0x1e, 0xc5, 0x13, 0x0c, 0xd0, 0xb3, 0x31, 0x1e, 0xb5, 0x79, 0x30, 0xd9, 0x83, 0xbb,
-	0x5d, 0xf4, 0xf0, 0x84, 0xdb, 0x9c, 0x05, 0xb3, 0x83, 0xde, 0x70, 0x7a, 0x3c, 0xe1, 0xb6, 0x8a,
-	0x34, 0x1a, 0xf5, 0x39, 0xa8, 0x1a, 0xfe, 0x11, 0x7f, 0x82, 0x99, 0x79, 0x3c, 0xfc, 0x05, 0xa5,
-	0x9e, 0xb7, 0x8f, 0x47, 0x99, 0x51, 0x94, 0x58, 0x41, 0x47, 0x9a, 0x50, 0x53, 0xd3, 0x1b, 0x4a,
-	0xe6, 0xfa, 0x3a, 0xc3, 0xab, 0x86, 0x3c, 0x33, 0xb1, 0x7e, 0x2b, 0xc3, 0xad, 0xcf, 0x02, 0x07,
-	0x83, 0x34, 0x7b, 0x02, 0x15, 0x35, 0x65, 0x71, 0xaa, 0xfa, 0x4c, 0x5e, 0x83, 0x4a, 0x4f, 0xf0,
-	0x68, 0xa1, 0xca, 0x5d, 0x72, 0x71, 0xbe, 0xb1, 0xb2, 0xfa, 0x4f, 0xf2, 0x33, 0xcc, 0x3f, 0x17,
-	0xa9, 0xc6, 0xc9, 0xbb, 0x50, 0x1f, 0x06, 0xdc, 0xb3, 0xb9, 0xcf, 0x26, 0xaa, 0xb5, 0xe5, 0xeb,
-	0x5b, 0x5b, 0x20, 0x90, 0x1e, 0xac, 0xe4, 0x4a, 0x94, 0xee, 0xdd, 0xf3, 0x5d, 0x5c, 0xa2, 0xa8,
-	0x07, 0x31, 0xeb, 0xd8, 0x82, 0xe6, 0xeb, 0x31, 0xfd, 0xc2, 0xa0, 0x19, 0x50, 0xac, 0x54, 0xf5,
-	0xda, 0x4a, 0x91, 0x07, 0x00, 0xba, 0x50, 0x43, 0x16, 0xc8, 0x96, 0x5e, 0xce, 0xe5, 0xd6, 0x7a,
-	0x6e, 0x27, 0x32, 0x90, 0xe6, 0x0c, 0x0b, 0xb4, 0xb6, 0x5e, 0xcf, 0x39, 0xb4, 0x76, 0x8e, 0xd6,
-	0x2e, 0xd0, 0x3a, 0x7a, 0x4d, 0xe7, 0xd0, 0x3a, 0x39, 0x5a, 0xc7, 0xfa, 0xc5, 0xc8, 0x47, 0x49,
-	0xde, 0x84, 0x3b, 0x3d, 0xe1, 0xba, 0x5c, 0x2a, 0x52, 0x36, 0x38, 0x51, 0x6b, 0xaf, 0x82, 0xc8,
-	0x07, 0xb0, 0x9a, 0x3c, 0x71, 0xda, 0xcf, 0x0d, 0x17, 0xf4, 0x3f, 0xa4, 0x62, 0x79, 0xcb, 0xd7,
-	0x0f, 0xe2, 0xef, 0xf9, 0xc8, 0xdb, 0x6a, 0x85, 0x29, 0x3a, 0xe8, 0xfa, 0x32, 0x79, 0x38, 0x6a,
-	0x34, 0xa7, 0xf9, 0xff, 0xe2, 0xdc, 0x07, 0x33, 0xbf, 0x94, 0xc9, 0x43, 0xd8, 0x67, 0x92, 0xc5,
-	0x5b, 0x38, 0x17, 0x2f, 0xe6, 0x58, 0xb9, 0x3e, 0xc7, 0x1f, 0xf2, 0x39, 0x76, 0xd4, 0x3f, 0xd8,
-	0x08, 0xed, 0x00, 0x65, 0xf2, 0x0f, 0x16, 0x49, 0x2f, 0xae, 0x07, 0x7b, 0x50, 0x1d, 0x8a, 0x09,
-	0xb7, 0x67, 0xcf, 0x79, 0xb4, 0x09, 0x54, 0x3e, 0x65, 0x2e, 0x46, 0x01, 0x51, 0x7d, 0xb6, 0x76,
-	0xe1, 0xf6, 0x70, 0xc2, 0xb8, 0x77, 0x88, 0xa1, 0x8c, 0x3f, 0x0d, 0x76, 0xc9, 0x2b, 0x50, 0x53,
-	0xa0, 0xc4, 0x50, 0xee, 0xc6, 0x09, 0x66, 0x0a, 0xab, 0x0d, 0x77, 0xe2, 0x5a, 0xce, 0x23, 0xb5,
-	0x2e, 0x93, 0x5a, 0x56, 0x03, 0xea, 0x23, 0xee, 0xfa, 0x13, 0x1c, 0xc9, 0x80, 0x7b, 0x63, 0x15,
-	0x65, 0x4f, 0x78, 0x12, 0xbd, 0xa4, 0x82, 0x89, 0x78, 0x5c, 0xd5, 0x5f, 0x4b, 0xed, 0x7f, 0x03,
-	0x00, 0x00, 0xff, 0xff, 0x54, 0x80, 0x91, 0x77, 0xad, 0x09, 0x00, 0x00,
+	// 1367 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x57, 0xcd, 0x6f, 0x1b, 0xd5,
+	0x16, 0xd7, 0xf8, 0x2b, 0xf1, 0xb1, 0xe3, 0x26, 0xb7, 0x4e, 0x7a, 0x5f, 0xdf, 0xd3, 0x73, 0x34,
+	0x8a, 0xde, 0x4b, 0x51, 0x93, 0x50, 0x27, 0x2d, 0x6d, 0x54, 0x51, 0xc5, 0x76, 0x0a, 0x16, 0xb4,
+	0xb8, 0xd7, 0x51, 0xd4, 0xaa, 0x6a, 0xa5, 0x89, 0xe7, 0xc6, 0xbe, 0xea, 0x78, 0xee, 0x30, 0x33,
+	0x4e, 0x6b, 0x41, 0xd7, 0x08, 0xb1, 0x64, 0xc1, 0x0e, 0x89, 0x45, 0x59, 0xb2, 0x44, 0xfc, 0x01,
+	0xec, 0xd8, 0xb3, 0xb5, 0xe4, 0x05, 0x88, 0xff, 0x80, 0x1d, 0xe8, 0xde, 0xf9, 0x76, 0xec, 0x26,
+	0x08, 0xa4, 0x7a, 0x35, 0xe7, 0x9c, 0xdf, 0xb9, 0xe7, 0xdc, 0xf3, 0x79, 0x0d, 0xa0, 0xf3, 0x8e,
+	0xb3, 0x69, 0xd9, 0xdc, 0xe5, 0x28, 0xaf, 0xf3, 0xce, 0xa0, 0x4f, 0x4d, 0xd7, 0xb9, 0x7c, 0xa3,
+	0xcb, 0xdc, 0xde, 0xe0, 0x68, 0xb3, 0xc3, 0xfb, 0x5b, 0xfd, 0xe7, 0xcc, 0x7d, 0xc6, 0x9f, 0x6f,
+	0x75, 0xf9, 0x86, 0xc4, 0x6d, 0x9c, 0x68, 0x06, 0xd3, 0x35, 0x97, 0xdb, 0xce, 0x56, 0xf8, 0xe9,
+	0x1d, 0x71, 0x79, 0x23, 0xa6, 0xd7, 0xe5, 0x5d, 0xbe, 0x25, 0xd9, 0x47, 0x83, 0x63, 0x49, 0x49,
+	0x42, 0x7e, 0x79, 0x70, 0xf5, 0x0b, 0x05, 0x4a, 0x6d, 0xd6, 0x35, 0xa9, 0xbe, 0x6f, 0x9e, 0x50,
+	0x83, 0x5b, 0x14, 0xad, 0x41, 0x5e, 0x70, 0x34, 0x77, 0x60, 0x53, 0xac, 0xac, 0x2a, 0xeb, 0xc5,
+	0x5a, 0x6e, 0x3c, 0xaa, 0xa4, 0xac, 0x32, 0x89, 0x04, 0xe8, 0x96, 0x87, 0xa2, 0x76, 0xbd, 0xd9,
+	0xc0, 0xa9, 0x55, 0x65, 0x3d, 0x5f, 0xfb, 0xf7, 0x78, 0x54, 0xb9, 0x04, 0xcb, 0x4f, 0x1f, 0x3c,
+	0x7e, 0xbc, 0xab, 0x19, 0xe6, 0xa0, 0xbf, 0xfb, 0xe4, 0xc9, 0x27, 0x3b, 0xd7, 0x5f, 0xae, 0x7d,
+	0xfa, 0x74, 0x8d, 0x44, 0x68, 0x84, 0x61, 0xee, 0x1e, 0x75, 0x1c, 0xad, 0x4b, 0x71, 0x5a, 0x1c,
+	0x4f, 0x02, 0x52, 0xe5, 0x30, 0x1f, 0xba, 0x71, 0x05, 0x72, 0xef, 0x53, 0x4d, 0xa7, 0xb6, 0xf4,
+	0xa1, 0x50, 0x5d, 0xda, 0x0c, 0x83, 0xb3, 0xe9, 0x09, 0x88, 0x0f, 0x40, 0x08, 0x32, 0x35, 0xae,
+	0x0f, 0xa5, 0x1b, 0x45, 0x22, 0xbf, 0xd1, 0x1a, 0x2c, 0xec, 0x9b, 0x1d, 0x7b, 0x68, 0xb9, 0x54,
+	0x97, 0x42, 0xcf, 0x54, 0x92, 0xa9, 0xfe, 0x90, 0x0e, 0xac, 0xa0, 0x15, 0xc8, 0x35, 0x5b, 0x77,
+	0xdb, 0xcd, 0x86, 0xb4, 0x97, 0x27, 0x3e, 0x25, 0xbc, 0x3d, 0xa4, 0xb6, 0xc3, 0xb8, 0x29, 0xcf,
+	0x4f, 0x91, 0x80, 0x44, 0x57, 0x61, 0xbe, 0xa1, 0xb9, 0xf4, 0x80, 0xf5, 0xbd, 0x8b, 0xa4, 0x6b,
+	0x8b, 0xe3, 0x51, 0xa5, 0xb8, 0xf8, 0xea, 0xb3, 0x5f, 0x7e, 0xcb, 0xe2, 0x57, 0x3f, 0x7e, 0xff,
+	0xe5, 0x90, 0x84, 0x08, 0xb4, 0x0a, 0x85, 0x96, 0x4d, 0x4f, 0x18, 0x1f, 0x38, 0x22, 0x64, 0x19,
+	0x69, 0x24, 0xce, 0x42, 0x2a, 0x14, 0x85, 0x53, 0x07, 0x43, 0x8b, 0xd6, 0xb9, 0x4e, 0x71, 0x56,
+	0x9a, 0x4b, 0xf0, 0xc4, 0x29, 0x82, 0x0e, 0x3c, 0xca, 0x49, 0x48, 0x9c, 0x85, 0x76, 0x60, 0x39,
+	0x71, 0xc7, 0xf0, 0xb8, 0x39, 0x89, 0x9d, 0x2e, 0x44, 0x55, 0x28, 0x27, 0x04, 0x81, 0x81, 0x79,
+	0xa9, 0x34, 0x55, 0x86, 0xd6, 0xe1, 0x42, 0x82, 0xdf, 0x3c, 0xc4, 0x79, 0x19, 0xe4, 0x49, 0x36,
+	0xba, 0x0d, 0x40, 0x68, 0x87, 0x59, 0x4c, 0x64, 0x0f, 0xc3, 0x6a, 0x7a, 0xbd, 0x50, 0x2d, 0xc7,
+	0xf2, 0x19, 0x0a, 0xbd, 0x4a, 0xeb, 0x95, 0x49, 0x0c, 0x8f, 0xca, 0x90, 0xbd, 0xcf, 0xcd, 0x0e,
+	0xc5, 0x05, 0x79, 0xba, 0x47, 0xa8, 0xdf, 0x29, 0x90, 0x0f, 0x41, 0xf1, 0x2c, 0x29, 0xc9, 0x2c,
+	0x6d, 0x40, 0xfa, 0x9c, 0x25, 0x2a, 0x70, 0xfe, 0xa5, 0x34, 0xcb, 0x19, 0x18, 0x9a, 0x4b, 0xf5,
+	0x0f, 0x68, 0x50, 0x39, 0x93, 0x6c, 0xf4, 0x5f, 0x80, 0x3a, 0xb3, 0x7a, 0xd4, 0x3e, 0xa0, 0x2f,
+	0x5c, 0x99, 0xcf, 0x22, 0x89, 0x71, 0x50, 0x09, 0x52, 0xcd, 0x43, 0x99, 0xc4, 0x22, 0x49, 0x35,
+	0x0f, 0xd5, 0xdf, 0x15, 0x80, 0x66, 0xa3, 0xe1, 0x5f, 0x1a, 0xdd, 0x84, 0x4b, 0x7b, 0x03, 0xb7,
+	0x47, 0x4d, 0x97, 0x75, 0x34, 0x97, 0x71, 0x93, 0xd0, 0x63, 0x6a, 0x53, 0x71, 0x4f, 0xaf, 0x00,
+	0x67, 0x89, 0xd1, 0x0d, 0x58, 0xa9, 0x51, 0x93, 0x1e, 0xb3, 0x0e, 0xd3, 0xec, 0xe1, 0x7e, 0xbd,
+	0x35, 0x38, 0x32, 0x58, 0x47, 0x78, 0xea, 0x35, 0xc0, 0x0c, 0xa9, 0x68, 0x89, 0x36, 0x7b, 0x46,
+	0x23, 0xb8, 0xdf, 0x12, 0x09, 0xa6, 0xac, 0xc2, 0x0f, 0xdb, 0x11, 0xc8, 0xbb, 0x58, 0x82, 0x87,
+	0x36, 0x21, 0x2f, 0x6a, 0xda, 0x71, 0xb5, 0xbe, 0x85, 0xb3, 0x33, 0x4a, 0x3f, 0x82, 0xa8, 0x3f,
+	0xe7, 0x60, 0xe1, 0x23, 0x5b, 0xa7, 0x76, 0x78, 0x7b, 0x04, 0x19, 0x51, 0x7b, 0xfe, 0x55, 0xe5,
+	0x37, 0xfa, 0x1f, 0x64, 0xea, 0x9c, 0x79, 0x6d, 0x96, 0xae, 0xa1, 0xf1, 0xa8, 0x52, 0x5a, 0xfc,
+	0x23, 0xf8, 0x29, 0xf8, 0xd7, 0x39, 0x22, 0xe5, 0xe8, 0x0e, 0x14, 0x5b, 0x36, 0x33, 0x3b, 0xcc,
+	0xd2, 0x0c, 0x91, 0xda, 0xf4, 0xd9, 0xa9, 0x4d, 0x28, 0xa0, 0x3a, 0x94, 0x62, 0x21, 0x0a, 0xbb,
+	0xf1, 0xf5, 0x47, 0x4c, 0xa8, 0x88, 0x31, 0x19, 0x65, 0x2c, 0x2b, 0xf5, 0x65, 0xf1, 0x3e, 0x54,
+	0x48, 0x24, 0x48, 0x46, 0x2a, 0x77, 0x66, 0xa4, 0xd0, 0x75, 0x00, 0x19, 0xa8, 0x96, 0x66, 0xbb,
+	0x55, 0xd9, 0xb2, 0x85, 0xea, 0x72, 0xac, 0x53, 0x22, 0x21, 0x89, 0x01, 0x13, 0x6a, 0xdb, 0x78,
+	0x7e, 0xb6, 0xda, 0x76, 0x4c, 0x6d, 0x3b, 0xa1, 0xb6, 0x83, 0xf3, 0xb3, 0xd5, 0x76, 0x62, 0x6a,
+	0x3b, 0xe8, 0x3f, 0x90, 0x3f, 0xe8, 0xd9, 0xd4, 0xe9, 0x71, 0x43, 0xc7, 0x20, 0x2e, 0x45, 0x22,
+	0x06, 0xba, 0x0a, 0x0b, 0x77, 0x99, 0x3e, 0x08, 0x02, 0xe5, 0xe0, 0xc2, 0x6a, 0x3a, 0x08, 0x4e,
+	0xaf, 0x4c, 0x92, 0x42, 0x74, 0x15, 0xb2, 0xed, 0x9e, 0x66, 0x53, 0x5c, 0x94, 0xd6, 0x57, 0x62,
+	0xd6, 0xdb, 0xb4, 0x63, 0x53, 0x57, 0x4a, 0x89, 0x07, 0x42, 0x37, 0xa1, 0x20, 0xfd, 0xa8, 0x6b,
+	0x66, 0x87, 0x1a, 0x78, 0xe1, 0x94, 0x4e, 0x4c, 0x4a, 0xe2, 0x50, 0xb4, 0x07, 0x25, 0x49, 0x1e,
+	0xf0, 0xfe, 0x91, 0xe3, 0x72, 0x93, 0xe2, 0x92, 0x54, 0xfe, 0xd7, 0xa4, 0x72, 0x08, 0x20, 0x13,
+	0x0a, 0x68, 0x03, 0xf2, 0xf7, 0xb9, 0x5b, 0xa3, 0xc7, 0xdc, 0xa6, 0xf8, 0x82, 0xcc, 0xe5, 0x85,
+	0xf1, 0xa8, 0x52, 0x88, 0x15, 0x29, 0x89, 0x10, 0xe8, 0xff, 0x90, 0xdb, 0x7f, 0x61, 0x31, 0x7b,
+	0x88, 0x17, 0xa7, 0x63, 0x7d, 0xb1, 0x58, 0xa5, 0x2d, 0x6e, 0xb0, 0x8e, 0xac, 0xc4, 0xa5, 0x73,
+	0xac, 0xd2, 0x10, 0xad, 0x5e, 0x81, 0x42, 0x2c, 0x4a, 0xa8, 0x08, 0xca, 0x43, 0x6f, 0x65, 0x13,
+	0xe5, 0xa1, 0xa0, 0x1e, 0xf9, 0x23, 0x41, 0x79, 0xa4, 0xbe, 0x84, 0xbc, 0x04, 0x35, 0xa8, 0x66,
+	0xa0, 0xdb, 0x50, 0x8c, 0xa7, 0xc1, 0x6b, 0xc3, 0x1a, 0x1e, 0x8f, 0x2a, 0x65, 0x40, 0xa7, 0xad,
+	0x92, 0x04, 0x1a, 0x55, 0x61, 0x4e, 0x9c, 0x12, 0x8d, 0xd5, 0xd9, 0x8a, 0x01, 0x50, 0xfd, 0x26,
+	0x15, 0xaf, 0x6c, 0xf4, 0x36, 0x5c, 0xac, 0xf3, 0x7e, 0x9f, 0xb9, 0x22, 0xf2, 0xd1, 0xb0, 0xf1,
+	0xc6, 0xc1, 0x34, 0x11, 0x7a, 0x0f, 0x16, 0x83, 0x65, 0xe9, 0xe5, 0xf5, 0x7c, 0x43, 0xfd, 0x94,
+	0x52, 0xb2, 0x25, 0xd3, 0x67, 0xb7, 0xe4, 0x5b, 0xb0, 0x28, 0x03, 0x17, 0x39, 0xe5, 0xe0, 0xcc,
+	0x6a, 0x7a, 0xbd, 0x48, 0x4e, 0xf1, 0xc5, 0xa2, 0x0b, 0x83, 0xec, 0xe0, 0xec, 0xa9, 0x45, 0x17,
+	0x0a, 0xa3, 0x45, 0x17, 0xe1, 0xd5, 0x6f, 0xe3, 0x31, 0xda, 0x16, 0x0b, 0x86, 0x50, 0x9d, 0xf6,
+	0x2d, 0x37, 0x58, 0x6b, 0x79, 0x12, 0xe3, 0xfc, 0x73, 0x11, 0xd9, 0x05, 0x1c, 0x5f, 0x19, 0xc1,
+	0xf2, 0x6e, 0x68, 0xae, 0xe6, 0xef, 0x88, 0x99, 0xf2, 0x64, 0x34, 0x33, 0x67, 0x47, 0xf3, 0xf4,
+	0xec, 0xcd, 0xfe, 0xe5, 0xd9, 0xab, 0xfe, 0xa4, 0xc4, 0x07, 0x97, 0x78, 0xba, 0x79, 0x5d, 0x10,
+	0x3c, 0xdd, 0x3c, 0xea, 0xcd, 0x95, 0x4c, 0x38, 0xd4, 0x32, 0xe7, 0x18, 0x6a, 0xea, 0xd7, 0x4a,
+	0x62, 0xaa, 0x89, 0xeb, 0x10, 0xaa, 0x39, 0x61, 0xce, 0x7d, 0xea, 0x8d, 0x5d, 0x47, 0xfd, 0x5c,
+	0x99, 0x1c, 0x9e, 0x53, 0x7d, 0x51, 0xfe, 0xb6, 0x2f, 0xa9, 0xb3, 0x7d, 0xf9, 0x2a, 0x0d, 0x39,
+	0x6f, 0xfe, 0xbd, 0xe6, 0xcd, 0x87, 0x20, 0x73, 0x5f, 0xeb, 0x53, 0x2f, 0x3a, 0x44, 0x7e, 0xa3,
+	0x77, 0x61, 0x65, 0xcf, 0x30, 0xf8, 0x73, 0xaa, 0x27, 0x8b, 0xc9, 0xc1, 0xe9, 0xd8, 0x7e, 0xd2,
+	0xc9, 0x0c, 0x14, 0xba, 0x03, 0xe8, 0x1e, 0x33, 0xa3, 0xf6, 0x6b, 0x50, 0x43, 0x1b, 0xe2, 0xcc,
+	0xf4, 0xd1, 0x3e, 0x05, 0x8a, 0x76, 0xa1, 0x4c, 0xe8, 0xc7, 0x03, 0x66, 0x53, 0x7d, 0xcf, 0xb2,
+	0x6c, 0x7e, 0x22, 0x03, 0xe0, 0x4d, 0x89, 0x68, 0x3d, 0x4e, 0xc5, 0xa0, 0x77, 0xa0, 0x74, 0x4f,
+	0x7b, 0x11, 0x9d, 0xe8, 0xe0, 0xdc, 0x74, 0xc3, 0x13, 0xb0, 0x64, 0x78, 0xe7, 0xce, 0xae, 0xdc,
+	0x5b, 0x50, 0xf2, 0x0c, 0x6b, 0xc6, 0x83, 0x01, 0xb7, 0x07, 0x7d, 0xf9, 0x98, 0x48, 0xd7, 0x96,
+	0xc6, 0xa3, 0xca, 0x42, 0xcc, 0x10, 0x5e, 0x26, 0x13, 0x40, 0xf5, 0x1a, 0x2c, 0xb5, 0x0c, 0x8d,
+	0x99, 0x07, 0xd4, 0x71, 0xfd, 0x3f, 0x74, 0xd7, 0xc4, 0x53, 0x41, 0x44, 0xdf, 0xa5, 0x8e, 0x7b,
+	0xcd, 0x2f, 0xe7, 0x88, 0xa1, 0x6e, 0xc3, 0x45, 0x7f, 0x9a, 0xcc, 0x52, 0xaa, 0x4e, 0x2a, 0x55,
+	0xd5, 0x75, 0x28, 0xb6, 0x59, 0xdf, 0x32, 0x68, 0xdb, 0xb5, 0x99, 0xd9, 0x15, 0x65, 0x50, 0xe7,
+	0xa6, 0x4b, 0xcd, 0xa0, 0xfd, 0x03, 0xf2, 0x28, 0x27, 0xff, 0xe3, 0x6e, 0xff, 0x39, 0x00, 0x05,
+	0x7c, 0x25, 0x90, 0x63, 0x0f, 0x00, 0x00,
 }
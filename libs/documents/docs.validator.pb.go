@@ -77,6 +77,7 @@ func (this *IDDocument) Validate() error {
 
 var _regex_OrderDocument_PrincipalCID = regexp.MustCompile(`^Q[[:alnum:]]{45}$|^$`)
 var _regex_OrderDocument_BeneficiaryCID = regexp.MustCompile(`^Q[[:alnum:]]{45}$|^$`)
+var _regex_OrderDocument_PolicyCID = regexp.MustCompile(`^Q[[:alnum:]]{45}$|^$`)
 
 func (this *OrderDocument) Validate() error {
 	if !(this.Coin > -1) {
@@ -115,6 +116,49 @@ func (this *OrderDocument) Validate() error {
 			return github_com_mwitkow_go_proto_validators.FieldError("OrderPart4", err)
 		}
 	}
+	if len(this.FiduciaryCIDs) > 20 {
+		return github_com_mwitkow_go_proto_validators.FieldError("FiduciaryCIDs", fmt.Errorf(`value '%v' must contain at most 20 elements`, this.FiduciaryCIDs))
+	}
+	if this.Share != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Share); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Share", err)
+		}
+	}
+	if this.OrderCancel != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.OrderCancel); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("OrderCancel", err)
+		}
+	}
+	if this.OrderTombstone != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.OrderTombstone); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("OrderTombstone", err)
+		}
+	}
+	if !(this.NotBefore > -1) {
+		return github_com_mwitkow_go_proto_validators.FieldError("NotBefore", fmt.Errorf(`value '%v' must be greater than '-1'`, this.NotBefore))
+	}
+	if !(this.Expiry > -1) {
+		return github_com_mwitkow_go_proto_validators.FieldError("Expiry", fmt.Errorf(`value '%v' must be greater than '-1'`, this.Expiry))
+	}
+	if !_regex_OrderDocument_PolicyCID.MatchString(this.PolicyCID) {
+		return github_com_mwitkow_go_proto_validators.FieldError("PolicyCID", fmt.Errorf(`value '%v' must be a string conforming to regex "^Q[[:alnum:]]{45}$|^$"`, this.PolicyCID))
+	}
+	return nil
+}
+func (this *SecretShare) Validate() error {
+	return nil
+}
+
+var _regex_ShareDeal_FiduciaryCID = regexp.MustCompile(`^Q[[:alnum:]]{45}$`)
+var _regex_ShareDeal_DealCID = regexp.MustCompile(`^Q[[:alnum:]]{45}$`)
+
+func (this *ShareDeal) Validate() error {
+	if !_regex_ShareDeal_FiduciaryCID.MatchString(this.FiduciaryCID) {
+		return github_com_mwitkow_go_proto_validators.FieldError("FiduciaryCID", fmt.Errorf(`value '%v' must be a string conforming to regex "^Q[[:alnum:]]{45}$"`, this.FiduciaryCID))
+	}
+	if !_regex_ShareDeal_DealCID.MatchString(this.DealCID) {
+		return github_com_mwitkow_go_proto_validators.FieldError("DealCID", fmt.Errorf(`value '%v' must be a string conforming to regex "^Q[[:alnum:]]{45}$"`, this.DealCID))
+	}
 	return nil
 }
 
@@ -130,10 +174,21 @@ func (this *OrderPart2) Validate() error {
 	if !(this.Timestamp < 32521429541) {
 		return github_com_mwitkow_go_proto_validators.FieldError("Timestamp", fmt.Errorf(`value '%v' must be less than '32521429541'`, this.Timestamp))
 	}
+	if len(this.ShareDeals) > 20 {
+		return github_com_mwitkow_go_proto_validators.FieldError("ShareDeals", fmt.Errorf(`value '%v' must contain at most 20 elements`, this.ShareDeals))
+	}
+	for _, item := range this.ShareDeals {
+		if item != nil {
+			if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(item); err != nil {
+				return github_com_mwitkow_go_proto_validators.FieldError("ShareDeals", err)
+			}
+		}
+	}
 	return nil
 }
 
 var _regex_OrderPart3_PreviousOrderCID = regexp.MustCompile(`^Q[[:alnum:]]{45}$|^$`)
+var _regex_OrderPart3_BeneficiaryCID = regexp.MustCompile(`^Q[[:alnum:]]{45}$|^$`)
 
 func (this *OrderPart3) Validate() error {
 	if !_regex_OrderPart3_PreviousOrderCID.MatchString(this.PreviousOrderCID) {
@@ -145,6 +200,9 @@ func (this *OrderPart3) Validate() error {
 	if !(this.Timestamp < 32521429541) {
 		return github_com_mwitkow_go_proto_validators.FieldError("Timestamp", fmt.Errorf(`value '%v' must be less than '32521429541'`, this.Timestamp))
 	}
+	if !_regex_OrderPart3_BeneficiaryCID.MatchString(this.BeneficiaryCID) {
+		return github_com_mwitkow_go_proto_validators.FieldError("BeneficiaryCID", fmt.Errorf(`value '%v' must be a string conforming to regex "^Q[[:alnum:]]{45}$|^$"`, this.BeneficiaryCID))
+	}
 	return nil
 }
 
@@ -160,9 +218,68 @@ func (this *OrderPart4) Validate() error {
 	if !(this.Timestamp < 32521429541) {
 		return github_com_mwitkow_go_proto_validators.FieldError("Timestamp", fmt.Errorf(`value '%v' must be less than '32521429541'`, this.Timestamp))
 	}
+	if this.Share != nil {
+		if err := github_com_mwitkow_go_proto_validators.CallValidatorIfExists(this.Share); err != nil {
+			return github_com_mwitkow_go_proto_validators.FieldError("Share", err)
+		}
+	}
+	return nil
+}
+
+var _regex_OrderCancel_PreviousOrderCID = regexp.MustCompile(`^Q[[:alnum:]]{45}$|^$`)
+
+func (this *OrderCancel) Validate() error {
+	if !_regex_OrderCancel_PreviousOrderCID.MatchString(this.PreviousOrderCID) {
+		return github_com_mwitkow_go_proto_validators.FieldError("PreviousOrderCID", fmt.Errorf(`value '%v' must be a string conforming to regex "^Q[[:alnum:]]{45}$|^$"`, this.PreviousOrderCID))
+	}
+	if !(this.Timestamp > 1564050341) {
+		return github_com_mwitkow_go_proto_validators.FieldError("Timestamp", fmt.Errorf(`value '%v' must be greater than '1564050341'`, this.Timestamp))
+	}
+	if !(this.Timestamp < 32521429541) {
+		return github_com_mwitkow_go_proto_validators.FieldError("Timestamp", fmt.Errorf(`value '%v' must be less than '32521429541'`, this.Timestamp))
+	}
+	return nil
+}
+
+var _regex_OrderTombstone_PreviousOrderCID = regexp.MustCompile(`^Q[[:alnum:]]{45}$|^$`)
+
+func (this *OrderTombstone) Validate() error {
+	if !_regex_OrderTombstone_PreviousOrderCID.MatchString(this.PreviousOrderCID) {
+		return github_com_mwitkow_go_proto_validators.FieldError("PreviousOrderCID", fmt.Errorf(`value '%v' must be a string conforming to regex "^Q[[:alnum:]]{45}$|^$"`, this.PreviousOrderCID))
+	}
+	if !(this.Timestamp > 1564050341) {
+		return github_com_mwitkow_go_proto_validators.FieldError("Timestamp", fmt.Errorf(`value '%v' must be greater than '1564050341'`, this.Timestamp))
+	}
+	if !(this.Timestamp < 32521429541) {
+		return github_com_mwitkow_go_proto_validators.FieldError("Timestamp", fmt.Errorf(`value '%v' must be less than '32521429541'`, this.Timestamp))
+	}
 	return nil
 }
 func (this *Policy) Validate() error {
+	if len(this.AllowedBeneficiaryCIDs) > 100 {
+		return github_com_mwitkow_go_proto_validators.FieldError("AllowedBeneficiaryCIDs", fmt.Errorf(`value '%v' must contain at most 100 elements`, this.AllowedBeneficiaryCIDs))
+	}
+	if !(this.MinRedemptionDelay > -1) {
+		return github_com_mwitkow_go_proto_validators.FieldError("MinRedemptionDelay", fmt.Errorf(`value '%v' must be greater than '-1'`, this.MinRedemptionDelay))
+	}
+	if len(this.RequiredApproverCIDs) > 20 {
+		return github_com_mwitkow_go_proto_validators.FieldError("RequiredApproverCIDs", fmt.Errorf(`value '%v' must contain at most 20 elements`, this.RequiredApproverCIDs))
+	}
+	if !(this.MaxRedemptions > -1) {
+		return github_com_mwitkow_go_proto_validators.FieldError("MaxRedemptions", fmt.Errorf(`value '%v' must be greater than '-1'`, this.MaxRedemptions))
+	}
+	if !(this.Timestamp > 1564050341) {
+		return github_com_mwitkow_go_proto_validators.FieldError("Timestamp", fmt.Errorf(`value '%v' must be greater than '1564050341'`, this.Timestamp))
+	}
+	if !(this.Timestamp < 32521429541) {
+		return github_com_mwitkow_go_proto_validators.FieldError("Timestamp", fmt.Errorf(`value '%v' must be less than '32521429541'`, this.Timestamp))
+	}
+	if !(this.ApprovalQuorum > -1) {
+		return github_com_mwitkow_go_proto_validators.FieldError("ApprovalQuorum", fmt.Errorf(`value '%v' must be greater than '-1'`, this.ApprovalQuorum))
+	}
+	if !(this.ApprovalQuorum < 21) {
+		return github_com_mwitkow_go_proto_validators.FieldError("ApprovalQuorum", fmt.Errorf(`value '%v' must be less than '21'`, this.ApprovalQuorum))
+	}
 	return nil
 }
 func (this *PlainTestMessage1) Validate() error {
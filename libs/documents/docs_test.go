@@ -83,6 +83,21 @@ func Test_EncodeDecodeOrderDoc(t *testing.T) {
 	assert.NotNil(t, reconstitutedOrder.OrderPart4.Secret, "Reconstituted Fields dont match")
 }
 
+func Test_DecodeSignerCID(t *testing.T) {
+	s1, id1, _, _, _, blsSK := BuildTestIDDoc()
+	order, _ := BuildTestOrderDoc()
+	recipients := map[string]*IDDoc{
+		id1: s1,
+	}
+	raw, _ := EncodeOrderDocument(id1, order, blsSK, recipients)
+	signerCID, err := DecodeSignerCID(raw)
+	assert.Nil(t, err, "Error should be nil")
+	assert.Equal(t, id1, signerCID, "Signer doesn't match")
+
+	_, err = DecodeSignerCID([]byte("invalid"))
+	assert.NotNil(t, err, "Error should not be nil")
+}
+
 func Test_EncodeDecodeID(t *testing.T) {
 	iddoc, tag, _, _, _, blsSK := BuildTestIDDoc()
 	raw, _ := EncodeIDDocument(iddoc, blsSK)
@@ -99,6 +114,30 @@ func Test_EncodeDecodeID(t *testing.T) {
 	assert.NotNil(t, reconstitutedIDDoc.DateTime, "Reconstituted Fields dont match")
 }
 
+func Test_EncodeDecodePolicy(t *testing.T) {
+	_, id1, _, _, blsPK, blsSK := BuildTestIDDoc()
+	policy := NewPolicyDoc()
+	policy.Name = "Test Policy"
+	policy.AllowedBeneficiaryCIDs = []string{id1}
+	policy.MinRedemptionDelay = 3600
+	policy.MaxRedemptions = 1
+	policy.RequiredApproverCIDs = []string{id1}
+	policy.ApprovalQuorum = 1
+	policy.Timestamp = time.Now().Unix()
+	raw, err := EncodePolicyDocument(id1, policy, blsSK)
+	assert.Nil(t, err, "Error should be nil")
+
+	reconstitutedPolicy := NewPolicyDoc()
+	err = DecodePolicyDocument(raw, "POLICY", reconstitutedPolicy, blsPK)
+	assert.Nil(t, err, "Error should be nil")
+	assert.Equal(t, policy.Name, reconstitutedPolicy.Name, "Reconstituted Fields dont match")
+	assert.Equal(t, policy.AllowedBeneficiaryCIDs, reconstitutedPolicy.AllowedBeneficiaryCIDs, "Reconstituted Fields dont match")
+	assert.Equal(t, policy.MinRedemptionDelay, reconstitutedPolicy.MinRedemptionDelay, "Reconstituted Fields dont match")
+	assert.Equal(t, policy.MaxRedemptions, reconstitutedPolicy.MaxRedemptions, "Reconstituted Fields dont match")
+	assert.Equal(t, policy.RequiredApproverCIDs, reconstitutedPolicy.RequiredApproverCIDs, "Reconstituted Fields dont match")
+	assert.Equal(t, policy.ApprovalQuorum, reconstitutedPolicy.ApprovalQuorum, "Reconstituted Fields dont match")
+}
+
 func Test_AESPadding(t *testing.T) {
 	for i := 0; i < 1000; i++ {
 		randCount := mrand.Intn(100)
@@ -143,6 +182,63 @@ func Test_EncodeDecode(t *testing.T) {
 	assert.Equal(t, reconHeader.IPFSID, tag, "tag not loaded into header")
 }
 
+func Test_EncodeUniqueEnvelope(t *testing.T) {
+	s1, id1, _, sikeSK1, _, _ := BuildTestIDDoc()
+	recipients := map[string]*IDDoc{
+		id1: s1,
+	}
+	seed, _ := cryptowallet.RandomBytes(16)
+	_, blsPK, blsSK := crypto.BLSKeys(seed, nil)
+
+	//The same document is encoded in two different envelopes
+	headers := []*Header{}
+	rawDocs := [][]byte{}
+	for i := 0; i < 2; i++ {
+		rawDoc, err := Encode(id1, &SimpleString{Content: "A"}, &SimpleString{Content: "B"}, &Header{}, blsSK, recipients)
+		assert.Nil(t, err, "Failed to Encode")
+		header, err := Decode(rawDoc, "", sikeSK1, id1, &SimpleString{}, &SimpleString{}, blsPK)
+		assert.Nil(t, err, "Failed to Decode")
+		headers = append(headers, header)
+		rawDocs = append(rawDocs, rawDoc)
+	}
+
+	assert.NotEqual(t, rawDocs[0], rawDocs[1], "Envelopes are equal")
+	assert.NotEqual(t, headers[0].Nonce, headers[1].Nonce, "Nonces are equal")
+	for _, header := range headers {
+		assert.Len(t, header.Nonce, EnvelopeNonceLength, "Invalid nonce")
+		assert.InDelta(t, time.Now().Unix(), header.DateTime, 5, "Invalid envelope time")
+	}
+}
+
+func Test_SmartDecodeEnvelope(t *testing.T) {
+	s1, id1, _, sikeSK1, _, _ := BuildTestIDDoc()
+	recipients := map[string]*IDDoc{
+		id1: s1,
+	}
+	seed, _ := cryptowallet.RandomBytes(16)
+	_, blsPK, blsSK := crypto.BLSKeys(seed, nil)
+
+	rawDoc, err := Encode(id1, &SimpleString{Content: "A"}, &SimpleString{Content: "B"}, &Header{}, blsSK, recipients)
+	assert.Nil(t, err, "Failed to Encode")
+
+	se, err := SmartDecodeEnvelope(rawDoc, "tag")
+	assert.Nil(t, err, "Failed to SmartDecodeEnvelope")
+	assert.Equal(t, id1, se.SignerCID, "Invalid signer")
+	assert.Equal(t, "tag", se.Header.IPFSID, "Invalid tag")
+	assert.Equal(t, "Simple", se.BodyType.Name, "Invalid body type")
+	assert.Equal(t, "Simple", se.EncryptedBodyType.Name, "Invalid encrypted body type")
+	assert.Equal(t, "A", se.Body.(*SimpleString).Content, "Invalid body")
+	assert.Nil(t, se.EncryptedBody, "Encrypted body decrypted")
+
+	assert.Nil(t, se.Verify(blsPK), "Failed to verify")
+
+	assert.Nil(t, se.Decrypt(sikeSK1, id1), "Failed to Decrypt")
+	assert.Equal(t, "B", se.EncryptedBody.(*SimpleString).Content, "Invalid encrypted body")
+
+	_, err = SmartDecodeEnvelope([]byte("invalid"), "")
+	assert.NotNil(t, err, "Invalid envelope decoded")
+}
+
 func BuildTestOrderDoc() (OrderDoc, error) {
 	reference, err := uuid.NewUUID()
 	if err != nil {
@@ -18,19 +18,23 @@
 package keystore
 
 import (
+	"bytes"
 	"encoding/json"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sync"
 
 	"github.com/pkg/errors"
 )
 
 // FileStore is the key Store implementation storing the keys in a file
+// The file is encrypted with a passphrase if one is set
 type FileStore struct {
 	sync.RWMutex
-	filePath string
-	keys     map[string][]byte
+	filePath   string
+	passphrase []byte
+	keys       map[string][]byte
 }
 
 // NewFileStore creates a new FileStore
@@ -47,6 +51,78 @@ func NewFileStore(filePath string) (Store, error) {
 	return fs, nil
 }
 
+// NewEncryptedFileStore creates a new FileStore encrypted with the passphrase
+// The keys are encrypted with a key derived from the passphrase with scrypt
+func NewEncryptedFileStore(filePath string, passphrase []byte) (Store, error) {
+	if len(passphrase) == 0 {
+		return nil, ErrInvalidPassphrase
+	}
+
+	fs := &FileStore{
+		filePath:   filePath,
+		passphrase: passphrase,
+		keys:       map[string][]byte{},
+	}
+
+	if err := fs.loadKeys(); err != nil {
+		return nil, err
+	}
+
+	return fs, nil
+}
+
+// IsEncryptedFile checks if the key file is encrypted with a passphrase
+// A missing file isn't encrypted
+func IsEncryptedFile(filePath string) (bool, error) {
+	rawKeys, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "Load keys")
+	}
+	return isEncrypted(rawKeys), nil
+}
+
+// EncryptFile encrypts a plain key file with the passphrase
+func EncryptFile(filePath string, passphrase []byte) error {
+	if len(passphrase) == 0 {
+		return ErrInvalidPassphrase
+	}
+
+	encrypted, err := IsEncryptedFile(filePath)
+	if err != nil {
+		return err
+	}
+	if encrypted {
+		return ErrEncrypted
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		return errors.Wrap(err, "Load keys")
+	}
+
+	fs := &FileStore{filePath: filePath, keys: map[string][]byte{}}
+	if err := fs.loadKeys(); err != nil {
+		return err
+	}
+	fs.passphrase = passphrase
+	return fs.storeKeys()
+}
+
+// ChangePassphrase encrypts the key file with the new passphrase
+func ChangePassphrase(filePath string, passphrase, newPassphrase []byte) error {
+	if len(newPassphrase) == 0 {
+		return ErrInvalidPassphrase
+	}
+
+	fs := &FileStore{filePath: filePath, passphrase: passphrase, keys: map[string][]byte{}}
+	if err := fs.loadKeys(); err != nil {
+		return err
+	}
+	fs.passphrase = newPassphrase
+	return fs.storeKeys()
+}
+
 // Set stores multiple keys at once
 func (f *FileStore) Set(name string, key []byte) error {
 	f.Lock()
@@ -82,6 +158,18 @@ func (f *FileStore) loadKeys() error {
 		return errors.Wrap(err, "Load keys")
 	}
 
+	switch {
+	case f.passphrase != nil:
+		if !isEncrypted(rawKeys) {
+			return ErrNotEncrypted
+		}
+		if rawKeys, err = OpenWithPassphrase(f.passphrase, rawKeys); err != nil {
+			return err
+		}
+	case isEncrypted(rawKeys):
+		return ErrEncrypted
+	}
+
 	return json.Unmarshal(rawKeys, &(f).keys)
 }
 
@@ -90,6 +178,11 @@ func (f *FileStore) storeKeys() error {
 	if err != nil {
 		return err
 	}
+	if f.passphrase != nil {
+		if rawKeys, err = SealWithPassphrase(f.passphrase, rawKeys); err != nil {
+			return errors.Wrap(err, "Encrypt keys")
+		}
+	}
 
 	// Get the file permissions
 	var perm os.FileMode
@@ -103,9 +196,31 @@ func (f *FileStore) storeKeys() error {
 		perm = fi.Mode().Perm()
 	}
 
-	if err := ioutil.WriteFile(f.filePath, rawKeys, perm); err != nil {
+	// The keys are replaced at once so a failed write doesn't lose them
+	tmpFile, err := ioutil.TempFile(filepath.Dir(f.filePath), filepath.Base(f.filePath)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "Store keys")
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(rawKeys); err != nil {
+		tmpFile.Close()
+		return errors.Wrap(err, "Store keys")
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errors.Wrap(err, "Store keys")
+	}
+	if err := os.Chmod(tmpFile.Name(), perm); err != nil {
+		return errors.Wrap(err, "Store keys")
+	}
+	if err := os.Rename(tmpFile.Name(), f.filePath); err != nil {
 		return errors.Wrap(err, "Store keys")
 	}
 
 	return nil
 }
+
+// isEncrypted checks the key file format, the plain keys are a JSON object
+func isEncrypted(rawKeys []byte) bool {
+	rawKeys = bytes.TrimSpace(rawKeys)
+	return len(rawKeys) > 0 && rawKeys[0] != '{'
+}
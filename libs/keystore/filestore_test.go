@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"crypto/rand"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -76,6 +77,90 @@ func TestFileStore(t *testing.T) {
 	}
 }
 
+func TestEncryptedFileStore(t *testing.T) {
+	passphrase := []byte("passphrase")
+	newPassphrase := []byte("new passphrase")
+
+	fn := tmpFileName()
+	defer func() {
+		if err := os.Remove(fn); err != nil {
+			t.Logf("Warning! Temp file could not be deleted (%v): %v", err, fn)
+		}
+	}()
+
+	// Plain key file migrated to an encrypted one
+	fs, err := NewFileStore(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Set("seed", []byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if encrypted, err := IsEncryptedFile(fn); err != nil || encrypted {
+		t.Fatalf("plain key file detected as encrypted: %v", err)
+	}
+	if _, err := NewEncryptedFileStore(fn, passphrase); err != ErrNotEncrypted {
+		t.Fatalf("invalid error. Expected: %v, found: %v", ErrNotEncrypted, err)
+	}
+
+	if err := EncryptFile(fn, passphrase); err != nil {
+		t.Fatal(err)
+	}
+	if encrypted, err := IsEncryptedFile(fn); err != nil || !encrypted {
+		t.Fatalf("encrypted key file not detected: %v", err)
+	}
+	if err := EncryptFile(fn, passphrase); err != ErrEncrypted {
+		t.Fatalf("invalid error. Expected: %v, found: %v", ErrEncrypted, err)
+	}
+	if _, err := NewFileStore(fn); err != ErrEncrypted {
+		t.Fatalf("invalid error. Expected: %v, found: %v", ErrEncrypted, err)
+	}
+	if _, err := NewEncryptedFileStore(fn, newPassphrase); err != ErrInvalidPassphrase {
+		t.Fatalf("invalid error. Expected: %v, found: %v", ErrInvalidPassphrase, err)
+	}
+
+	efs, err := NewEncryptedFileStore(fn, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := efs.Set("key", []byte{4, 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Change the passphrase
+	if err := ChangePassphrase(fn, newPassphrase, passphrase); err != ErrInvalidPassphrase {
+		t.Fatalf("invalid error. Expected: %v, found: %v", ErrInvalidPassphrase, err)
+	}
+	if err := ChangePassphrase(fn, passphrase, newPassphrase); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewEncryptedFileStore(fn, passphrase); err != ErrInvalidPassphrase {
+		t.Fatalf("invalid error. Expected: %v, found: %v", ErrInvalidPassphrase, err)
+	}
+
+	efs1, err := NewEncryptedFileStore(fn, newPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, v := range map[string][]byte{"seed": {1, 2, 3}, "key": {4, 5}} {
+		key, err := efs1.Get(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(v, key) {
+			t.Errorf("Key not match: %v. Expected: %v, Found: %v", name, v, key)
+		}
+	}
+
+	rawKeys, err := ioutil.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(rawKeys, []byte("seed")) {
+		t.Fatal("encrypted key file contains the key names")
+	}
+}
+
 func tmpFileName() string {
 	rnd := make([]byte, 8)
 	rand.Read(rnd)
@@ -25,6 +25,12 @@ import "github.com/pkg/errors"
 var (
 	// ErrKeyNotFound is returned when a key is not found in the store
 	ErrKeyNotFound = errors.New("Key not found")
+	// ErrEncrypted is returned when a plain key store is opened on an encrypted file
+	ErrEncrypted = errors.New("Keys encrypted with a passphrase")
+	// ErrNotEncrypted is returned when an encrypted key store is opened on a plain file
+	ErrNotEncrypted = errors.New("Keys not encrypted")
+	// ErrInvalidPassphrase is returned when the data can't be decrypted with the passphrase
+	ErrInvalidPassphrase = errors.New("invalid passphrase")
 )
 
 // Store is the keystore interface
@@ -0,0 +1,100 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	sealedVersion  = 1
+	sealedSaltSize = 16
+	// scrypt cost parameters recommended for interactive use
+	scryptLogN = 15
+	scryptR    = 8
+	scryptP    = 1
+	// scryptMaxLogN bounds the memory used to open data from untrusted sources (128*r*2^N bytes)
+	scryptMaxLogN = scryptLogN + 2
+)
+
+// SealWithPassphrase encrypts data with AES-256-GCM and a key derived from the passphrase with scrypt
+// The output is the version, the scrypt cost, the salt, the nonce and the ciphertext
+func SealWithPassphrase(passphrase, data []byte) ([]byte, error) {
+	salt := make([]byte, sealedSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	aead, err := passphraseCipher(passphrase, salt, scryptLogN)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	header := append([]byte{sealedVersion, scryptLogN}, salt...)
+	header = append(header, nonce...)
+	// The header is authenticated with the ciphertext
+	return append(header, aead.Seal(nil, nonce, data, header)...), nil
+}
+
+// OpenWithPassphrase decrypts data encrypted by SealWithPassphrase
+func OpenWithPassphrase(passphrase, sealed []byte) ([]byte, error) {
+	if len(sealed) < 2+sealedSaltSize || sealed[0] != sealedVersion {
+		return nil, errors.New("invalid encrypted data")
+	}
+	logN := sealed[1]
+	if logN == 0 || logN > scryptMaxLogN {
+		return nil, errors.Errorf("invalid scrypt cost %d", logN)
+	}
+	salt := sealed[2 : 2+sealedSaltSize]
+	aead, err := passphraseCipher(passphrase, salt, logN)
+	if err != nil {
+		return nil, err
+	}
+
+	headerSize := 2 + sealedSaltSize + aead.NonceSize()
+	if len(sealed) < headerSize+aead.Overhead() {
+		return nil, errors.New("invalid encrypted data")
+	}
+	header := sealed[:headerSize]
+	data, err := aead.Open(nil, sealed[2+sealedSaltSize:headerSize], sealed[headerSize:], header)
+	if err != nil {
+		return nil, ErrInvalidPassphrase
+	}
+	return data, nil
+}
+
+func passphraseCipher(passphrase, salt []byte, logN byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, 1<<logN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, errors.Wrap(err, "derive key")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
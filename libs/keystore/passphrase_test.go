@@ -0,0 +1,66 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package keystore
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSealWithPassphrase(t *testing.T) {
+	data := []byte("node seed")
+	passphrase := []byte("correct horse battery staple")
+
+	sealed, err := SealWithPassphrase(passphrase, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, data) {
+		t.Fatal("sealed data contains the plaintext")
+	}
+
+	opened, err := OpenWithPassphrase(passphrase, sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(opened, data) {
+		t.Fatalf("invalid data. Expected: %s, found: %s", data, opened)
+	}
+
+	if _, err := OpenWithPassphrase([]byte("wrong"), sealed); err != ErrInvalidPassphrase {
+		t.Fatalf("invalid error. Expected: %v, found: %v", ErrInvalidPassphrase, err)
+	}
+
+	// Tampered header
+	tampered := append([]byte{}, sealed...)
+	tampered[2] ^= 1
+	if _, err := OpenWithPassphrase(passphrase, tampered); err != ErrInvalidPassphrase {
+		t.Fatalf("invalid error. Expected: %v, found: %v", ErrInvalidPassphrase, err)
+	}
+
+	// Excessive scrypt cost
+	expensive := append([]byte{}, sealed...)
+	expensive[1] = 30
+	if _, err := OpenWithPassphrase(passphrase, expensive); err == nil || err == ErrInvalidPassphrase {
+		t.Fatalf("excessive scrypt cost accepted: %v", err)
+	}
+
+	if _, err := OpenWithPassphrase(passphrase, sealed[:10]); err == nil {
+		t.Fatal("truncated data opened")
+	}
+}
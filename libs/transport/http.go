@@ -55,6 +55,7 @@ const (
 	contextAuthorizeError    contextKey = 10006
 	contextUserInfo          contextKey = 10007
 	contextReqID             contextKey = 10008
+	contextRequestSigner     contextKey = 10009
 )
 
 var (
@@ -83,6 +84,8 @@ type HTTPEndpoint struct {
 	NewResponse func() interface{}
 	ErrStatus   ErrorStatus
 	Options     []httptransport.ServerOption
+	// Stream endpoints return a StreamFunc sent as Server-Sent Events
+	Stream bool
 }
 
 // ClientEndpoints is a map of all exported client endpoints
@@ -169,11 +172,16 @@ func NewHTTPHandler(endpoints HTTPEndpoints, logger *logger.Logger, duration met
 			),
 		)
 
+		encodeResponse := encodeJSONResponse
+		if e.Stream {
+			encodeResponse = encodeEventStream(logger)
+		}
+
 		m.Path(e.Path).Methods(e.Method).Handler(
 			httptransport.NewServer(
 				endpoint,
 				decodeJSONRequest(e),
-				encodeJSONResponse,
+				encodeResponse,
 				options...,
 			),
 		)
@@ -488,18 +496,21 @@ func encodeJSONRequest(ctx context.Context, r *http.Request, request interface{}
 	}
 
 	if request == nil {
-		return nil
+		return signRequest(ctx, r, nil)
 	}
 
 	if urlV, ok := request.(url.Values); ok {
 		r.URL.RawQuery = urlV.Encode()
-		return nil
+		return signRequest(ctx, r, nil)
 	}
 
 	var buf bytes.Buffer
 	if err := json.NewEncoder(&buf).Encode(request); err != nil {
 		return err
 	}
+	if err := signRequest(ctx, r, buf.Bytes()); err != nil {
+		return errors.Wrap(err, "sign request")
+	}
 	r.Body = ioutil.NopCloser(&buf)
 	return nil
 }
@@ -523,7 +534,7 @@ func decodeJSONResponse(e HTTPEndpoint) httptransport.DecodeResponseFunc {
 }
 
 // NewHTTPClient returns an HTTP handler that makes a set of endpoints
-func NewHTTPClient(instance string, endpoints HTTPEndpoints, logger *logger.Logger) (ClientEndpoints, error) {
+func NewHTTPClient(instance string, endpoints HTTPEndpoints, logger *logger.Logger, options ...httptransport.ClientOption) (ClientEndpoints, error) {
 	if !strings.HasPrefix(instance, "http") {
 		instance = "http://" + instance
 	}
@@ -540,6 +551,7 @@ func NewHTTPClient(instance string, endpoints HTTPEndpoints, logger *logger.Logg
 			copyURL(u, e.Path),
 			encodeJSONRequest,
 			decodeJSONResponse(e),
+			options...,
 		).Endpoint()
 	}
 
@@ -0,0 +1,99 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transport
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/pkg/errors"
+)
+
+// maxSignedRequestSize is the largest request body read for the signature verification
+const maxSignedRequestSize = 32 << 20
+
+// RequestSigner interface for signing the HTTP client requests
+type RequestSigner interface {
+	// SignRequest adds the signature of the request to the header
+	SignRequest(method, path string, header http.Header, body []byte) error
+}
+
+// RequestAuthorizer interface for authorizing the signed requests
+type RequestAuthorizer interface {
+	// AuthorizeRequest verifies the signature of the request and returns the claims of the signer
+	// The body of the request is already read
+	AuthorizeRequest(r *http.Request, body []byte) (*UserClaims, error)
+}
+
+// SetRequestSigner sets the signer of the requests to the http client context
+func SetRequestSigner(ctx context.Context, signer RequestSigner) context.Context {
+	return context.WithValue(ctx, contextRequestSigner, signer)
+}
+
+// signRequest signs the request with the signer from the context if set
+func signRequest(ctx context.Context, r *http.Request, body []byte) error {
+	signer, ok := ctx.Value(contextRequestSigner).(RequestSigner)
+	if !ok || signer == nil {
+		return nil
+	}
+	return signer.SignRequest(r.Method, r.URL.Path, r.Header, body)
+}
+
+// AuthorizeSignedRequest verifies the request signature with the authorizer
+// The endpoint is protected and the request body is kept for the decoder
+func AuthorizeSignedRequest(authorizer RequestAuthorizer) httptransport.ServerOption {
+	return func(s *httptransport.Server) {
+		httptransport.ServerBefore(
+			func(ctx context.Context, r *http.Request) context.Context {
+				ctx = context.WithValue(ctx, contextProtectedEndpoint, true)
+
+				userInfo, err := authorizeSignedRequest(authorizer, r)
+				if err != nil {
+					ctx = context.WithValue(ctx, contextAuthorized, false)
+					ctx = context.WithValue(ctx, contextAuthorizeError, err.Error())
+					return ctx
+				}
+				ctx = context.WithValue(ctx, contextAuthorized, true)
+				ctx = context.WithValue(ctx, contextUserInfo, userInfo)
+				return ctx
+			},
+		)(s)
+	}
+}
+
+func authorizeSignedRequest(authorizer RequestAuthorizer, r *http.Request) (*UserClaims, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(io.LimitReader(r.Body, maxSignedRequestSize+1))
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return nil, errors.Wrap(err, "read request")
+		}
+		if len(body) > maxSignedRequestSize {
+			return nil, errors.New("request too large")
+		}
+	}
+
+	return authorizer.AuthorizeRequest(r, body)
+}
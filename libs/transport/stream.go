@@ -0,0 +1,144 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/logger"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/pkg/errors"
+)
+
+// StreamKeepAlive is the interval of the comments sent to keep an idle stream open
+var StreamKeepAlive = 15 * time.Second
+
+// Event is a single Server-Sent Event
+type Event struct {
+	// ID is sent back by the client in the Last-Event-ID header when it reconnects
+	ID    string
+	Event string
+	// Data is encoded as JSON
+	Data interface{}
+}
+
+// StreamFunc sends the events of a stream until the context is done
+// The endpoint of a Stream HTTPEndpoint returns a StreamFunc
+type StreamFunc func(ctx context.Context, send func(e *Event) error) error
+
+// LastEventID returns the ID of the last event received by a reconnecting client
+// The Last-Event-ID header is sent by browsers, the cursor query param by the other clients
+func LastEventID(ctx context.Context) string {
+	if id := GetHeaders(ctx).Get("Last-Event-ID"); id != "" {
+		return id
+	}
+	return GetParams(ctx).Get("cursor")
+}
+
+// encodeEventStream writes the events of the StreamFunc response as text/event-stream
+// The response headers are already sent when the stream starts so the
+// stream errors are only logged
+func encodeEventStream(logger *logger.Logger) httptransport.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		stream, ok := response.(StreamFunc)
+		if !ok {
+			return errors.New("invalid stream response")
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			return errors.New("streaming not supported")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		var mutex sync.Mutex
+		write := func(b []byte) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if _, err := w.Write(b); err != nil {
+				return err
+			}
+			flusher.Flush()
+			return nil
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		go func() {
+			ticker := time.NewTicker(StreamKeepAlive)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := write([]byte(": keep-alive\n\n")); err != nil {
+						cancel()
+						return
+					}
+				}
+			}
+		}()
+
+		err := stream(ctx, func(e *Event) error {
+			b, err := encodeEvent(e)
+			if err != nil {
+				return err
+			}
+			if err := write(b); err != nil {
+				cancel()
+				return err
+			}
+			return nil
+		})
+		if err != nil && ctx.Err() == nil {
+			reqID := getContextStr(ctx, contextReqID)
+			logger.Error("reqID: %v, stream: %v", reqID, err.Error())
+		}
+		return nil
+	}
+}
+
+func encodeEvent(e *Event) ([]byte, error) {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if e.ID != "" {
+		fmt.Fprintf(&buf, "id: %s\n", e.ID)
+	}
+	if e.Event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", e.Event)
+	}
+	fmt.Fprintf(&buf, "data: %s\n\n", data)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,79 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/pkg/errors"
+)
+
+// LoadTLSConfig creates a TLS config with the certificate of certFile and keyFile
+// The CAs of caFiles are trusted to verify the server and the client certificates
+// If no CA is set the system CAs verify the servers
+func LoadTLSConfig(certFile, keyFile string, caFiles []string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "load certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(caFiles) > 0 {
+		pool := x509.NewCertPool()
+		for _, caFile := range caFiles {
+			pem, err := ioutil.ReadFile(caFile)
+			if err != nil {
+				return nil, errors.Wrapf(err, "load CA %s", caFile)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, errors.Errorf("load CA %s: no certificates found", caFile)
+			}
+		}
+		tlsConfig.RootCAs = pool
+		tlsConfig.ClientCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// ClientTLS sets the TLS config of the http client
+// The client certificate of the config is sent to the servers asking for it
+func ClientTLS(tlsConfig *tls.Config) httptransport.ClientOption {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tlsConfig
+	return httptransport.SetClient(&http.Client{Transport: t})
+}
+
+// PeerCertificate returns the verified certificate of the client
+// or nil if the request has no client certificate
+func PeerCertificate(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
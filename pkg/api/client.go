@@ -26,6 +26,7 @@ import (
 
 	"github.com/apache/incubator-milagro-dta/libs/logger"
 	"github.com/apache/incubator-milagro-dta/libs/transport"
+	httptransport "github.com/go-kit/kit/transport/http"
 )
 
 var (
@@ -35,13 +36,17 @@ var (
 //ClientService - enables service to be mocked
 type ClientService interface {
 	FulfillOrder(req *FulfillOrderRequest) (*FulfillOrderResponse, error)
+	FulfillOrderBatch(req *FulfillOrderBatchRequest) (*FulfillOrderBatchResponse, error)
 	FulfillOrderSecret(req *FulfillOrderSecretRequest) (*FulfillOrderSecretResponse, error)
+	FulfillOrderCancel(req *FulfillOrderCancelRequest) (*FulfillOrderCancelResponse, error)
+	FulfillOrderApprove(req *FulfillOrderApproveRequest) (*FulfillOrderApproveResponse, error)
 	Status(token string) (*StatusResponse, error)
 }
 
 // MilagroClientService - implements Service Interface
 type MilagroClientService struct {
 	endpoints transport.ClientEndpoints
+	signer    transport.RequestSigner
 }
 
 // ClientEndpoints return only the exported endpoints
@@ -53,12 +58,30 @@ func ClientEndpoints() transport.HTTPEndpoints {
 			NewRequest:  func() interface{} { return &FulfillOrderRequest{} },
 			NewResponse: func() interface{} { return &FulfillOrderResponse{} },
 		},
+		"FulfillOrderBatch": {
+			Path:        "/" + apiVersion + "/fulfill/order/batch",
+			Method:      http.MethodPost,
+			NewRequest:  func() interface{} { return &FulfillOrderBatchRequest{} },
+			NewResponse: func() interface{} { return &FulfillOrderBatchResponse{} },
+		},
 		"FulfillOrderSecret": {
 			Path:        "/" + apiVersion + "/fulfill/order/secret",
 			Method:      http.MethodPost,
 			NewRequest:  func() interface{} { return &FulfillOrderSecretRequest{} },
 			NewResponse: func() interface{} { return &FulfillOrderSecretResponse{} },
 		},
+		"FulfillOrderCancel": {
+			Path:        "/" + apiVersion + "/fulfill/order/cancel",
+			Method:      http.MethodPost,
+			NewRequest:  func() interface{} { return &FulfillOrderCancelRequest{} },
+			NewResponse: func() interface{} { return &FulfillOrderCancelResponse{} },
+		},
+		"FulfillOrderApprove": {
+			Path:        "/" + apiVersion + "/fulfill/order/approve",
+			Method:      http.MethodPost,
+			NewRequest:  func() interface{} { return &FulfillOrderApproveRequest{} },
+			NewResponse: func() interface{} { return &FulfillOrderApproveResponse{} },
+		},
 		"Status": {
 			Path:        "/" + apiVersion + "/status",
 			Method:      http.MethodGet,
@@ -70,14 +93,30 @@ func ClientEndpoints() transport.HTTPEndpoints {
 // NewHTTPClient returns Service backed by an HTTP server living at the remote instance
 func NewHTTPClient(instance string, logger *logger.Logger) (ClientService, error) {
 	clientEndpoints, err := transport.NewHTTPClient(instance, ClientEndpoints(), logger)
-	return MilagroClientService{clientEndpoints}, err
+	return MilagroClientService{endpoints: clientEndpoints}, err
+
+}
 
+// NewSignedHTTPClient returns Service backed by an HTTP server living at the remote instance
+// The fulfill requests are signed by the signer
+func NewSignedHTTPClient(instance string, signer transport.RequestSigner, logger *logger.Logger, options ...httptransport.ClientOption) (ClientService, error) {
+	clientEndpoints, err := transport.NewHTTPClient(instance, ClientEndpoints(), logger, options...)
+	return MilagroClientService{endpoints: clientEndpoints, signer: signer}, err
+}
+
+// requestContext returns the context of the signed requests
+func (c MilagroClientService) requestContext() context.Context {
+	ctx := context.Background()
+	if c.signer != nil {
+		ctx = transport.SetRequestSigner(ctx, c.signer)
+	}
+	return ctx
 }
 
 //FulfillOrder -
 func (c MilagroClientService) FulfillOrder(req *FulfillOrderRequest) (*FulfillOrderResponse, error) {
 	endpoint := c.endpoints["FulfillOrder"]
-	ctx := context.Background()
+	ctx := c.requestContext()
 
 	d, err := endpoint(ctx, req)
 	if err != nil {
@@ -87,10 +126,23 @@ func (c MilagroClientService) FulfillOrder(req *FulfillOrderRequest) (*FulfillOr
 	return r, nil
 }
 
+//FulfillOrderBatch -
+func (c MilagroClientService) FulfillOrderBatch(req *FulfillOrderBatchRequest) (*FulfillOrderBatchResponse, error) {
+	endpoint := c.endpoints["FulfillOrderBatch"]
+	ctx := c.requestContext()
+
+	d, err := endpoint(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	r := d.(*FulfillOrderBatchResponse)
+	return r, nil
+}
+
 //FulfillOrderSecret -
 func (c MilagroClientService) FulfillOrderSecret(req *FulfillOrderSecretRequest) (*FulfillOrderSecretResponse, error) {
 	endpoint := c.endpoints["FulfillOrderSecret"]
-	ctx := context.Background()
+	ctx := c.requestContext()
 
 	d, err := endpoint(ctx, req)
 	if err != nil {
@@ -100,6 +152,32 @@ func (c MilagroClientService) FulfillOrderSecret(req *FulfillOrderSecretRequest)
 	return r, nil
 }
 
+//FulfillOrderCancel -
+func (c MilagroClientService) FulfillOrderCancel(req *FulfillOrderCancelRequest) (*FulfillOrderCancelResponse, error) {
+	endpoint := c.endpoints["FulfillOrderCancel"]
+	ctx := c.requestContext()
+
+	d, err := endpoint(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	r := d.(*FulfillOrderCancelResponse)
+	return r, nil
+}
+
+//FulfillOrderApprove -
+func (c MilagroClientService) FulfillOrderApprove(req *FulfillOrderApproveRequest) (*FulfillOrderApproveResponse, error) {
+	endpoint := c.endpoints["FulfillOrderApprove"]
+	ctx := c.requestContext()
+
+	d, err := endpoint(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	r := d.(*FulfillOrderApproveResponse)
+	return r, nil
+}
+
 //Status - Allows a client to see the status of the server that it is connecting too
 func (c MilagroClientService) Status(token string) (*StatusResponse, error) {
 	endpoint := c.endpoints["Status"]
@@ -25,6 +25,27 @@ package api
 
 import (
 	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/audit"
+)
+
+//Order states
+const (
+	OrderStateCreated             = "created"
+	OrderStatePart1Written        = "part1-written"
+	OrderStateFulfilled           = "fulfilled"
+	OrderStateRedemptionRequested = "redemption-requested"
+	OrderStateRedeemed            = "redeemed"
+	OrderStateFailed              = "failed"
+	OrderStateCancelled           = "cancelled"
+)
+
+//Webhook events
+const (
+	WebhookEventOrderCreated   = "order.created"
+	WebhookEventOrderFulfilled = "order.fulfilled"
+	WebhookEventOrderRedeemed  = "order.redeemed"
+	WebhookEventOrderFailed    = "order.failed"
 )
 
 //CreateIdentityRequest -
@@ -33,9 +54,10 @@ type CreateIdentityRequest struct {
 	Extension map[string]string `json:"extension,omitempty"`
 }
 
-//CreateIdentityResponse -
+//CreateIdentityResponse - the seed is returned once and never kept by the node
 type CreateIdentityResponse struct {
 	IDDocumentCID string            `json:"idDocumentCID,omitempty"`
+	Seed          string            `json:"seed,omitempty"`
 	Extension     map[string]string `json:"extension,omitempty"`
 }
 
@@ -60,23 +82,72 @@ type GetIdentityResponse struct {
 
 //IdentityListRequest -
 type IdentityListRequest struct {
-	Page      int               `json:"page,omitempty"`
-	PerPage   int               `json:"perPage,omitempty"`
-	SortBy    string            `json:"sortBy,omitempty"`
+	Page    int    `json:"page,omitempty" validate:"min=0"`
+	PerPage int    `json:"perPage,omitempty" validate:"min=0"`
+	SortBy  string `json:"sortBy,omitempty"`
+	// Cursor of the page returned by the previous list, the page is ignored
+	Cursor string `json:"cursor,omitempty"`
+	// Count requests the total number of identities
+	Count     bool              `json:"count,omitempty"`
 	Extension map[string]string `json:"extension,omitempty"`
 }
 
 //IdentityListResponse -
 type IdentityListResponse struct {
 	IDDocumentList []GetIdentityResponse `json:"idDocumentList,omitempty"`
-	Extension      map[string]string     `json:"extension,omitempty"`
+	// Cursor of the next page, empty on the last page
+	Cursor    string            `json:"cursor,omitempty"`
+	Total     int               `json:"total,omitempty"`
+	Extension map[string]string `json:"extension,omitempty"`
+}
+
+//CreatePolicyRequest -
+type CreatePolicyRequest struct {
+	Name                   string            `json:"name,omitempty" validate:"required"`
+	AllowedBeneficiaryCIDs []string          `json:"allowedBeneficiaryCIDs,omitempty" validate:"max=100,dive,IPFS"`
+	MinRedemptionDelay     int64             `json:"minRedemptionDelay,omitempty" validate:"min=0"`
+	RequiredApproverCIDs   []string          `json:"requiredApproverCIDs,omitempty" validate:"max=20,dive,IPFS"`
+	MaxRedemptions         int64             `json:"maxRedemptions,omitempty" validate:"min=0"`
+	ApprovalQuorum         int64             `json:"approvalQuorum,omitempty" validate:"min=0,max=20"`
+	Extension              map[string]string `json:"extension,omitempty"`
+}
+
+//CreatePolicyResponse -
+type CreatePolicyResponse struct {
+	PolicyCID string            `json:"policyCID,omitempty"`
+	Extension map[string]string `json:"extension,omitempty"`
+}
+
+//GetPolicyRequest -
+type GetPolicyRequest struct {
+	PolicyCID string `json:"policyCID" validate:"IPFS"`
+}
+
+//GetPolicyResponse -
+type GetPolicyResponse struct {
+	PolicyCID              string            `json:"policyCID,omitempty"`
+	SignerCID              string            `json:"signerCID,omitempty"`
+	Name                   string            `json:"name,omitempty"`
+	AllowedBeneficiaryCIDs []string          `json:"allowedBeneficiaryCIDs,omitempty"`
+	MinRedemptionDelay     int64             `json:"minRedemptionDelay,omitempty"`
+	RequiredApproverCIDs   []string          `json:"requiredApproverCIDs,omitempty"`
+	MaxRedemptions         int64             `json:"maxRedemptions,omitempty"`
+	ApprovalQuorum         int64             `json:"approvalQuorum,omitempty"`
+	Timestamp              int64             `json:"timestamp,omitempty"`
+	Extension              map[string]string `json:"extension,omitempty"`
 }
 
 //OrderRequest -
 type OrderRequest struct {
 	// BeneficiaryIDDocumentCID string            `json:"BeneficiaryIDDocumentCID,omitempty" validate:"omitempty,IPFS"`
 	BeneficiaryIDDocumentCID string            `json:"beneficiaryIDDocumentCID,omitempty"`
+	Async                    bool              `json:"async,omitempty"`
+	NotBefore                int64             `json:"notBefore,omitempty" validate:"omitempty,min=0"`
+	Expiry                   int64             `json:"expiry,omitempty" validate:"omitempty,gtfield=NotBefore"`
+	PolicyCID                string            `json:"policyCID,omitempty" validate:"omitempty,IPFS"`
 	Extension                map[string]string `json:"extension,omitempty"`
+	// Subject is the OIDC subject of the caller
+	Subject string `json:"-"`
 }
 
 //OrderResponse -
@@ -85,22 +156,76 @@ type OrderResponse struct {
 	// OrderPart2CID  string            `json:"orderPart2CID,omitempty" validate:"omitempty,IPFS"`
 	OrderReference string            `json:"orderReference,omitempty" validate:"omitempty"`
 	Commitment     string            `json:"commitment,omitempty"`
+	State          string            `json:"state,omitempty"`
 	CreatedAt      int64             `json:"createdAt,omitempty"`
 	Extension      map[string]string `json:"extension,omitempty"`
 }
 
+//BatchOrderRequest -
+type BatchOrderRequest struct {
+	Orders []*OrderRequest `json:"orders,omitempty" validate:"required,min=1,max=1000,dive,required"`
+}
+
+//BatchOrderResult - result of a single order of the batch
+type BatchOrderResult struct {
+	OrderReference string            `json:"orderReference,omitempty"`
+	Commitment     string            `json:"commitment,omitempty"`
+	State          string            `json:"state,omitempty"`
+	CreatedAt      int64             `json:"createdAt,omitempty"`
+	Extension      map[string]string `json:"extension,omitempty"`
+	Error          string            `json:"error,omitempty"`
+}
+
+//BatchOrderResponse - the results are in the order of the request
+type BatchOrderResponse struct {
+	Orders []BatchOrderResult `json:"orders"`
+	Failed int                `json:"failed"`
+}
+
 //OrderListRequest -
 type OrderListRequest struct {
-	Page      int               `json:"page,omitempty"`
-	PerPage   int               `json:"perPage,omitempty"`
-	SortBy    string            `json:"sortBy,omitempty"`
+	Page    int    `json:"page,omitempty" validate:"min=0"`
+	PerPage int    `json:"perPage,omitempty" validate:"min=0"`
+	SortBy  string `json:"sortBy,omitempty"`
+	// Filters
+	BeneficiaryCID string `json:"beneficiaryCID,omitempty" validate:"omitempty,IPFS"`
+	Type           string `json:"type,omitempty"`
+	Coin           string `json:"coin,omitempty" validate:"omitempty,numeric"`
+	State          string `json:"state,omitempty" validate:"omitempty,oneof=created part1-written fulfilled redemption-requested redeemed failed cancelled"`
+	// Role of the node in the order, principal or fiduciary
+	Role string `json:"role,omitempty" validate:"omitempty,oneof=principal fiduciary"`
+	// From and To are the range of the creation time, in Unix time
+	From int64 `json:"from,omitempty" validate:"min=0"`
+	To   int64 `json:"to,omitempty" validate:"omitempty,gtefield=From"`
+	// Cursor of the page returned by the previous list, the page is ignored
+	Cursor string `json:"cursor,omitempty"`
+	// Count requests the total number of orders
+	Count     bool              `json:"count,omitempty"`
 	Extension map[string]string `json:"extension,omitempty"`
 }
 
 //OrderListResponse -
 type OrderListResponse struct {
-	OrderReference []string          `json:"orderReference,omitempty"`
-	Extension      map[string]string `json:"extension,omitempty"`
+	OrderReference []string        `json:"orderReference,omitempty"`
+	Orders         []OrderListItem `json:"orders"`
+	// Cursor of the next page, empty on the last page
+	Cursor    string            `json:"cursor,omitempty"`
+	Total     int               `json:"total,omitempty"`
+	Extension map[string]string `json:"extension,omitempty"`
+}
+
+//OrderListItem - metadata of an order of the list
+type OrderListItem struct {
+	OrderReference string `json:"orderReference"`
+	// LatestCID is the last order document written by the node
+	LatestCID      string `json:"latestCID,omitempty"`
+	Role           string `json:"role"`
+	State          string `json:"state"`
+	Type           string `json:"type,omitempty"`
+	Coin           int64  `json:"coin"`
+	BeneficiaryCID string `json:"beneficiaryCID,omitempty"`
+	CreatedAt      int64  `json:"createdAt"`
+	UpdatedAt      int64  `json:"updatedAt"`
 }
 
 //GetOrderRequest -
@@ -111,12 +236,70 @@ type GetOrderRequest struct {
 
 //GetOrderResponse -
 type GetOrderResponse struct {
-	OrderCID  string            `json:"orderCID,omitempty"`
-	Order     string            `json:"order,omitempty"`
-	Timestamp int64             `json:"timestamp,omitempty"`
+	OrderCID   string `json:"orderCID,omitempty"`
+	Order      string `json:"order,omitempty"`
+	State      string `json:"state,omitempty"`
+	Commitment string `json:"commitment,omitempty"`
+	// OrderPart2CIDs maps the fiduciary CID to the order part 2 approved by the approvers
+	OrderPart2CIDs map[string]string `json:"orderPart2CIDs,omitempty"`
+	Timestamp      int64             `json:"timestamp,omitempty"`
+	Extension      map[string]string `json:"extension,omitempty"`
+}
+
+//ResumeOrderRequest -
+type ResumeOrderRequest struct {
+	OrderReference string            `json:"orderReference,omitempty" validate:"required"`
+	Extension      map[string]string `json:"extension,omitempty"`
+}
+
+//ResumeOrderResponse -
+type ResumeOrderResponse struct {
+	OrderReference string            `json:"orderReference,omitempty"`
+	State          string            `json:"state,omitempty"`
+	Commitment     string            `json:"commitment,omitempty"`
+	Secret         string            `json:"secret,omitempty"`
+	BeneficiaryCID string            `json:"beneficiaryCID,omitempty"`
+	OrderPart4CIDs []string          `json:"orderPart4CIDs,omitempty"`
+	Extension      map[string]string `json:"extension,omitempty"`
+}
+
+//VerifyOrderRequest -
+type VerifyOrderRequest struct {
+	OrderReference string            `json:"orderReference,omitempty" validate:"required"`
+	Extension      map[string]string `json:"extension,omitempty"`
+}
+
+//VerifyOrderResponse -
+type VerifyOrderResponse struct {
+	OrderReference string `json:"orderReference,omitempty"`
+	// Valid is true if none of the chains has a break
+	Valid     bool              `json:"valid"`
+	Chains    []OrderChain      `json:"chains"`
 	Extension map[string]string `json:"extension,omitempty"`
 }
 
+//OrderChain -
+type OrderChain struct {
+	FiduciaryCID string               `json:"fiduciaryCID,omitempty"`
+	Valid        bool                 `json:"valid"`
+	Documents    []OrderChainDocument `json:"documents"`
+	Breaks       []OrderChainBreak    `json:"breaks,omitempty"`
+}
+
+//OrderChainDocument -
+type OrderChainDocument struct {
+	CID       string `json:"cid,omitempty"`
+	Part      string `json:"part,omitempty"`
+	SignerCID string `json:"signerCID,omitempty"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+}
+
+//OrderChainBreak -
+type OrderChainBreak struct {
+	CID    string `json:"cid,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
 //OrderSecretRequest -
 type OrderSecretRequest struct {
 	OrderReference           string            `json:"orderReference,omitempty" validate:"omitempty"`
@@ -129,6 +312,22 @@ type OrderSecretResponse struct {
 	Secret         string            `json:"secret,omitempty"`
 	Commitment     string            `json:"commitment,omitempty"`
 	OrderReference string            `json:"orderReference,omitempty" validate:"omitempty"`
+	BeneficiaryCID string            `json:"beneficiaryCID,omitempty"`
+	OrderPart4CIDs []string          `json:"orderPart4CIDs,omitempty"`
+	Extension      map[string]string `json:"extension,omitempty"`
+}
+
+//RedeemOrderRequest -
+type RedeemOrderRequest struct {
+	OrderPart4CIDs []string          `json:"orderPart4CIDs,omitempty" validate:"required,dive,IPFS"`
+	Extension      map[string]string `json:"extension,omitempty"`
+}
+
+//RedeemOrderResponse -
+type RedeemOrderResponse struct {
+	OrderReference string            `json:"orderReference,omitempty"`
+	Secret         string            `json:"secret,omitempty"`
+	Commitment     string            `json:"commitment,omitempty"`
 	Extension      map[string]string `json:"extension,omitempty"`
 }
 
@@ -141,23 +340,119 @@ type FulfillOrderSecretRequest struct {
 
 //FulfillOrderSecretResponse -
 type FulfillOrderSecretResponse struct {
-	OrderPart4CID string            `json:"orderPart4CID,omitempty"`
-	Extension     map[string]string `json:"extension,omitempty"`
+	OrderReference string            `json:"orderReference,omitempty"`
+	OrderPart4CID  string            `json:"orderPart4CID,omitempty"`
+	Extension      map[string]string `json:"extension,omitempty"`
+}
+
+//CancelOrderRequest -
+type CancelOrderRequest struct {
+	OrderReference string            `json:"orderReference,omitempty" validate:"required"`
+	Reason         string            `json:"reason,omitempty"`
+	Extension      map[string]string `json:"extension,omitempty"`
+}
+
+//CancelOrderResponse -
+type CancelOrderResponse struct {
+	OrderReference     string            `json:"orderReference,omitempty"`
+	State              string            `json:"state,omitempty"`
+	OrderTombstoneCIDs []string          `json:"orderTombstoneCIDs,omitempty"`
+	Extension          map[string]string `json:"extension,omitempty"`
+}
+
+//FulfillOrderCancelRequest -
+type FulfillOrderCancelRequest struct {
+	OrderCancelCID    string            `json:"orderCancelCID,omitempty" validate:"IPFS"`
+	SenderDocumentCID string            `json:"documentCID,omitempty" validate:"IPFS"`
+	Extension         map[string]string `json:"extension,omitempty"`
+}
+
+//FulfillOrderCancelResponse -
+type FulfillOrderCancelResponse struct {
+	OrderReference    string            `json:"orderReference,omitempty"`
+	OrderTombstoneCID string            `json:"orderTombstoneCID,omitempty"`
+	Extension         map[string]string `json:"extension,omitempty"`
+}
+
+//ApproveOrderRequest -
+type ApproveOrderRequest struct {
+	OrderReference string            `json:"orderReference,omitempty" validate:"required"`
+	OrderPart2CID  string            `json:"orderPart2CID,omitempty" validate:"IPFS"`
+	FiduciaryCID   string            `json:"fiduciaryCID,omitempty" validate:"IPFS"`
+	Extension      map[string]string `json:"extension,omitempty"`
+}
+
+//ApproveOrderResponse -
+type ApproveOrderResponse struct {
+	OrderReference     string            `json:"orderReference,omitempty"`
+	OrderPart2CID      string            `json:"orderPart2CID,omitempty"`
+	Approvals          int               `json:"approvals"`
+	Quorum             int               `json:"quorum"`
+	AggregateSignature string            `json:"aggregateSignature,omitempty"`
+	Extension          map[string]string `json:"extension,omitempty"`
+}
+
+//FulfillOrderApproveRequest -
+type FulfillOrderApproveRequest struct {
+	OrderReference string            `json:"orderReference,omitempty" validate:"required"`
+	OrderPart2CID  string            `json:"orderPart2CID,omitempty" validate:"IPFS"`
+	ApproverCID    string            `json:"approverCID,omitempty" validate:"IPFS"`
+	Signature      string            `json:"signature,omitempty" validate:"required,hexadecimal"`
+	Extension      map[string]string `json:"extension,omitempty"`
+}
+
+//FulfillOrderApproveResponse -
+type FulfillOrderApproveResponse struct {
+	OrderReference     string            `json:"orderReference,omitempty"`
+	OrderPart2CID      string            `json:"orderPart2CID,omitempty"`
+	Approvals          int               `json:"approvals"`
+	Quorum             int               `json:"quorum"`
+	AggregateSignature string            `json:"aggregateSignature,omitempty"`
+	Extension          map[string]string `json:"extension,omitempty"`
 }
 
 //FulfillOrderRequest -
 type FulfillOrderRequest struct {
-	OrderPart1CID string            `json:"orderPart1CID,omitempty" validate:"IPFS"`
-	DocumentCID   string            `json:"documentCID,omitempty" validate:"IPFS"`
-	Extension     map[string]string `json:"extension,omitempty"`
+	OrderPart1CID string `json:"orderPart1CID,omitempty" validate:"IPFS"`
+	DocumentCID   string `json:"documentCID,omitempty" validate:"IPFS"`
+	// ShareDealCID is the share dealt to the fiduciary of a k-of-n order, empty for the dealer
+	ShareDealCID string            `json:"shareDealCID,omitempty" validate:"omitempty,IPFS"`
+	Extension    map[string]string `json:"extension,omitempty"`
 }
 
 //FulfillOrderResponse -
 type FulfillOrderResponse struct {
-	OrderPart2CID string            `json:"orderPart2CID,omitempty"`
+	OrderReference string            `json:"orderReference,omitempty"`
+	OrderPart2CID  string            `json:"orderPart2CID,omitempty"`
+	Extension      map[string]string `json:"extension,omitempty"`
+}
+
+//FulfillOrderBatchRequest -
+type FulfillOrderBatchRequest struct {
+	DocumentCID string                   `json:"documentCID,omitempty" validate:"IPFS"`
+	Orders      []FulfillOrderBatchOrder `json:"orders,omitempty" validate:"required,min=1,max=1000,dive"`
+}
+
+//FulfillOrderBatchOrder - order of the batch to fulfill
+type FulfillOrderBatchOrder struct {
+	OrderPart1CID string            `json:"orderPart1CID,omitempty" validate:"IPFS"`
 	Extension     map[string]string `json:"extension,omitempty"`
 }
 
+//FulfillOrderBatchResponse - the results are in the order of the request
+type FulfillOrderBatchResponse struct {
+	Orders []FulfillOrderBatchResult `json:"orders"`
+}
+
+//FulfillOrderBatchResult - result of a single order of the batch
+type FulfillOrderBatchResult struct {
+	OrderPart1CID  string            `json:"orderPart1CID,omitempty"`
+	OrderReference string            `json:"orderReference,omitempty"`
+	OrderPart2CID  string            `json:"orderPart2CID,omitempty"`
+	Extension      map[string]string `json:"extension,omitempty"`
+	Error          string            `json:"error,omitempty"`
+}
+
 //StatusResponse -
 type StatusResponse struct {
 	Application     string            `json:"application,omitempty"`
@@ -169,3 +464,75 @@ type StatusResponse struct {
 	Plugin          string            `json:"plugin,omitempty"`
 	NodeType        string            `json:"nodeType,omitempty"`
 }
+
+//ExportAuditResponse -
+type ExportAuditResponse struct {
+	NodeID       string              `json:"nodeID"`
+	BLSPublicKey string              `json:"blsPublicKey"`
+	Entries      []*audit.Entry      `json:"entries"`
+	Checkpoints  []*audit.Checkpoint `json:"checkpoints"`
+}
+
+//CreateWebhookRequest -
+type CreateWebhookRequest struct {
+	URL    string   `json:"url,omitempty" validate:"required,url"`
+	Events []string `json:"events,omitempty" validate:"max=4,dive,oneof=order.created order.fulfilled order.redeemed order.failed"`
+	// Secret is the HMAC key of the notifications. They are always signed with the node BLS key
+	Secret string `json:"secret,omitempty" validate:"omitempty,min=16"`
+	// PerSubject restricts the notifications to the orders of the caller
+	PerSubject bool              `json:"perSubject,omitempty"`
+	Subject    string            `json:"-"`
+	Extension  map[string]string `json:"extension,omitempty"`
+}
+
+//CreateWebhookResponse -
+type CreateWebhookResponse struct {
+	WebhookID string            `json:"webhookID,omitempty"`
+	Extension map[string]string `json:"extension,omitempty"`
+}
+
+//Webhook -
+type Webhook struct {
+	WebhookID string   `json:"webhookID,omitempty"`
+	URL       string   `json:"url,omitempty"`
+	Events    []string `json:"events,omitempty"`
+	Subject   string   `json:"subject,omitempty"`
+	HMAC      bool     `json:"hmac,omitempty"`
+	CreatedAt int64    `json:"createdAt,omitempty"`
+}
+
+//WebhookListRequest -
+type WebhookListRequest struct {
+	// Subject is the OIDC subject of the caller, only the webhooks it registered are listed
+	Subject string `json:"-"`
+}
+
+//WebhookListResponse -
+type WebhookListResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+//DeleteWebhookRequest -
+type DeleteWebhookRequest struct {
+	WebhookID string `json:"webhookID,omitempty" validate:"required"`
+	Subject   string `json:"-"`
+}
+
+//OrderEventsRequest -
+type OrderEventsRequest struct {
+	// Cursor is the ID of the last event received
+	Cursor  string `json:"cursor,omitempty" validate:"omitempty,numeric"`
+	Subject string `json:"-"`
+}
+
+//WebhookEvent - payload of the webhook notifications and of the event stream
+type WebhookEvent struct {
+	EventID        string `json:"eventID"`
+	Event          string `json:"event"`
+	NodeID         string `json:"nodeID"`
+	OrderReference string `json:"orderReference"`
+	State          string `json:"state"`
+	Commitment     string `json:"commitment,omitempty"`
+	Error          string `json:"error,omitempty"`
+	Timestamp      int64  `json:"timestamp"`
+}
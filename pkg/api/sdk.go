@@ -0,0 +1,505 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultClientTimeout       = 30 * time.Second
+	defaultClientRetryInterval = 500 * time.Millisecond
+)
+
+// Errors returned by the Client for the error responses of the node
+// The APIError of the response has the status code and the message
+var (
+	ErrBadRequest          = errors.New("bad request")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrForbidden           = errors.New("forbidden")
+	ErrNotFound            = errors.New("not found")
+	ErrConflict            = errors.New("conflict")
+	ErrGone                = errors.New("gone")
+	ErrUnprocessableEntity = errors.New("unprocessable entity")
+	ErrTooManyRequests     = errors.New("too many requests")
+	ErrServerError         = errors.New("server error")
+)
+
+var statusErrors = map[int]error{
+	http.StatusBadRequest:          ErrBadRequest,
+	http.StatusUnauthorized:        ErrUnauthorized,
+	http.StatusForbidden:           ErrForbidden,
+	http.StatusNotFound:            ErrNotFound,
+	http.StatusConflict:            ErrConflict,
+	http.StatusGone:                ErrGone,
+	http.StatusUnprocessableEntity: ErrUnprocessableEntity,
+	http.StatusTooManyRequests:     ErrTooManyRequests,
+}
+
+// APIError is the error response of the node
+// errors.Cause returns the error of the status code, ErrServerError for the unknown codes
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Cause returns the error of the status code
+func (e *APIError) Cause() error {
+	if err, ok := statusErrors[e.StatusCode]; ok {
+		return err
+	}
+	return ErrServerError
+}
+
+// Unwrap returns the error of the status code
+func (e *APIError) Unwrap() error {
+	return e.Cause()
+}
+
+// TokenSource returns the bearer token of the requests
+type TokenSource func(ctx context.Context) (string, error)
+
+// ClientOption configures the Client
+type ClientOption func(c *Client)
+
+// WithToken sets the bearer token of the requests
+func WithToken(token string) ClientOption {
+	return WithTokenSource(func(context.Context) (string, error) {
+		return token, nil
+	})
+}
+
+// WithTokenSource sets the source of the bearer token, called for every request
+func WithTokenSource(tokenSource TokenSource) ClientOption {
+	return func(c *Client) {
+		c.tokenSource = tokenSource
+	}
+}
+
+// WithTimeout sets the timeout of each attempt of a request
+// The event stream has no timeout
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetries sets the number of retries of a failed request and the interval before the first retry
+// The interval doubles with every retry
+// Only the requests that didn't reach the node and the GET and DELETE requests
+// failed with a network error or an unavailable node are retried
+func WithRetries(retries int, interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.retries = retries
+		c.retryInterval = interval
+	}
+}
+
+// WithHTTPClient sets the http client used for the requests, for example with the TLS config
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// Client is the Go client of the node API
+type Client struct {
+	server        *url.URL
+	httpClient    *http.Client
+	tokenSource   TokenSource
+	timeout       time.Duration
+	retries       int
+	retryInterval time.Duration
+}
+
+// NewClient creates a new client of the node at the server address
+func NewClient(server string, options ...ClientOption) (*Client, error) {
+	if !strings.HasPrefix(server, "http") {
+		server = "http://" + server
+	}
+	u, err := url.Parse(strings.TrimRight(server, "/"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid server address")
+	}
+
+	c := &Client{
+		server:        u,
+		httpClient:    &http.Client{},
+		timeout:       defaultClientTimeout,
+		retryInterval: defaultClientRetryInterval,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c, nil
+}
+
+// CreateIdentity creates a new identity of the node
+func (c *Client) CreateIdentity(ctx context.Context, req *CreateIdentityRequest) (*CreateIdentityResponse, error) {
+	res := &CreateIdentityResponse{}
+	return res, c.Do(ctx, http.MethodPost, "/identity", nil, req, res)
+}
+
+// GetIdentity returns the identity of the IDDocument CID
+func (c *Client) GetIdentity(ctx context.Context, idDocumentCID string) (*GetIdentityResponse, error) {
+	res := &GetIdentityResponse{}
+	return res, c.Do(ctx, http.MethodGet, "/identity/"+url.PathEscape(idDocumentCID), nil, nil, res)
+}
+
+// IdentityList returns a page of the identities
+func (c *Client) IdentityList(ctx context.Context, req *IdentityListRequest) (*IdentityListResponse, error) {
+	q := url.Values{}
+	setQuery(q, "page", req.Page)
+	setQuery(q, "perPage", req.PerPage)
+	setQuery(q, "sortBy", req.SortBy)
+	setQuery(q, "cursor", req.Cursor)
+	setQuery(q, "count", req.Count)
+
+	res := &IdentityListResponse{}
+	return res, c.Do(ctx, http.MethodGet, "/identity", q, nil, res)
+}
+
+// Order creates a new order
+func (c *Client) Order(ctx context.Context, req *OrderRequest) (*OrderResponse, error) {
+	res := &OrderResponse{}
+	return res, c.Do(ctx, http.MethodPost, "/order", nil, req, res)
+}
+
+// BatchOrder creates a batch of orders
+func (c *Client) BatchOrder(ctx context.Context, req *BatchOrderRequest) (*BatchOrderResponse, error) {
+	res := &BatchOrderResponse{}
+	return res, c.Do(ctx, http.MethodPost, "/order/batch", nil, req, res)
+}
+
+// GetOrder returns the order of the reference
+func (c *Client) GetOrder(ctx context.Context, orderReference string) (*GetOrderResponse, error) {
+	res := &GetOrderResponse{}
+	return res, c.Do(ctx, http.MethodGet, "/order/"+url.PathEscape(orderReference), nil, nil, res)
+}
+
+// OrderList returns a page of the orders matching the filters of the request
+func (c *Client) OrderList(ctx context.Context, req *OrderListRequest) (*OrderListResponse, error) {
+	q := url.Values{}
+	setQuery(q, "page", req.Page)
+	setQuery(q, "perPage", req.PerPage)
+	setQuery(q, "sortBy", req.SortBy)
+	setQuery(q, "beneficiaryCID", req.BeneficiaryCID)
+	setQuery(q, "type", req.Type)
+	setQuery(q, "coin", req.Coin)
+	setQuery(q, "state", req.State)
+	setQuery(q, "role", req.Role)
+	setQuery(q, "from", req.From)
+	setQuery(q, "to", req.To)
+	setQuery(q, "cursor", req.Cursor)
+	setQuery(q, "count", req.Count)
+
+	res := &OrderListResponse{}
+	return res, c.Do(ctx, http.MethodGet, "/order", q, nil, res)
+}
+
+// OrderSecret requests the secret of the order
+func (c *Client) OrderSecret(ctx context.Context, req *OrderSecretRequest) (*OrderSecretResponse, error) {
+	res := &OrderSecretResponse{}
+	return res, c.Do(ctx, http.MethodPost, "/order/secret", nil, req, res)
+}
+
+// VerifyOrder verifies the order chains of the order
+func (c *Client) VerifyOrder(ctx context.Context, orderReference string) (*VerifyOrderResponse, error) {
+	res := &VerifyOrderResponse{}
+	return res, c.Do(ctx, http.MethodGet, "/order/"+url.PathEscape(orderReference)+"/verify", nil, nil, res)
+}
+
+// ResumeOrder retries the failed order
+func (c *Client) ResumeOrder(ctx context.Context, orderReference string) (*ResumeOrderResponse, error) {
+	res := &ResumeOrderResponse{}
+	return res, c.Do(ctx, http.MethodPost, "/order/"+url.PathEscape(orderReference)+"/resume", nil, nil, res)
+}
+
+// CancelOrder cancels the order with the fiduciaries
+func (c *Client) CancelOrder(ctx context.Context, req *CancelOrderRequest) (*CancelOrderResponse, error) {
+	res := &CancelOrderResponse{}
+	return res, c.Do(ctx, http.MethodPost, "/order/"+url.PathEscape(req.OrderReference)+"/cancel", nil, req, res)
+}
+
+// RedeemOrder requests the redemption of the order
+func (c *Client) RedeemOrder(ctx context.Context, req *RedeemOrderRequest) (*RedeemOrderResponse, error) {
+	res := &RedeemOrderResponse{}
+	return res, c.Do(ctx, http.MethodPost, "/order/redeem", nil, req, res)
+}
+
+// ApproveOrder approves a redemption request
+func (c *Client) ApproveOrder(ctx context.Context, req *ApproveOrderRequest) (*ApproveOrderResponse, error) {
+	res := &ApproveOrderResponse{}
+	return res, c.Do(ctx, http.MethodPost, "/order/approve", nil, req, res)
+}
+
+// CreatePolicy creates a new redemption policy
+func (c *Client) CreatePolicy(ctx context.Context, req *CreatePolicyRequest) (*CreatePolicyResponse, error) {
+	res := &CreatePolicyResponse{}
+	return res, c.Do(ctx, http.MethodPost, "/policy", nil, req, res)
+}
+
+// GetPolicy returns the policy of the CID
+func (c *Client) GetPolicy(ctx context.Context, policyCID string) (*GetPolicyResponse, error) {
+	res := &GetPolicyResponse{}
+	return res, c.Do(ctx, http.MethodGet, "/policy/"+url.PathEscape(policyCID), nil, nil, res)
+}
+
+// CreateWebhook registers a new webhook
+func (c *Client) CreateWebhook(ctx context.Context, req *CreateWebhookRequest) (*CreateWebhookResponse, error) {
+	res := &CreateWebhookResponse{}
+	return res, c.Do(ctx, http.MethodPost, "/webhook", nil, req, res)
+}
+
+// WebhookList returns the webhooks registered by the caller
+func (c *Client) WebhookList(ctx context.Context) (*WebhookListResponse, error) {
+	res := &WebhookListResponse{}
+	return res, c.Do(ctx, http.MethodGet, "/webhook", nil, nil, res)
+}
+
+// DeleteWebhook deletes a webhook registered by the caller
+func (c *Client) DeleteWebhook(ctx context.Context, webhookID string) error {
+	return c.Do(ctx, http.MethodDelete, "/webhook/"+url.PathEscape(webhookID), nil, nil, nil)
+}
+
+// ExportAudit returns the audit log of the node
+func (c *Client) ExportAudit(ctx context.Context) (*ExportAuditResponse, error) {
+	res := &ExportAuditResponse{}
+	return res, c.Do(ctx, http.MethodGet, "/audit", nil, nil, res)
+}
+
+// Status returns the status of the node
+func (c *Client) Status(ctx context.Context) (*StatusResponse, error) {
+	res := &StatusResponse{}
+	return res, c.Do(ctx, http.MethodGet, "/status", nil, nil, res)
+}
+
+// Ext calls the endpoint of the service plugin at /v1/ext/<namespace><path>
+// req is sent as JSON if not nil and the response is decoded into res if not nil
+func (c *Client) Ext(ctx context.Context, method, namespace, path string, query url.Values, req, res interface{}) error {
+	return c.Do(ctx, method, "/ext/"+namespace+path, query, req, res)
+}
+
+// OrderEvents streams the order events after the cursor until the context is done or handler returns an error
+// handler receives the cursor of every event, the stream can be resumed from the last cursor
+func (c *Client) OrderEvents(ctx context.Context, cursor string, handler func(cursor string, event *WebhookEvent) error) error {
+	q := url.Values{}
+	setQuery(q, "cursor", cursor)
+
+	r, err := c.newRequest(ctx, http.MethodGet, "/events", q, nil)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.httpClient.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return decodeAPIError(resp)
+	}
+
+	var id string
+	var data bytes.Buffer
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data.Len() == 0 {
+				continue
+			}
+			event := &WebhookEvent{}
+			if err := json.Unmarshal(data.Bytes(), event); err != nil {
+				return errors.Wrap(err, "invalid event")
+			}
+			data.Reset()
+			if err := handler(id, event); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, ":"):
+			//Keep-alive comment
+		case strings.HasPrefix(line, "id:"):
+			id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
+
+// Do sends the request to the API path of the node and decodes the JSON response into res
+// The request is retried as set by WithRetries
+func (c *Client) Do(ctx context.Context, method, path string, query url.Values, req, res interface{}) error {
+	var body []byte
+	if req != nil {
+		var err error
+		if body, err = json.Marshal(req); err != nil {
+			return errors.Wrap(err, "encode request")
+		}
+	}
+
+	interval := c.retryInterval
+	for attempt := 0; ; attempt++ {
+		retry, err := c.do(ctx, method, path, query, body, res)
+		if err == nil || !retry || attempt >= c.retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+}
+
+// do sends the request once and returns true if the request can be retried
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body []byte, res interface{}) (retry bool, err error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	r, err := c.newRequest(ctx, method, path, query, body)
+	if err != nil {
+		return false, err
+	}
+
+	idempotent := method == http.MethodGet || method == http.MethodDelete
+	resp, err := c.httpClient.Do(r)
+	if err != nil {
+		return idempotent || isDialError(err), err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			retry = idempotent
+		}
+		return retry, decodeAPIError(resp)
+	}
+
+	if res == nil {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return false, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		return idempotent, errors.Wrap(err, "decode response")
+	}
+	return false, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Request, error) {
+	u := *c.server
+	u.Path = c.server.Path + "/" + apiVersion + path
+	u.RawQuery = query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	r, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.tokenSource != nil {
+		token, err := c.tokenSource(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "bearer token")
+		}
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	return r, nil
+}
+
+// decodeAPIError returns the APIError of the error response
+func decodeAPIError(resp *http.Response) error {
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	ew := struct {
+		Error string `json:"error"`
+	}{}
+	message := strings.TrimSpace(string(b))
+	if err := json.Unmarshal(b, &ew); err == nil && ew.Error != "" {
+		message = ew.Error
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: message}
+}
+
+// isDialError returns true if the request failed before reaching the node
+func isDialError(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	opErr, ok := err.(*net.OpError)
+	return ok && opErr.Op == "dial"
+}
+
+// setQuery sets the query param if the value isn't empty
+func setQuery(q url.Values, name string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			q.Set(name, v)
+		}
+	case int:
+		if v != 0 {
+			q.Set(name, strconv.Itoa(v))
+		}
+	case int64:
+		if v != 0 {
+			q.Set(name, strconv.FormatInt(v, 10))
+		}
+	case bool:
+		if v {
+			q.Set(name, "true")
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package common
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/apache/incubator-milagro-dta/libs/crypto"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrInvalidApproval is returned when an approval signature doesn't match the approver
+	ErrInvalidApproval = errors.New("invalid approval signature")
+)
+
+// OrderApprovalMessage returns the message signed by the approvers of the redemptions of an order
+// The approval follows the order part 2 of the fiduciary, a renewed redemption request keeps it
+// Each field is prefixed by its length so the fields can't be shifted
+func OrderApprovalMessage(reference, orderPart2CID string) []byte {
+	message := []byte{}
+	for _, field := range []string{reference, orderPart2CID} {
+		length := make([]byte, 4)
+		binary.BigEndian.PutUint32(length, uint32(len(field)))
+		message = append(message, length...)
+		message = append(message, field...)
+	}
+	return message
+}
+
+// SignOrderApproval signs the approval of the order redemptions with the approver BLS key
+func SignOrderApproval(blsSK []byte, reference, orderPart2CID string) ([]byte, error) {
+	rc, signature := crypto.BLSSign(OrderApprovalMessage(reference, orderPart2CID), blsSK)
+	if rc != 0 {
+		return nil, fmt.Errorf("Failed to sign approval: %v", rc)
+	}
+	return signature, nil
+}
+
+// VerifyOrderApproval checks the approval signature with the approver BLS public key
+func VerifyOrderApproval(blsPK []byte, reference, orderPart2CID string, signature []byte) error {
+	if rc := crypto.BLSVerify(OrderApprovalMessage(reference, orderPart2CID), blsPK, signature); rc != 0 {
+		return ErrInvalidApproval
+	}
+	return nil
+}
+
+// AggregateOrderApprovals combines the approval signatures into one signature
+// The aggregate signature verifies with the sum of the approvers public keys
+func AggregateOrderApprovals(reference, orderPart2CID string, blsPKs, signatures [][]byte) ([]byte, error) {
+	if len(signatures) == 0 || len(signatures) != len(blsPKs) {
+		return nil, errors.New("no approvals to aggregate")
+	}
+
+	pk, signature := blsPKs[0], signatures[0]
+	for i := 1; i < len(signatures); i++ {
+		var rc int
+		if rc, pk = crypto.BLSAddG2(pk, blsPKs[i]); rc != 0 {
+			return nil, fmt.Errorf("Failed to aggregate public keys: %v", rc)
+		}
+		if rc, signature = crypto.BLSAddG1(signature, signatures[i]); rc != 0 {
+			return nil, fmt.Errorf("Failed to aggregate signatures: %v", rc)
+		}
+	}
+
+	if err := VerifyOrderApproval(pk, reference, orderPart2CID, signature); err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
@@ -0,0 +1,279 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/apache/incubator-milagro-dta/libs/documents"
+	"github.com/apache/incubator-milagro-dta/libs/ipfs"
+	"github.com/golang/protobuf/proto"
+)
+
+// Order chain parts
+const (
+	OrderPart1     = "OrderPart1"
+	OrderPart2     = "OrderPart2"
+	OrderPart3     = "OrderPart3"
+	OrderPart4     = "OrderPart4"
+	OrderCancel    = "OrderCancel"
+	OrderTombstone = "OrderTombstone"
+)
+
+// OrderChainClockSkew is the time in seconds a part can predate the previous part
+// The parts are timestamped by the principal and by the fiduciary
+var OrderChainClockSkew int64 = 60
+
+// maxOrderChainLength stops the walk of a chain that never reaches the order part 1
+const maxOrderChainLength = 16
+
+// orderChainPrevious are the parts that can precede each part
+var orderChainPrevious = map[string][]string{
+	OrderPart2:     {OrderPart1},
+	OrderPart3:     {OrderPart2},
+	OrderPart4:     {OrderPart3},
+	OrderCancel:    {OrderPart2, OrderPart4},
+	OrderTombstone: {OrderCancel},
+}
+
+// OrderChainLink is a verified document of the order chain
+type OrderChainLink struct {
+	CID       string
+	Part      string
+	SignerCID string
+	Timestamp int64
+	Order     *documents.OrderDoc
+}
+
+// OrderChainBreak is an inconsistency found in the order chain
+type OrderChainBreak struct {
+	CID    string
+	Reason string
+}
+
+// OrderChain is the result of the order chain verification
+type OrderChain struct {
+	// Links are in chronological order starting from the order part 1
+	Links  []*OrderChainLink
+	Breaks []OrderChainBreak
+}
+
+// Valid returns true if the chain has no breaks
+func (c *OrderChain) Valid() bool {
+	return len(c.Breaks) == 0
+}
+
+func (c *OrderChain) addBreak(cid, format string, args ...interface{}) {
+	c.Breaks = append(c.Breaks, OrderChainBreak{CID: cid, Reason: fmt.Sprintf(format, args...)})
+}
+
+// VerifyOrderChain walks the order chain back from orderCID to the order part 1
+// Every document is decrypted for the recipient and its envelope signature is verified
+// against the BLS key of the signer IDDoc. The parts of the principal must be signed
+// by the order principal and the other parts by fiduciaryCID.
+// The chain is checked for broken PreviousOrderCID links, parts out of sequence,
+// timestamps going back and earlier parts rewritten in the later documents
+func VerifyOrderChain(ipfs ipfs.Connector, orderCID, fiduciaryCID string, sikeSK []byte, recipientID string) *OrderChain {
+	chain := &OrderChain{}
+	signers := map[string]*documents.IDDoc{}
+	visited := map[string]bool{}
+
+	links := []*OrderChainLink{}
+	for cid := orderCID; cid != ""; {
+		if visited[cid] {
+			chain.addBreak(cid, "loop in the order chain")
+			break
+		}
+		if len(links) == maxOrderChainLength {
+			chain.addBreak(cid, "order chain longer than %v documents", maxOrderChainLength)
+			break
+		}
+		visited[cid] = true
+
+		link, err := retrieveOrderChainLink(ipfs, cid, sikeSK, recipientID, signers)
+		if err != nil {
+			chain.addBreak(cid, "%v", err)
+			break
+		}
+		links = append(links, link)
+
+		previousCID := orderPreviousCID(link.Order, link.Part)
+		if previousCID == "" && link.Part != OrderPart1 {
+			chain.addBreak(cid, "%s without a previous order CID", link.Part)
+		}
+		cid = previousCID
+	}
+
+	//Chronological order
+	for i := len(links) - 1; i >= 0; i-- {
+		chain.Links = append(chain.Links, links[i])
+	}
+
+	for i, link := range chain.Links {
+		verifyOrderChainSigner(chain, link, fiduciaryCID)
+		if i > 0 {
+			verifyOrderChainLink(chain, chain.Links[i-1], link)
+		}
+	}
+	return chain
+}
+
+// retrieveOrderChainLink retrieves the order document and verifies the envelope signature
+// The signer IDDocs are cached in signers
+func retrieveOrderChainLink(ipfs ipfs.Connector, cid string, sikeSK []byte, recipientID string, signers map[string]*documents.IDDoc) (*OrderChainLink, error) {
+	rawDoc, err := ipfs.Get(cid)
+	if err != nil {
+		return nil, fmt.Errorf("retrieve document: %v", err)
+	}
+	signerCID, err := documents.DecodeSignerCID(rawDoc)
+	if err != nil {
+		return nil, err
+	}
+	signerIDDoc, ok := signers[signerCID]
+	if !ok {
+		signerIDDoc, err = RetrieveIDDocFromIPFS(ipfs, signerCID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieve signer %s: %v", signerCID, err)
+		}
+		signers[signerCID] = signerIDDoc
+	}
+
+	order := &documents.OrderDoc{}
+	if err := documents.DecodeOrderDocument(rawDoc, cid, order, sikeSK, recipientID, signerIDDoc.BLSPublicKey); err != nil {
+		return nil, fmt.Errorf("invalid document signed by %s: %v", signerCID, err)
+	}
+
+	part := orderPart(order)
+	return &OrderChainLink{
+		CID:       cid,
+		Part:      part,
+		SignerCID: signerCID,
+		Timestamp: orderPartTimestamp(order, part),
+		Order:     order,
+	}, nil
+}
+
+// verifyOrderChainSigner checks the part is signed by the principal or by the fiduciary
+func verifyOrderChainSigner(chain *OrderChain, link *OrderChainLink, fiduciaryCID string) {
+	switch link.Part {
+	case OrderPart1, OrderPart3, OrderCancel:
+		if link.SignerCID != link.Order.PrincipalCID {
+			chain.addBreak(link.CID, "%s signed by %s, not by the principal %s", link.Part, link.SignerCID, link.Order.PrincipalCID)
+		}
+	default:
+		if link.SignerCID != fiduciaryCID {
+			chain.addBreak(link.CID, "%s signed by %s, not by the fiduciary %s", link.Part, link.SignerCID, fiduciaryCID)
+		}
+	}
+}
+
+// verifyOrderChainLink checks the part follows the previous part of the chain
+func verifyOrderChainLink(chain *OrderChain, previous, link *OrderChainLink) {
+	if !containsPart(orderChainPrevious[link.Part], previous.Part) {
+		chain.addBreak(link.CID, "%s follows %s", link.Part, previous.Part)
+	}
+
+	o, p := link.Order, previous.Order
+	if o.Reference != p.Reference || o.Type != p.Type || o.Coin != p.Coin || o.PrincipalCID != p.PrincipalCID || o.Timestamp != p.Timestamp {
+		chain.addBreak(link.CID, "order details differ from the previous document %s", previous.CID)
+	}
+
+	if link.Timestamp+OrderChainClockSkew < previous.Timestamp {
+		chain.addBreak(link.CID, "%s timestamp %v before the previous %s timestamp %v", link.Part, link.Timestamp, previous.Part, previous.Timestamp)
+	}
+
+	//The parts of the previous document are carried unchanged
+	earlier := []struct {
+		part              string
+		previous, current proto.Message
+		set               bool
+	}{
+		{OrderPart2, p.OrderPart2, o.OrderPart2, p.OrderPart2 != nil},
+		{OrderPart3, p.OrderPart3, o.OrderPart3, p.OrderPart3 != nil},
+		{OrderPart4, p.OrderPart4, o.OrderPart4, p.OrderPart4 != nil},
+		{OrderCancel, p.OrderCancel, o.OrderCancel, p.OrderCancel != nil},
+	}
+	for _, e := range earlier {
+		if e.set && !proto.Equal(e.previous, e.current) {
+			chain.addBreak(link.CID, "%s differs from the previous document %s", e.part, previous.CID)
+		}
+	}
+}
+
+// orderPart returns the last part added to the order document
+func orderPart(order *documents.OrderDoc) string {
+	switch {
+	case order.OrderTombstone != nil:
+		return OrderTombstone
+	case order.OrderCancel != nil:
+		return OrderCancel
+	case order.OrderPart4 != nil:
+		return OrderPart4
+	case order.OrderPart3 != nil:
+		return OrderPart3
+	case order.OrderPart2 != nil:
+		return OrderPart2
+	default:
+		return OrderPart1
+	}
+}
+
+// orderPreviousCID returns the CID of the document preceding the part
+func orderPreviousCID(order *documents.OrderDoc, part string) string {
+	switch part {
+	case OrderTombstone:
+		return order.OrderTombstone.PreviousOrderCID
+	case OrderCancel:
+		return order.OrderCancel.PreviousOrderCID
+	case OrderPart4:
+		return order.OrderPart4.PreviousOrderCID
+	case OrderPart3:
+		return order.OrderPart3.PreviousOrderCID
+	case OrderPart2:
+		return order.OrderPart2.PreviousOrderCID
+	default:
+		return ""
+	}
+}
+
+// orderPartTimestamp returns the timestamp of the part
+func orderPartTimestamp(order *documents.OrderDoc, part string) int64 {
+	switch part {
+	case OrderTombstone:
+		return order.OrderTombstone.Timestamp
+	case OrderCancel:
+		return order.OrderCancel.Timestamp
+	case OrderPart4:
+		return order.OrderPart4.Timestamp
+	case OrderPart3:
+		return order.OrderPart3.Timestamp
+	case OrderPart2:
+		return order.OrderPart2.Timestamp
+	default:
+		return order.Timestamp
+	}
+}
+
+func containsPart(parts []string, part string) bool {
+	for _, p := range parts {
+		if p == part {
+			return true
+		}
+	}
+	return false
+}
@@ -23,6 +23,7 @@ package common
 import (
 	"encoding/hex"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/apache/incubator-milagro-dta/libs/datastore"
@@ -62,6 +63,48 @@ func RetrieveOrderFromIPFS(ipfs ipfs.Connector, ipfsID string, sikeSK []byte, re
 	return o, err
 }
 
+// RetrieveSignedOrderFromIPFS - retrieve an Order from IPFS and verify it was signed by the ID document it names
+func RetrieveSignedOrderFromIPFS(ipfs ipfs.Connector, ipfsID string, sikeSK []byte, recipientID string) (order *documents.OrderDoc, signerCID string, err error) {
+	rawDocO, err := ipfs.Get(ipfsID)
+	if err != nil {
+		return nil, "", err
+	}
+	signerCID, err = documents.DecodeSignerCID(rawDocO)
+	if err != nil {
+		return nil, "", err
+	}
+	signerIDDoc, err := RetrieveIDDocFromIPFS(ipfs, signerCID)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "Retrieve signer %s", signerCID)
+	}
+	order = &documents.OrderDoc{}
+	if err := documents.DecodeOrderDocument(rawDocO, ipfsID, order, sikeSK, recipientID, signerIDDoc.BLSPublicKey); err != nil {
+		return nil, "", err
+	}
+	return order, signerCID, nil
+}
+
+// RetrievePolicyFromIPFS - retrieve a Policy from IPFS and verify it was signed by the ID document it names
+func RetrievePolicyFromIPFS(ipfs ipfs.Connector, ipfsID string) (policy *documents.PolicyDoc, signerCID string, err error) {
+	rawDocP, err := ipfs.Get(ipfsID)
+	if err != nil {
+		return nil, "", err
+	}
+	signerCID, err = documents.DecodeSignerCID(rawDocP)
+	if err != nil {
+		return nil, "", err
+	}
+	signerIDDoc, err := RetrieveIDDocFromIPFS(ipfs, signerCID)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "Retrieve signer %s", signerCID)
+	}
+	policy = documents.NewPolicyDoc()
+	if err := documents.DecodePolicyDocument(rawDocP, ipfsID, policy, signerIDDoc.BLSPublicKey); err != nil {
+		return nil, "", err
+	}
+	return policy, signerCID, nil
+}
+
 // RetrieveIDDocFromIPFS finds and parses the IDDocument
 func RetrieveIDDocFromIPFS(ipfs ipfs.Connector, ipfsID string) (*documents.IDDoc, error) {
 	iddoc := &documents.IDDoc{}
@@ -100,8 +143,24 @@ func RetrieveSeed(store *datastore.Store, reference string) (seedHex string, err
 	return seedHex, nil
 }
 
+// DestroySeed overwrites and deletes the order seed
+func DestroySeed(store *datastore.Store, reference string) error {
+	var seedHex string
+	if err := store.Get("keySeed", reference, &seedHex); err != nil {
+		return err
+	}
+	//Overwrite the value before deleting it
+	if err := store.Set("keySeed", reference, strings.Repeat("0", len(seedHex)), nil); err != nil {
+		return errors.Wrap(err, "overwrite seed")
+	}
+	if err := store.Del("keySeed", reference); err != nil {
+		return errors.Wrap(err, "delete seed")
+	}
+	return nil
+}
+
 // CreateAndStoreOrderPart2 -
-func CreateAndStoreOrderPart2(ipfs ipfs.Connector, store *datastore.Store, keyStore keystore.Store, order *documents.OrderDoc, orderPart1CID, commitmentPublicKey, nodeID string, recipients map[string]*documents.IDDoc) (orderPart2CID string, err error) {
+func CreateAndStoreOrderPart2(ipfs ipfs.Connector, store *datastore.Store, blsSK []byte, order *documents.OrderDoc, orderPart1CID, commitmentPublicKey, nodeID string, recipients map[string]*documents.IDDoc) (orderPart2CID string, err error) {
 	Part2 := documents.OrderPart2{
 		CommitmentPublicKey: commitmentPublicKey,
 		PreviousOrderCID:    orderPart1CID,
@@ -109,21 +168,70 @@ func CreateAndStoreOrderPart2(ipfs ipfs.Connector, store *datastore.Store, keySt
 	}
 	order.OrderPart2 = &Part2
 	//Write the updated doc back to IPFS
-	orderPart2CID, err = WriteOrderToIPFS(nodeID, ipfs, store, keyStore, nodeID, order, recipients)
+	orderPart2CID, err = WriteSignedOrderToIPFS(nodeID, ipfs, store, blsSK, order, recipients)
 	if err != nil {
 		return "", err
 	}
 	return orderPart2CID, nil
 }
 
+// CreateAndStoreShareOrderPart2 - Part 2 of a k-of-n order commits to the secret and to the shares of all the fiduciaries
+// The share deals are written by the first fiduciary only
+func CreateAndStoreShareOrderPart2(ipfs ipfs.Connector, store *datastore.Store, blsSK []byte, order *documents.OrderDoc, orderPart1CID, commitmentPublicKey string, shareCommitments [][]byte, shareDeals []*documents.ShareDeal, nodeID string, recipients map[string]*documents.IDDoc) (orderPart2CID string, err error) {
+	Part2 := documents.OrderPart2{
+		CommitmentPublicKey: commitmentPublicKey,
+		ShareCommitments:    shareCommitments,
+		ShareDeals:          shareDeals,
+		PreviousOrderCID:    orderPart1CID,
+		Timestamp:           time.Now().Unix(),
+	}
+	order.OrderPart2 = &Part2
+	//Write the updated doc back to IPFS
+	orderPart2CID, err = WriteSignedOrderToIPFS(nodeID, ipfs, store, blsSK, order, recipients)
+	if err != nil {
+		return "", err
+	}
+	return orderPart2CID, nil
+}
+
+// CreateAndStoreShareDeal writes the share of the order secret dealt to another fiduciary
+// The deal carries the commitments of the dealer order part 2, it isn't part of the order chain
+func CreateAndStoreShareDeal(ipfs ipfs.Connector, blsSK []byte, order *documents.OrderDoc, share *documents.SecretShare, commitmentPublicKey string, shareCommitments [][]byte, nodeID string, recipients map[string]*documents.IDDoc) (dealCID string, err error) {
+	deal := documents.NewOrderDoc()
+	deal.Type = order.Type
+	deal.Coin = order.Coin
+	deal.PrincipalCID = order.PrincipalCID
+	deal.Reference = order.Reference
+	deal.Timestamp = time.Now().Unix()
+	deal.Threshold = order.Threshold
+	deal.FiduciaryCIDs = order.FiduciaryCIDs
+	deal.Share = share
+	deal.OrderPart2 = &documents.OrderPart2{
+		CommitmentPublicKey: commitmentPublicKey,
+		ShareCommitments:    shareCommitments,
+		Timestamp:           deal.Timestamp,
+	}
+
+	rawDoc, err := documents.EncodeOrderDocument(nodeID, deal, blsSK, recipients)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to encode share deal")
+	}
+	dealCID, err = ipfs.Add(rawDoc)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to Save Raw Document into IPFS")
+	}
+	return dealCID, nil
+}
+
 // CreateAndStorePart3 adds part 3 "redemption request" to the order doc
-func CreateAndStorePart3(ipfs ipfs.Connector, store *datastore.Store, keyStore keystore.Store, order *documents.OrderDoc, orderPart2CID, nodeID string, beneficiaryEncryptedData []byte, recipients map[string]*documents.IDDoc) (orderPart3CID string, err error) {
+func CreateAndStorePart3(ipfs ipfs.Connector, store *datastore.Store, keyStore keystore.Store, order *documents.OrderDoc, orderPart2CID, nodeID, beneficiaryCID string, beneficiaryEncryptedData []byte, recipients map[string]*documents.IDDoc) (orderPart3CID string, err error) {
 	//Add part 3 "redemption request" to the order doc
 	redemptionRequest := documents.OrderPart3{
 		//TODO
 		Redemption:               "SignedReferenceNumber",
 		PreviousOrderCID:         orderPart2CID,
 		BeneficiaryEncryptedData: beneficiaryEncryptedData,
+		BeneficiaryCID:           beneficiaryCID,
 		Timestamp:                time.Now().Unix(),
 	}
 	order.OrderPart3 = &redemptionRequest
@@ -151,6 +259,53 @@ func CreateAndStoreOrderPart4(ipfs ipfs.Connector, store *datastore.Store, keySt
 	return orderPart4CID, nil
 }
 
+// CreateAndStoreShareOrderPart4 - Part 4 of a k-of-n order returns the fiduciary share
+func CreateAndStoreShareOrderPart4(ipfs ipfs.Connector, store *datastore.Store, keyStore keystore.Store, order *documents.OrderDoc, share *documents.SecretShare, orderPart3CID, nodeID string, recipients map[string]*documents.IDDoc) (orderPart4CID string, err error) {
+	Part4 := documents.OrderPart4{
+		Share:            share,
+		PreviousOrderCID: orderPart3CID,
+		Timestamp:        time.Now().Unix(),
+	}
+	order.OrderPart4 = &Part4
+	//Write the updated doc back to IPFS
+	orderPart4CID, err = WriteOrderToIPFS(nodeID, ipfs, store, keyStore, nodeID, order, recipients)
+	if err != nil {
+		return "", err
+	}
+	return orderPart4CID, nil
+}
+
+// CreateAndStoreOrderCancel adds the cancel request to the order doc
+func CreateAndStoreOrderCancel(ipfs ipfs.Connector, store *datastore.Store, keyStore keystore.Store, order *documents.OrderDoc, previousOrderCID, reason, nodeID string, recipients map[string]*documents.IDDoc) (orderCancelCID string, err error) {
+	cancel := documents.OrderCancel{
+		Reason:           reason,
+		PreviousOrderCID: previousOrderCID,
+		Timestamp:        time.Now().Unix(),
+	}
+	order.OrderCancel = &cancel
+	//Write the updated doc back to IPFS
+	orderCancelCID, err = WriteOrderToIPFS(nodeID, ipfs, store, keyStore, nodeID, order, recipients)
+	if err != nil {
+		return "", err
+	}
+	return orderCancelCID, nil
+}
+
+// CreateAndStoreOrderTombstone adds the tombstone of the destroyed order secret to the order doc
+func CreateAndStoreOrderTombstone(ipfs ipfs.Connector, store *datastore.Store, keyStore keystore.Store, order *documents.OrderDoc, orderCancelCID, nodeID string, recipients map[string]*documents.IDDoc) (orderTombstoneCID string, err error) {
+	tombstone := documents.OrderTombstone{
+		PreviousOrderCID: orderCancelCID,
+		Timestamp:        time.Now().Unix(),
+	}
+	order.OrderTombstone = &tombstone
+	//Write the updated doc back to IPFS
+	orderTombstoneCID, err = WriteOrderToIPFS(nodeID, ipfs, store, keyStore, nodeID, order, recipients)
+	if err != nil {
+		return "", err
+	}
+	return orderTombstoneCID, nil
+}
+
 // WriteOrderToIPFS writes the order document to IPFS network
 func WriteOrderToIPFS(nodeID string, ipfs ipfs.Connector, store *datastore.Store, keyStore keystore.Store, id string, order *documents.OrderDoc, recipients map[string]*documents.IDDoc) (ipfsAddress string, err error) { // Get the secret keys
 	seed, err := keyStore.Get("seed")
@@ -161,8 +316,13 @@ func WriteOrderToIPFS(nodeID string, ipfs ipfs.Connector, store *datastore.Store
 	if err != nil {
 		return "", err
 	}
+	return WriteSignedOrderToIPFS(nodeID, ipfs, store, blsSecretKey, order, recipients)
+}
 
-	rawDoc, err := documents.EncodeOrderDocument(nodeID, *order, blsSecretKey, recipients)
+// WriteSignedOrderToIPFS writes the order document signed with the BLS secret key to IPFS network
+// It saves the key derivation when many orders are written at once
+func WriteSignedOrderToIPFS(nodeID string, ipfs ipfs.Connector, store *datastore.Store, blsSK []byte, order *documents.OrderDoc, recipients map[string]*documents.IDDoc) (ipfsAddress string, err error) {
+	rawDoc, err := documents.EncodeOrderDocument(nodeID, *order, blsSK, recipients)
 	if err != nil {
 		return "", errors.Wrap(err, "Failed to encode IDDocument")
 	}
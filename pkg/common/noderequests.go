@@ -0,0 +1,164 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/crypto"
+	"github.com/apache/incubator-milagro-dta/libs/documents"
+	"github.com/apache/incubator-milagro-dta/libs/ipfs"
+	"github.com/apache/incubator-milagro-dta/libs/transport"
+	"github.com/pkg/errors"
+)
+
+// Node request signature headers
+const (
+	HeaderNodeID        = "X-Milagro-Node-Id"
+	HeaderNodeTimestamp = "X-Milagro-Node-Timestamp"
+	HeaderNodeSignature = "X-Milagro-Node-Signature"
+)
+
+// NodeRequestMaxAge is the time a signed node request is accepted for
+// It also bounds the clock difference between the nodes
+var NodeRequestMaxAge = 5 * time.Minute
+
+// NodeRequestMessage returns the message signed by the node sending a request
+// The body is hashed so the message has a fixed size
+func NodeRequestMessage(method, path, nodeID string, timestamp int64, body []byte) []byte {
+	h := sha256.Sum256(body)
+	return []byte(fmt.Sprintf("%s\n%s\n%s\n%d\n%x", method, path, nodeID, timestamp, h))
+}
+
+// NodeRequestSigner signs the requests to other nodes with the node BLS key
+type NodeRequestSigner struct {
+	nodeID string
+	blsSK  []byte
+}
+
+// NewNodeRequestSigner creates a new request signer for the node
+func NewNodeRequestSigner(nodeID string, blsSK []byte) *NodeRequestSigner {
+	return &NodeRequestSigner{
+		nodeID: nodeID,
+		blsSK:  blsSK,
+	}
+}
+
+// SignRequest adds the node ID, the timestamp and the signature headers to the request
+func (s *NodeRequestSigner) SignRequest(method, path string, header http.Header, body []byte) error {
+	timestamp := time.Now().Unix()
+	rc, signature := crypto.BLSSign(NodeRequestMessage(method, path, s.nodeID, timestamp, body), s.blsSK)
+	if rc != 0 {
+		return fmt.Errorf("Failed to sign request: %v", rc)
+	}
+
+	header.Set(HeaderNodeID, s.nodeID)
+	header.Set(HeaderNodeTimestamp, strconv.FormatInt(timestamp, 10))
+	header.Set(HeaderNodeSignature, hex.EncodeToString(signature))
+	return nil
+}
+
+// NodeRequestAuthorizer verifies the requests signed by the allowed nodes
+// The signature is checked with the BLS key of the node IDDoc
+type NodeRequestAuthorizer struct {
+	// BindTLSIdentity requires the client certificate of the request to be bound to the node
+	BindTLSIdentity bool
+
+	ipfs    ipfs.Connector
+	allowed map[string]bool
+
+	mutex  sync.Mutex
+	idDocs map[string]*documents.IDDoc
+}
+
+// NewNodeRequestAuthorizer creates a new authorizer accepting the requests of allowedNodeIDs
+func NewNodeRequestAuthorizer(ipfs ipfs.Connector, allowedNodeIDs ...string) *NodeRequestAuthorizer {
+	allowed := map[string]bool{}
+	for _, nodeID := range allowedNodeIDs {
+		allowed[nodeID] = true
+	}
+	return &NodeRequestAuthorizer{
+		ipfs:    ipfs,
+		allowed: allowed,
+		idDocs:  map[string]*documents.IDDoc{},
+	}
+}
+
+// AuthorizeRequest checks the request is recent and signed by an allowed node
+// The claims subject is the node ID
+func (a *NodeRequestAuthorizer) AuthorizeRequest(r *http.Request, body []byte) (*transport.UserClaims, error) {
+	header := r.Header
+	nodeID := header.Get(HeaderNodeID)
+	if nodeID == "" {
+		return nil, errors.New("missing node signature")
+	}
+	if !a.allowed[nodeID] {
+		return nil, errors.Errorf("node %s not allowed", nodeID)
+	}
+	if a.BindTLSIdentity {
+		if err := CheckNodeCertificate(transport.PeerCertificate(r), nodeID); err != nil {
+			return nil, err
+		}
+	}
+
+	timestamp, err := strconv.ParseInt(header.Get(HeaderNodeTimestamp), 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid node request timestamp")
+	}
+	age := time.Since(time.Unix(timestamp, 0))
+	if age > NodeRequestMaxAge || age < -NodeRequestMaxAge {
+		return nil, errors.Errorf("node request signed at %s", time.Unix(timestamp, 0).UTC().Format(time.RFC3339))
+	}
+
+	signature, err := hex.DecodeString(header.Get(HeaderNodeSignature))
+	if err != nil {
+		return nil, errors.New("invalid node request signature")
+	}
+
+	idDoc, err := a.idDoc(nodeID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "retrieve node %s", nodeID)
+	}
+	if rc := crypto.BLSVerify(NodeRequestMessage(r.Method, r.URL.Path, nodeID, timestamp, body), idDoc.BLSPublicKey, signature); rc != 0 {
+		return nil, errors.Errorf("invalid signature of node %s", nodeID)
+	}
+
+	return &transport.UserClaims{"sub": nodeID, "name": idDoc.AuthenticationReference}, nil
+}
+
+// idDoc returns the IDDoc of the node, the IDDocs are immutable and kept once retrieved
+func (a *NodeRequestAuthorizer) idDoc(nodeID string) (*documents.IDDoc, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if idDoc, ok := a.idDocs[nodeID]; ok {
+		return idDoc, nil
+	}
+	idDoc, err := RetrieveIDDocFromIPFS(a.ipfs, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	a.idDocs[nodeID] = idDoc
+	return idDoc, nil
+}
@@ -0,0 +1,70 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package common
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	"github.com/pkg/errors"
+)
+
+// NodeCertificateScheme is the scheme of the certificate URI binding a certificate to a node IDDoc
+// The certificate of the node has the URI SAN milagro-dta:<node IDDoc CID>
+const NodeCertificateScheme = "milagro-dta"
+
+// NodeCertificateURI returns the URI SAN of the certificates of the node
+func NodeCertificateURI(nodeID string) string {
+	return NodeCertificateScheme + ":" + nodeID
+}
+
+// CertificateNodeIDs returns the IDs of the nodes the certificate is bound to
+func CertificateNodeIDs(cert *x509.Certificate) []string {
+	nodeIDs := []string{}
+	for _, u := range cert.URIs {
+		if u.Scheme == NodeCertificateScheme && u.Opaque != "" {
+			nodeIDs = append(nodeIDs, u.Opaque)
+		}
+	}
+	return nodeIDs
+}
+
+// CheckNodeCertificate returns an error if the certificate isn't bound to the node
+func CheckNodeCertificate(cert *x509.Certificate, nodeID string) error {
+	if cert == nil {
+		return errors.Errorf("no certificate of node %s", nodeID)
+	}
+	for _, id := range CertificateNodeIDs(cert) {
+		if id == nodeID {
+			return nil
+		}
+	}
+	return errors.Errorf("certificate %s isn't bound to node %s", cert.Subject.CommonName, nodeID)
+}
+
+// BindNodeTLSConfig returns a copy of the client TLS config accepting only the server certificates bound to the node
+func BindNodeTLSConfig(tlsConfig *tls.Config, nodeID string) *tls.Config {
+	c := tlsConfig.Clone()
+	c.VerifyPeerCertificate = func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if len(verifiedChains) == 0 || len(verifiedChains[0]) == 0 {
+			return errors.Errorf("unverified certificate of node %s", nodeID)
+		}
+		return CheckNodeCertificate(verifiedChains[0][0], nodeID)
+	}
+	return c
+}
@@ -0,0 +1,165 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package common
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/apache/incubator-milagro-dta/libs/crypto"
+	"github.com/apache/incubator-milagro-dta/libs/cryptowallet"
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/libs/documents"
+	"github.com/pkg/errors"
+)
+
+// The secret of a k-of-n order is a BLS secret key split with the Shamir's secret sharing of libs/crypto
+// The hex secret key is the seed of the order commitment, as the seed of a single fiduciary order
+// Each share is committed with its BLS public key, so a share is verified without recovering the secret
+
+var (
+	// ErrInvalidShare is returned when a fiduciary share doesn't match its commitment
+	ErrInvalidShare = errors.New("invalid secret share")
+)
+
+// secretShare is the datastore representation of a documents.SecretShare
+type secretShare struct {
+	X []byte
+	Y []byte
+}
+
+// DealSecretShares generates a random order secret and splits it in n shares
+// Any k of the shares recover the secret, the share i is dealt to the fiduciary i
+// The secret isn't returned, only the public key that commits to it and the commitments to the shares
+func DealSecretShares(rng io.Reader, k, n int) (commitmentPublicKey string, shareCommitments [][]byte, shares []*documents.SecretShare, err error) {
+	if k < 1 || k > n {
+		return "", nil, nil, errors.Errorf("invalid threshold %v of %v", k, n)
+	}
+
+	seed := make([]byte, 48)
+	if _, err := io.ReadFull(rng, seed); err != nil {
+		return "", nil, nil, err
+	}
+	rc, _, secretKey := crypto.BLSKeys(seed, nil)
+	if rc != 0 {
+		return "", nil, nil, fmt.Errorf("Failed to generate order secret: %v", rc)
+	}
+	if _, err := io.ReadFull(rng, seed); err != nil {
+		return "", nil, nil, err
+	}
+	rc, x, y, _ := crypto.BLSMakeShares(k, n, seed, secretKey)
+	if rc != 0 {
+		return "", nil, nil, fmt.Errorf("Failed to make secret shares: %v", rc)
+	}
+
+	commitmentPublicKey, err = cryptowallet.RedeemPublicKey(hex.EncodeToString(secretKey))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	for i := 0; i < n; i++ {
+		share := &documents.SecretShare{
+			X: x[i*crypto.BGSBLS381 : (i+1)*crypto.BGSBLS381],
+			Y: y[i*crypto.BGSBLS381 : (i+1)*crypto.BGSBLS381],
+		}
+		rc, shareCommitment, _ := crypto.BLSKeys(nil, share.Y)
+		if rc != 0 {
+			return "", nil, nil, fmt.Errorf("Failed to commit to secret share: %v", rc)
+		}
+		shares = append(shares, share)
+		shareCommitments = append(shareCommitments, shareCommitment)
+	}
+	return commitmentPublicKey, shareCommitments, shares, nil
+}
+
+// VerifySecretShare checks the share against the BLS public key it was committed with
+func VerifySecretShare(share *documents.SecretShare, shareCommitment []byte) error {
+	if share == nil || len(share.X) != crypto.BGSBLS381 || len(share.Y) != crypto.BGSBLS381 {
+		return ErrInvalidShare
+	}
+	rc, publicKey, _ := crypto.BLSKeys(nil, share.Y)
+	if rc != 0 || !bytes.Equal(publicKey, shareCommitment) {
+		return ErrInvalidShare
+	}
+	return nil
+}
+
+// RecoverSecretShares recovers the order secret from k verified shares
+// The secret is returned as a hex secp256k1 private key, as returned by cryptowallet.RedeemSecret
+func RecoverSecretShares(k int, shares []*documents.SecretShare) (secret string, err error) {
+	if k < 1 || len(shares) < k {
+		return "", errors.Errorf("shares %v of %v", len(shares), k)
+	}
+	shares = shares[:k]
+
+	x := make([]byte, 0, k*crypto.BGSBLS381)
+	y := make([]byte, 0, k*crypto.BGSBLS381)
+	for i, share := range shares {
+		if share == nil || len(share.X) != crypto.BGSBLS381 || len(share.Y) != crypto.BGSBLS381 {
+			return "", ErrInvalidShare
+		}
+		for _, other := range shares[:i] {
+			if bytes.Equal(other.X, share.X) {
+				return "", errors.Wrap(ErrInvalidShare, "duplicated share")
+			}
+		}
+		x = append(x, share.X...)
+		y = append(y, share.Y...)
+	}
+
+	rc, secretKey := crypto.BLSRecoverSecret(k, x, y)
+	if rc != 0 {
+		return "", fmt.Errorf("Failed to recover order secret: %v", rc)
+	}
+	return cryptowallet.RedeemSecret(hex.EncodeToString(secretKey))
+}
+
+// StoreSecretShare keeps the fiduciary share of an order for later redemption
+func StoreSecretShare(store *datastore.Store, reference string, share *documents.SecretShare) error {
+	if err := store.Set("keyShare", reference, secretShare{X: share.X, Y: share.Y}, nil); err != nil {
+		return errors.Wrap(err, "store share")
+	}
+	return nil
+}
+
+// RetrieveSecretShare gets the fiduciary share of an order
+func RetrieveSecretShare(store *datastore.Store, reference string) (*documents.SecretShare, error) {
+	share := secretShare{}
+	if err := store.Get("keyShare", reference, &share); err != nil {
+		return nil, err
+	}
+	return &documents.SecretShare{X: share.X, Y: share.Y}, nil
+}
+
+// DestroySecretShare overwrites and deletes the fiduciary share of an order
+// The share can't be recovered once destroyed
+func DestroySecretShare(store *datastore.Store, reference string) error {
+	share := secretShare{}
+	if err := store.Get("keyShare", reference, &share); err != nil {
+		return err
+	}
+	//Overwrite the value before deleting it
+	if err := store.Set("keyShare", reference, secretShare{X: make([]byte, len(share.X)), Y: make([]byte, len(share.Y))}, nil); err != nil {
+		return errors.Wrap(err, "overwrite share")
+	}
+	if err := store.Del("keyShare", reference); err != nil {
+		return errors.Wrap(err, "delete share")
+	}
+	return nil
+}
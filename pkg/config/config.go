@@ -25,6 +25,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/go-yaml/yaml"
 )
@@ -42,12 +43,27 @@ var (
 
 // HTTPConfig -
 type HTTPConfig struct {
-	ListenAddr    string `yaml:"listenAddr"`
-	MetricsAddr   string `yaml:"metricsAddr"`
-	OIDCProvider  string `yaml:"oidcProvider"`
-	OIDCClientID  string `yaml:"oidcClientID"`
-	OIDCClientKey string `yaml:"oidcClientKey"`
-	CorsAllow     string `yaml:"corsAllow"`
+	ListenAddr    string    `yaml:"listenAddr"`
+	MetricsAddr   string    `yaml:"metricsAddr"`
+	OIDCProvider  string    `yaml:"oidcProvider"`
+	OIDCClientID  string    `yaml:"oidcClientID"`
+	OIDCClientKey string    `yaml:"oidcClientKey"`
+	CorsAllow     string    `yaml:"corsAllow"`
+	TLS           TLSConfig `yaml:"tls"`
+}
+
+// TLSConfig - certificates of the HTTP server and of the client calling the other nodes
+// TLS is enabled when the certificate is set
+type TLSConfig struct {
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	// CAFiles are the CAs trusted to verify the server and the client certificates
+	CAFiles []string `yaml:"caFiles"`
+	// ClientAuth requires a client certificate on every request
+	// Otherwise the client certificate is verified only if sent
+	ClientAuth bool `yaml:"clientAuth"`
+	// BindIdentity requires the node certificates to be bound to the node IDDoc
+	BindIdentity bool `yaml:"bindIdentity"`
 }
 
 // LogConfig -
@@ -66,12 +82,57 @@ type IPFSConfig struct {
 
 // NodeConfig -
 type NodeConfig struct {
-	NodeType              string `yaml:"nodeType"`
-	MasterFiduciaryServer string `yaml:"masterFiduciaryServer"`
-	MasterFiduciaryNodeID string `yaml:"masterFiduciaryNodeID"`
-	NodeID                string `yaml:"nodeID"`
-	NodeName              string `yaml:"nodeName"`
-	Datastore             string `yaml:"dataStore"`
+	NodeType              string            `yaml:"nodeType"`
+	MasterFiduciaryServer string            `yaml:"masterFiduciaryServer"`
+	MasterFiduciaryNodeID string            `yaml:"masterFiduciaryNodeID"`
+	NodeID                string            `yaml:"nodeID"`
+	NodeName              string            `yaml:"nodeName"`
+	Datastore             string            `yaml:"dataStore"`
+	Fiduciaries           []FiduciaryConfig `yaml:"fiduciaries"`
+	FiduciaryThreshold    int               `yaml:"fiduciaryThreshold"`
+	OrderQueue            OrderQueueConfig  `yaml:"orderQueue"`
+	OrderReaperInterval   time.Duration     `yaml:"orderReaperInterval"`
+	AuditSignInterval     time.Duration     `yaml:"auditSignInterval"`
+	Webhooks              WebhookConfig     `yaml:"webhooks"`
+	// EnvelopeMaxAge is the age of the oldest envelope accepted by the fiduciary
+	EnvelopeMaxAge time.Duration `yaml:"envelopeMaxAge"`
+	// OrderEventRetention is the age of the oldest order event kept for the event streams
+	OrderEventRetention time.Duration `yaml:"orderEventRetention"`
+	// MaxBatchSize is the number of orders accepted in a batch, up to 1000
+	MaxBatchSize int `yaml:"maxBatchSize"`
+	// AllowedNodes are the IDs of the nodes allowed to call the fulfill endpoints
+	// The node is always allowed to call itself
+	AllowedNodes []string `yaml:"allowedNodes"`
+	// Operators are the OIDC subjects allowed to register the node-wide webhooks
+	// Without OIDC every caller is the operator of the node
+	Operators []string `yaml:"operators"`
+	// KeystorePassphraseFile is the file with the passphrase of the encrypted keystore
+	// The passphrase is read from MILAGRO_KEYSTORE_PASSPHRASE or the terminal if it's empty
+	KeystorePassphraseFile string `yaml:"keystorePassphraseFile"`
+}
+
+// WebhookConfig - delivery settings for the webhook notifications
+type WebhookConfig struct {
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxAttempts before the notification is dropped
+	MaxAttempts int `yaml:"maxAttempts"`
+	// AllowPrivateHosts allows the webhooks on loopback, private and link-local addresses
+	AllowPrivateHosts bool `yaml:"allowPrivateHosts"`
+}
+
+// OrderQueueConfig - retry settings for the asynchronous orders
+type OrderQueueConfig struct {
+	PollInterval     time.Duration `yaml:"pollInterval"`
+	RetryInterval    time.Duration `yaml:"retryInterval"`
+	MaxRetryInterval time.Duration `yaml:"maxRetryInterval"`
+	// MaxAttempts before the order is left failed, 0 retries forever
+	MaxAttempts int `yaml:"maxAttempts"`
+}
+
+// FiduciaryConfig - a fiduciary holding a share of k-of-n orders
+type FiduciaryConfig struct {
+	NodeID string `yaml:"nodeID"`
+	Server string `yaml:"server"`
 }
 
 // PluginsConfig -
@@ -17,6 +17,8 @@
 
 package config
 
+import "time"
+
 // DefaultConfig -
 func DefaultConfig() *Config {
 	return &Config{
@@ -67,6 +69,30 @@ func defaultNodeConfig() NodeConfig {
 		MasterFiduciaryNodeID: "",
 		NodeID:                "",
 		Datastore:             "embedded",
+		OrderQueue:            defaultOrderQueueConfig(),
+		OrderReaperInterval:   time.Minute,
+		AuditSignInterval:     10 * time.Minute,
+		Webhooks:              defaultWebhookConfig(),
+		EnvelopeMaxAge:        24 * time.Hour,
+		OrderEventRetention:   7 * 24 * time.Hour,
+		MaxBatchSize:          100,
+	}
+}
+
+// WebhookConfig -
+func defaultWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		Timeout:     10 * time.Second,
+		MaxAttempts: 20,
+	}
+}
+
+// OrderQueueConfig -
+func defaultOrderQueueConfig() OrderQueueConfig {
+	return OrderQueueConfig{
+		PollInterval:     5 * time.Second,
+		RetryInterval:    10 * time.Second,
+		MaxRetryInterval: 10 * time.Minute,
 	}
 }
 
@@ -0,0 +1,203 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"encoding/hex"
+
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/libs/documents"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+	"github.com/apache/incubator-milagro-dta/pkg/service"
+	"github.com/pkg/errors"
+)
+
+// ApproveOrder signs the approval of the order redemptions and sends it to the fiduciary
+func (s *Service) ApproveOrder(req *api.ApproveOrderRequest) (*api.ApproveOrderResponse, error) {
+	// BLS key
+	keyseed, err := s.KeyStore.Get("seed")
+	if err != nil {
+		return nil, err
+	}
+	_, blsSK, err := identity.GenerateBLSKeys(keyseed)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := common.SignOrderApproval(blsSK, req.OrderReference, req.OrderPart2CID)
+	if err != nil {
+		return nil, err
+	}
+
+	fiduciary, err := s.fiduciaryServer(req.FiduciaryCID)
+	if err != nil {
+		return nil, err
+	}
+	response, err := fiduciary.FulfillOrderApprove(&api.FulfillOrderApproveRequest{
+		OrderReference: req.OrderReference,
+		OrderPart2CID:  req.OrderPart2CID,
+		ApproverCID:    s.NodeID(),
+		Signature:      hex.EncodeToString(signature),
+		Extension:      req.Extension,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fiduciary %s", req.FiduciaryCID)
+	}
+
+	return &api.ApproveOrderResponse{
+		OrderReference:     response.OrderReference,
+		OrderPart2CID:      response.OrderPart2CID,
+		Approvals:          response.Approvals,
+		Quorum:             response.Quorum,
+		AggregateSignature: response.AggregateSignature,
+		Extension:          response.Extension,
+	}, nil
+}
+
+// FulfillOrderApprove collects the approver signatures of the order redemptions
+// The approvals follow the order part 2 written by the fiduciary, a renewed redemption request keeps them
+// The aggregate signature is returned once the policy quorum is reached
+func (s *Service) FulfillOrderApprove(req *api.FulfillOrderApproveRequest) (*api.FulfillOrderApproveResponse, error) {
+	op := &orderPolicy{}
+	switch err := s.Store.Get("orderPolicy", req.OrderReference, op); err {
+	case nil:
+	case datastore.ErrKeyNotFound:
+		return nil, errors.Wrapf(service.ErrPolicyViolation, "order %s doesn't require approvals", req.OrderReference)
+	default:
+		return nil, err
+	}
+	policy, _, err := common.RetrievePolicyFromIPFS(s.Ipfs, op.PolicyCID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Policy %s", op.PolicyCID)
+	}
+	if !containsCID(policy.RequiredApproverCIDs, req.ApproverCID) {
+		return nil, errors.Wrapf(service.ErrPolicyViolation, "%s isn't an approver of the order", req.ApproverCID)
+	}
+
+	approverIDDoc, err := common.RetrieveIDDocFromIPFS(s.Ipfs, req.ApproverCID)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := hex.DecodeString(req.Signature)
+	if err != nil {
+		return nil, common.ErrInvalidApproval
+	}
+	if err := common.VerifyOrderApproval(approverIDDoc.BLSPublicKey, req.OrderReference, req.OrderPart2CID, signature); err != nil {
+		return nil, errors.Wrapf(err, "approver %s", req.ApproverCID)
+	}
+
+	//The approvers of the same order are added one at a time
+	defer s.orderLocks.lock(req.OrderReference)()
+
+	//The approval must follow the order part 2 written by this node
+	fulfilment, err := s.loadOrderFulfilment(req.OrderReference)
+	if err != nil {
+		return nil, err
+	}
+	if fulfilment == nil || fulfilment.OrderPart2CID != req.OrderPart2CID {
+		return nil, errors.New("Approval doesn't match the order fulfilment")
+	}
+
+	approvals, err := s.orderApprovals(req.OrderPart2CID)
+	if err != nil {
+		return nil, err
+	}
+	approvals[req.ApproverCID] = signature
+	if err := s.Store.Set("orderApproval", req.OrderPart2CID, approvals, nil); err != nil {
+		return nil, errors.Wrap(err, "Save Order approval")
+	}
+
+	response := &api.FulfillOrderApproveResponse{
+		OrderReference: req.OrderReference,
+		OrderPart2CID:  req.OrderPart2CID,
+		Approvals:      len(policyApprovers(policy, approvals)),
+		Quorum:         approvalQuorum(policy),
+	}
+	if response.Approvals >= response.Quorum {
+		aggregate, err := s.aggregateOrderApprovals(req.OrderReference, req.OrderPart2CID, policy, approvals)
+		if err != nil {
+			return nil, err
+		}
+		response.AggregateSignature = hex.EncodeToString(aggregate)
+	}
+	return response, nil
+}
+
+// checkOrderApprovals returns ErrPolicyViolation until the quorum of approvers signed the order redemptions
+func (s *Service) checkOrderApprovals(reference, orderPart2CID string, policy *documents.PolicyDoc) error {
+	approvals, err := s.orderApprovals(orderPart2CID)
+	if err != nil {
+		return err
+	}
+
+	quorum := approvalQuorum(policy)
+	if approvers := policyApprovers(policy, approvals); len(approvers) < quorum {
+		return errors.Wrapf(service.ErrPolicyViolation, "approvals %v of %v", len(approvers), quorum)
+	}
+
+	if _, err := s.aggregateOrderApprovals(reference, orderPart2CID, policy, approvals); err != nil {
+		return errors.Wrapf(service.ErrPolicyViolation, "approvals: %v", err)
+	}
+	return nil
+}
+
+// aggregateOrderApprovals combines the approvals of the policy approvers into one signature
+func (s *Service) aggregateOrderApprovals(reference, orderPart2CID string, policy *documents.PolicyDoc, approvals map[string][]byte) ([]byte, error) {
+	var blsPKs, signatures [][]byte
+	for _, approverCID := range policyApprovers(policy, approvals) {
+		approverIDDoc, err := common.RetrieveIDDocFromIPFS(s.Ipfs, approverCID)
+		if err != nil {
+			return nil, err
+		}
+		blsPKs = append(blsPKs, approverIDDoc.BLSPublicKey)
+		signatures = append(signatures, approvals[approverCID])
+	}
+	return common.AggregateOrderApprovals(reference, orderPart2CID, blsPKs, signatures)
+}
+
+// orderApprovals returns the approver signatures of the order part 2
+func (s *Service) orderApprovals(orderPart2CID string) (map[string][]byte, error) {
+	approvals := map[string][]byte{}
+	switch err := s.Store.Get("orderApproval", orderPart2CID, &approvals); err {
+	case nil, datastore.ErrKeyNotFound:
+		return approvals, nil
+	default:
+		return nil, err
+	}
+}
+
+// policyApprovers returns the policy approvers that signed the order redemptions
+func policyApprovers(policy *documents.PolicyDoc, approvals map[string][]byte) []string {
+	var approvers []string
+	for _, approverCID := range policy.RequiredApproverCIDs {
+		if _, ok := approvals[approverCID]; ok && !containsCID(approvers, approverCID) {
+			approvers = append(approvers, approverCID)
+		}
+	}
+	return approvers
+}
+
+// approvalQuorum returns the number of approvals required by the policy
+func approvalQuorum(policy *documents.PolicyDoc) int {
+	if policy.ApprovalQuorum > 0 {
+		return int(policy.ApprovalQuorum)
+	}
+	return len(policy.RequiredApproverCIDs)
+}
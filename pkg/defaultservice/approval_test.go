@@ -0,0 +1,110 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/service"
+	"github.com/pkg/errors"
+)
+
+func TestFulfillOrderApproveConcurrent(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	const approverCount = 16
+	approvers := make([]*Service, approverCount)
+	approverCIDs := make([]string, approverCount)
+	for i := range approvers {
+		approvers[i] = n.node(fmt.Sprintf("approver%d", i), withMasterFiduciary(fiduciary))
+		approverCIDs[i] = approvers[i].NodeID()
+	}
+	policy, err := fiduciary.CreatePolicy(&api.CreatePolicyRequest{
+		Name:                 "approvers",
+		RequiredApproverCIDs: approverCIDs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orderResponse, err := principal.Order(&api.OrderRequest{PolicyCID: policy.PolicyCID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reference := orderResponse.OrderReference
+	_, err = principal.OrderSecret(&api.OrderSecretRequest{OrderReference: reference})
+	if errors.Cause(err) != service.ErrPolicyViolation {
+		t.Fatalf("invalid error. Expected: %v, found: %v", service.ErrPolicyViolation, err)
+	}
+	rec, err := principal.loadOrderRecord(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderPart2CID := rec.Fiduciaries[fiduciary.NodeID()].OrderPart2CID
+	orderPart3CID := rec.Fiduciaries[fiduciary.NodeID()].OrderPart3CID
+
+	var wg sync.WaitGroup
+	errs := make([]error, approverCount)
+	for i, approver := range approvers {
+		wg.Add(1)
+		go func(i int, approver *Service) {
+			defer wg.Done()
+			_, errs[i] = approver.ApproveOrder(&api.ApproveOrderRequest{
+				OrderReference: reference,
+				OrderPart2CID:  orderPart2CID,
+				FiduciaryCID:   fiduciary.NodeID(),
+			})
+		}(i, approver)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("ApproveOrder %v: %v", i, err)
+		}
+	}
+
+	//No approval is lost
+	approvals, err := fiduciary.orderApprovals(orderPart2CID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(approvals) != approverCount {
+		t.Fatalf("invalid approvals. Expected: %v, found: %v", approverCount, len(approvals))
+	}
+
+	//The approvals hold for a new redemption request of the order
+	secretResponse, err := principal.OrderSecret(&api.OrderSecretRequest{OrderReference: reference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec, err = principal.loadOrderRecord(reference); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Fiduciaries[fiduciary.NodeID()].OrderPart3CID == orderPart3CID {
+		t.Fatal("redemption request not written again")
+	}
+	if secretResponse.Commitment != orderResponse.Commitment {
+		t.Fatalf("invalid secret commitment. Expected: %v, found: %v", orderResponse.Commitment, secretResponse.Commitment)
+	}
+}
@@ -0,0 +1,121 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/audit"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+	"github.com/pkg/errors"
+)
+
+const defaultAuditSignInterval = 10 * time.Minute
+
+// RecordAudit appends an entry to the audit log of the node
+func (s *Service) RecordAudit(entry *audit.Entry) error {
+	if s.auditLog == nil {
+		return errors.New("Audit log not configured")
+	}
+	return s.auditLog.Append(entry)
+}
+
+// ExportAudit returns the audit log with the chain heads signed by the node
+// The chain head is signed before the export so every entry is covered
+func (s *Service) ExportAudit() (*api.ExportAuditResponse, error) {
+	if s.auditLog == nil {
+		return nil, errors.New("Audit log not configured")
+	}
+
+	// BLS key
+	keyseed, err := s.KeyStore.Get("seed")
+	if err != nil {
+		return nil, err
+	}
+	blsPK, blsSK, err := identity.GenerateBLSKeys(keyseed)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.auditLog.Sign(s.NodeID(), blsSK); err != nil && err != audit.ErrEmptyLog {
+		return nil, err
+	}
+
+	entries, err := s.auditLog.Entries(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints, err := s.auditLog.Checkpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.ExportAuditResponse{
+		NodeID:       s.NodeID(),
+		BLSPublicKey: hex.EncodeToString(blsPK),
+		Entries:      entries,
+		Checkpoints:  checkpoints,
+	}, nil
+}
+
+// RunAuditSigner signs the audit chain head periodically until stop is closed
+func (s *Service) RunAuditSigner(stop <-chan struct{}) {
+	signInterval := s.auditSignInterval
+	if signInterval <= 0 {
+		signInterval = defaultAuditSignInterval
+	}
+
+	ticker := time.NewTicker(signInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.signAuditLog(); err != nil {
+			s.Logger.Error("Audit signer: %v", err)
+		}
+	}
+}
+
+// signAuditLog signs the audit chain head with the node BLS key
+func (s *Service) signAuditLog() error {
+	if s.auditLog == nil {
+		return nil
+	}
+
+	// BLS key
+	keyseed, err := s.KeyStore.Get("seed")
+	if err != nil {
+		return err
+	}
+	_, blsSK, err := identity.GenerateBLSKeys(keyseed)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.auditLog.Sign(s.NodeID(), blsSK); err != nil && err != audit.ErrEmptyLog {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/libs/ipfs"
+	"github.com/apache/incubator-milagro-dta/libs/keystore"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+	"github.com/pkg/errors"
+)
+
+// BackupDocuments retrieves the documents a node needs to restore its orders
+// They are the node IDDocs and policies, and every order document kept in the order records,
+// in the fulfilments and in the tombstones. The order chains are followed back to the order part 1,
+// with the IDDocs of the signers and the share deals of the k-of-n orders
+func BackupDocuments(store *datastore.Store, ipfsConnector ipfs.Connector, keyStore keystore.Store, nodeID string) (map[string][]byte, error) {
+	keyseed, err := keyStore.Get("seed")
+	if err != nil {
+		return nil, err
+	}
+	_, sikeSK, err := identity.GenerateSIKEKeys(keyseed)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := map[string][]byte{}
+	getDoc := func(cid string) error {
+		if _, ok := docs[cid]; ok || cid == "" {
+			return nil
+		}
+		rawDoc, err := ipfsConnector.Get(cid)
+		if err != nil {
+			return errors.Wrapf(err, "retrieve document %s", cid)
+		}
+		docs[cid] = rawDoc
+		return nil
+	}
+
+	cids := []string{nodeID}
+	for _, datatype := range []string{"identity", "policy"} {
+		keys, err := store.ListKeys(datatype, "time", 0, 0, false)
+		if err != nil {
+			return nil, errors.Wrapf(err, "list %s", datatype)
+		}
+		cids = append(cids, keys...)
+	}
+	for _, cid := range cids {
+		if err := getDoc(cid); err != nil {
+			return nil, err
+		}
+	}
+
+	orderCIDs, err := storedOrderCIDs(store)
+	if err != nil {
+		return nil, err
+	}
+	for _, cid := range orderCIDs {
+		if _, ok := docs[cid]; ok {
+			continue
+		}
+		if err := getDoc(cid); err != nil {
+			return nil, err
+		}
+
+		//The documents the node can't read end the chain, they're kept as they are
+		chain := common.VerifyOrderChain(ipfsConnector, cid, "", sikeSK, nodeID)
+		for _, link := range chain.Links {
+			for _, linkCID := range []string{link.CID, link.SignerCID, link.Order.PrincipalCID} {
+				if err := getDoc(linkCID); err != nil {
+					return nil, err
+				}
+			}
+			if link.Order.OrderPart2 == nil {
+				continue
+			}
+			for _, deal := range link.Order.OrderPart2.ShareDeals {
+				if err := getDoc(deal.DealCID); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return docs, nil
+}
+
+// storedOrderCIDs returns the CIDs of the order documents kept by the node
+// The orders are the ones written by the node and the ones listed as principal or fiduciary
+func storedOrderCIDs(store *datastore.Store) ([]string, error) {
+	references := []string{}
+	for _, datatype := range []string{"order", "orderList"} {
+		keys, err := store.ListKeys(datatype, "time", 0, 0, false)
+		if err != nil {
+			return nil, errors.Wrapf(err, "list %s", datatype)
+		}
+		references = append(references, keys...)
+	}
+
+	cids := []string{}
+	for _, reference := range references {
+		for _, datatype := range []string{"order", "orderTombstone"} {
+			var cid string
+			if err := store.Get(datatype, reference, &cid); err != nil && err != datastore.ErrKeyNotFound {
+				return nil, errors.Wrapf(err, "get %s %s", datatype, reference)
+			}
+			cids = append(cids, cid)
+		}
+
+		rec := &orderRecord{}
+		switch err := store.Get("orderState", reference, rec); err {
+		case nil:
+			for _, fo := range rec.Fiduciaries {
+				cids = append(cids, fo.OrderPart1CID, fo.OrderPart2CID, fo.OrderPart3CID, fo.OrderPart4CID, fo.OrderCancelCID, fo.OrderTombstoneCID)
+				for _, dealCID := range fo.ShareDeals {
+					cids = append(cids, dealCID)
+				}
+			}
+		case datastore.ErrKeyNotFound:
+		default:
+			return nil, errors.Wrapf(err, "get order state %s", reference)
+		}
+
+		fulfilment := &orderFulfilment{}
+		switch err := store.Get("orderFulfilment", reference, fulfilment); err {
+		case nil:
+			cids = append(cids, fulfilment.OrderPart1CID, fulfilment.OrderPart2CID, fulfilment.OrderPart3CID, fulfilment.OrderPart4CID, fulfilment.PendingOrderPart3CID, fulfilment.ShareDealCID)
+		case datastore.ErrKeyNotFound:
+		default:
+			return nil, errors.Wrapf(err, "get order fulfilment %s", reference)
+		}
+	}
+
+	orderCIDs := []string{}
+	for _, cid := range cids {
+		if cid != "" {
+			orderCIDs = append(orderCIDs, cid)
+		}
+	}
+	return orderCIDs, nil
+}
@@ -0,0 +1,141 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/apache/incubator-milagro-dta/libs/ipfs"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+)
+
+// restoreDocuments backs up the documents of the node and restores them on an empty IPFS connector
+func restoreDocuments(t *testing.T, node *Service) ipfs.Connector {
+	t.Helper()
+
+	docs, err := BackupDocuments(node.Store, node.Ipfs, node.KeyStore, node.NodeID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := ipfs.NewMemoryConnector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for cid, rawDoc := range docs {
+		addedCID, err := restored.Add(rawDoc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if addedCID != cid {
+			t.Fatalf("document %s restored as %s", cid, addedCID)
+		}
+	}
+	return restored
+}
+
+func TestBackupDocuments(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciaries := []*Service{n.node("fiduciary1"), n.node("fiduciary2"), n.node("fiduciary3")}
+	principal := n.node("principal", withFiduciaries(2, fiduciaries...))
+
+	redeemed, err := principal.Order(&api.OrderRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secretResponse, err := principal.OrderSecret(&api.OrderSecretRequest{OrderReference: redeemed.OrderReference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelled, err := principal.Order(&api.OrderRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := principal.CancelOrder(&api.CancelOrderRequest{OrderReference: cancelled.OrderReference}); err != nil {
+		t.Fatal(err)
+	}
+
+	//Every node is restored on its own IPFS connector, with the documents of its own backup only
+	restored := map[*Service]ipfs.Connector{}
+	for _, node := range append([]*Service{principal}, fiduciaries...) {
+		restored[node] = restoreDocuments(t, node)
+	}
+	for node, connector := range restored {
+		node.Ipfs = connector
+	}
+
+	//The principal verifies the chains with all the fiduciaries and recovers the secret from the restored documents
+	for _, reference := range []string{redeemed.OrderReference, cancelled.OrderReference} {
+		verifyResponse, err := principal.VerifyOrder(&api.VerifyOrderRequest{OrderReference: reference})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !verifyResponse.Valid || len(verifyResponse.Chains) != len(fiduciaries) {
+			t.Fatalf("invalid restored order %s: %+v", reference, verifyResponse)
+		}
+	}
+	rec, err := principal.loadOrderRecord(redeemed.OrderReference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restoredSecret, err := principal.orderSecretResponse(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restoredSecret.Secret != secretResponse.Secret {
+		t.Fatal("invalid secret of the restored order")
+	}
+
+	//The fiduciaries keep their whole chains and the share deals
+	for i, fiduciary := range fiduciaries {
+		_, sikeSK, _, _, err := fiduciary.fulfillOrderKeys(principal.NodeID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		fulfilment, err := fiduciary.loadOrderFulfilment(redeemed.OrderReference)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lastCID := fulfilment.OrderPart2CID
+		if fulfilment.OrderPart4CID != "" {
+			lastCID = fulfilment.OrderPart4CID
+		}
+		if chain := common.VerifyOrderChain(fiduciary.Ipfs, lastCID, fiduciary.NodeID(), sikeSK, fiduciary.NodeID()); !chain.Valid() {
+			t.Fatalf("invalid restored chain of %s: %+v", fiduciary.NodeID(), chain.Breaks)
+		}
+		if fulfilment.ShareDealCID != "" {
+			if _, err := fiduciary.Ipfs.Get(fulfilment.ShareDealCID); err != nil {
+				t.Fatalf("share deal of %s not restored: %v", fiduciary.NodeID(), err)
+			}
+		}
+
+		tombstoneCID, err := fiduciary.orderTombstone(cancelled.OrderReference)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chain := common.VerifyOrderChain(fiduciary.Ipfs, tombstoneCID, fiduciary.NodeID(), sikeSK, fiduciary.NodeID()); !chain.Valid() {
+			t.Fatalf("invalid restored tombstone of %s: %+v", fiduciary.NodeID(), chain.Breaks)
+		}
+		if err := identity.CheckIdentity(fiduciary.NodeID(), fmt.Sprintf("fiduciary%v", i+1), fiduciary.Ipfs, fiduciary.KeyStore); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"github.com/apache/incubator-milagro-dta/libs/documents"
+	"github.com/apache/incubator-milagro-dta/libs/transport"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+	"github.com/pkg/errors"
+)
+
+const defaultMaxBatchSize = 100
+
+// batchOrder is an order of the batch waiting for the fiduciary
+type batchOrder struct {
+	// index of the order in the request
+	index int
+	rec   *orderRecord
+	// unlock releases the order once the fiduciary responded
+	unlock func()
+}
+
+// BatchOrder creates the orders of the batch with a single fulfilment request to the master fiduciary
+// The keys, IDDocs and policies are loaded once for the whole batch
+// A failed order is reported in its result without failing the batch, it can be resumed
+func (s *Service) BatchOrder(req *api.BatchOrderRequest) (*api.BatchOrderResponse, error) {
+	if err := s.checkBatchSize(len(req.Orders)); err != nil {
+		return nil, err
+	}
+
+	response := &api.BatchOrderResponse{
+		Orders: make([]api.BatchOrderResult, len(req.Orders)),
+	}
+	policies := map[string]*documents.PolicyDoc{}
+
+	//Each k-of-n order deals its own shares to the fiduciaries
+	if s.isThreshold() {
+		for i, o := range req.Orders {
+			rec, order, err := s.createOrder(o, policies)
+			if err != nil {
+				setBatchOrderResult(response, i, nil, nil, err)
+				continue
+			}
+			unlock := s.orderRecordLocks.lock(rec.Reference)
+			r, err := s.startOrder(rec, order, o.Async)
+			unlock()
+			setBatchOrderResult(response, i, rec, r, err)
+		}
+		return response, nil
+	}
+
+	nodeID := s.NodeID()
+	fiduciaryCID := s.MasterFiduciaryNodeID()
+	fiduciary, err := s.fiduciaryServer(fiduciaryCID)
+	if err != nil {
+		return nil, err
+	}
+
+	// SIKE and BLS keys
+	keyseed, err := s.KeyStore.Get("seed")
+	if err != nil {
+		return nil, err
+	}
+	_, sikeSK, err := identity.GenerateSIKEKeys(keyseed)
+	if err != nil {
+		return nil, err
+	}
+	_, blsSK, err := identity.GenerateBLSKeys(keyseed)
+	if err != nil {
+		return nil, err
+	}
+
+	//The orders are readable by the master fiduciary
+	recipientList, err := common.BuildRecipientList(s.Ipfs, nodeID, fiduciaryCID)
+	if err != nil {
+		return nil, err
+	}
+
+	fulfillRequest := &api.FulfillOrderBatchRequest{
+		DocumentCID: nodeID,
+	}
+	pending := []batchOrder{}
+	for i, o := range req.Orders {
+		rec, order, err := s.createOrder(o, policies)
+		if err != nil {
+			setBatchOrderResult(response, i, nil, nil, err)
+			continue
+		}
+		unlock := s.orderRecordLocks.lock(rec.Reference)
+		if err := s.writeBatchOrderPart1(rec, order, blsSK, recipientList); err != nil {
+			setBatchOrderResult(response, i, rec, nil, s.failOrder(rec, err))
+			unlock()
+			continue
+		}
+
+		if o.Async {
+			r, err := s.startOrder(rec, order, true)
+			unlock()
+			setBatchOrderResult(response, i, rec, r, err)
+			continue
+		}
+
+		fulfillRequest.Orders = append(fulfillRequest.Orders, api.FulfillOrderBatchOrder{
+			OrderPart1CID: rec.Fiduciaries[fiduciaryCID].OrderPart1CID,
+			Extension:     rec.FulfillExtension,
+		})
+		pending = append(pending, batchOrder{index: i, rec: rec, unlock: unlock})
+	}
+	if len(pending) == 0 {
+		return response, nil
+	}
+
+	//Fullfill the orders on the remote Server
+	fulfillResponse, err := fiduciary.FulfillOrderBatch(fulfillRequest)
+	if err == nil && len(fulfillResponse.Orders) != len(pending) {
+		err = errors.Errorf("%v results for %v orders", len(fulfillResponse.Orders), len(pending))
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "Contacting Fiduciary %s", fiduciaryCID)
+		for _, p := range pending {
+			setBatchOrderResult(response, p.index, p.rec, nil, s.failOrder(p.rec, err))
+			p.unlock()
+		}
+		return response, nil
+	}
+
+	fiduciaryIDDoc := recipientList[fiduciaryCID]
+	for i, p := range pending {
+		r, err := s.fulfillBatchOrder(p.rec, fiduciaryCID, fiduciaryIDDoc, sikeSK, &fulfillResponse.Orders[i])
+		p.unlock()
+		setBatchOrderResult(response, p.index, p.rec, r, err)
+	}
+	return response, nil
+}
+
+// checkBatchSize rejects the batches larger than the configured size
+func (s *Service) checkBatchSize(orders int) error {
+	maxBatchSize := s.maxBatchSize
+	if maxBatchSize <= 0 {
+		maxBatchSize = defaultMaxBatchSize
+	}
+	if orders > maxBatchSize {
+		return errors.Wrapf(transport.ErrInvalidRequest, "%v orders, the batch is limited to %v", orders, maxBatchSize)
+	}
+	return nil
+}
+
+// writeBatchOrderPart1 writes the order part 1 for the master fiduciary with the keys of the batch
+func (s *Service) writeBatchOrderPart1(rec *orderRecord, order *documents.OrderDoc, blsSK []byte, recipientList map[string]*documents.IDDoc) error {
+	nodeID := s.NodeID()
+
+	fulfillExtension, err := s.prepareOrderPart1(rec, order)
+	if err != nil {
+		return err
+	}
+
+	for _, fo := range rec.Fiduciaries {
+		fo.OrderPart1CID, err = common.WriteSignedOrderToIPFS(nodeID, s.Ipfs, s.Store, blsSK, order, recipientList)
+		if err != nil {
+			return err
+		}
+	}
+
+	rec.FulfillExtension = fulfillExtension
+	return s.setOrderState(rec, api.OrderStatePart1Written)
+}
+
+// fulfillBatchOrder completes an order with the result of the batched fulfilment
+func (s *Service) fulfillBatchOrder(rec *orderRecord, fiduciaryCID string, fiduciaryIDDoc *documents.IDDoc, sikeSK []byte, result *api.FulfillOrderBatchResult) (*api.OrderResponse, error) {
+	fo := rec.Fiduciaries[fiduciaryCID]
+	if result.Error != "" {
+		return nil, s.failOrder(rec, errors.Errorf("Fiduciary %s: %s", fiduciaryCID, result.Error))
+	}
+	if result.OrderPart1CID != fo.OrderPart1CID {
+		return nil, s.failOrder(rec, errors.Errorf("Fiduciary %s: result of order %s", fiduciaryCID, result.OrderPart1CID))
+	}
+
+	orderPart2, err := common.RetrieveOrderFromIPFS(s.Ipfs, result.OrderPart2CID, sikeSK, s.NodeID(), fiduciaryIDDoc.BLSPublicKey)
+	if err != nil {
+		return nil, s.failOrder(rec, errors.Wrap(err, "Fail to retrieve Order from IPFS"))
+	}
+
+	fo.OrderPart2CID = result.OrderPart2CID
+	fo.FulfillExtension = result.Extension
+	if err := s.setOrderState(rec, api.OrderStateFulfilled); err != nil {
+		return nil, s.failOrder(rec, err)
+	}
+
+	response, err := s.fulfilledOrderResponse(rec, orderPart2)
+	if err != nil {
+		return nil, s.failOrder(rec, err)
+	}
+	return response, nil
+}
+
+// setBatchOrderResult sets the result of the order at index i of the batch
+// rec is nil if the order couldn't be created
+func setBatchOrderResult(response *api.BatchOrderResponse, i int, rec *orderRecord, r *api.OrderResponse, err error) {
+	result := api.BatchOrderResult{}
+	if rec != nil {
+		result.OrderReference = rec.Reference
+		result.State = rec.State
+		result.CreatedAt = rec.CreatedAt
+	}
+	if r != nil {
+		result.Commitment = r.Commitment
+		result.State = r.State
+		result.Extension = r.Extension
+	}
+	if err != nil {
+		result.Error = err.Error()
+		response.Failed++
+	}
+	response.Orders[i] = result
+}
@@ -0,0 +1,59 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-milagro-dta/libs/transport"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/pkg/errors"
+)
+
+func TestBatchOrderSize(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+	principal.maxBatchSize = 2
+	fiduciary.maxBatchSize = 2
+
+	req := &api.BatchOrderRequest{Orders: []*api.OrderRequest{{}, {}}}
+	response, err := principal.BatchOrder(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range response.Orders {
+		if result.Error != "" {
+			t.Fatalf("order %v: %v", i, result.Error)
+		}
+	}
+
+	//The larger batches are rejected by the principal and the fiduciary
+	req.Orders = append(req.Orders, &api.OrderRequest{})
+	if _, err := principal.BatchOrder(req); errors.Cause(err) != transport.ErrInvalidRequest {
+		t.Fatalf("invalid error. Expected: %v, found: %v", transport.ErrInvalidRequest, err)
+	}
+	_, err = fiduciary.FulfillOrderBatch(&api.FulfillOrderBatchRequest{
+		DocumentCID: principal.NodeID(),
+		Orders:      make([]api.FulfillOrderBatchOrder, 3),
+	})
+	if errors.Cause(err) != transport.ErrInvalidRequest {
+		t.Fatalf("invalid error. Expected: %v, found: %v", transport.ErrInvalidRequest, err)
+	}
+}
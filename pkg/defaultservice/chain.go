@@ -0,0 +1,114 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+)
+
+// VerifyOrder verifies the order chain with every fiduciary of the order
+// The documents of the chain must also match the CIDs kept in the order record
+func (s *Service) VerifyOrder(req *api.VerifyOrderRequest) (*api.VerifyOrderResponse, error) {
+	nodeID := s.NodeID()
+
+	rec, err := s.loadOrderRecord(req.OrderReference)
+	if err != nil {
+		return nil, err
+	}
+
+	// SIKE key
+	keyseed, err := s.KeyStore.Get("seed")
+	if err != nil {
+		return nil, err
+	}
+	_, sikeSK, err := identity.GenerateSIKEKeys(keyseed)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &api.VerifyOrderResponse{
+		OrderReference: rec.Reference,
+		Valid:          true,
+		Chains:         []api.OrderChain{},
+	}
+	for _, fiduciaryCID := range rec.fiduciaryCIDs() {
+		fo := rec.Fiduciaries[fiduciaryCID]
+		orderCID := fo.lastCID()
+		if orderCID == "" {
+			continue
+		}
+
+		chain := common.VerifyOrderChain(s.Ipfs, orderCID, fiduciaryCID, sikeSK, nodeID)
+		verifyOrderRecordChain(chain, fo)
+
+		c := api.OrderChain{
+			FiduciaryCID: fiduciaryCID,
+			Valid:        chain.Valid(),
+			Documents:    []api.OrderChainDocument{},
+		}
+		for _, link := range chain.Links {
+			c.Documents = append(c.Documents, api.OrderChainDocument{
+				CID:       link.CID,
+				Part:      link.Part,
+				SignerCID: link.SignerCID,
+				Timestamp: link.Timestamp,
+			})
+		}
+		for _, b := range chain.Breaks {
+			c.Breaks = append(c.Breaks, api.OrderChainBreak{
+				CID:    b.CID,
+				Reason: b.Reason,
+			})
+		}
+		response.Valid = response.Valid && c.Valid
+		response.Chains = append(response.Chains, c)
+	}
+	return response, nil
+}
+
+// lastCID returns the CID of the last document of the chain with the fiduciary
+func (fo *fiduciaryOrder) lastCID() string {
+	for _, cid := range []string{fo.OrderTombstoneCID, fo.OrderCancelCID, fo.OrderPart4CID, fo.OrderPart3CID, fo.OrderPart2CID, fo.OrderPart1CID} {
+		if cid != "" {
+			return cid
+		}
+	}
+	return ""
+}
+
+// verifyOrderRecordChain adds a break for every part of the chain that isn't the one in the order record
+func verifyOrderRecordChain(chain *common.OrderChain, fo *fiduciaryOrder) {
+	recordCIDs := map[string]string{
+		common.OrderPart1:     fo.OrderPart1CID,
+		common.OrderPart2:     fo.OrderPart2CID,
+		common.OrderPart3:     fo.OrderPart3CID,
+		common.OrderPart4:     fo.OrderPart4CID,
+		common.OrderCancel:    fo.OrderCancelCID,
+		common.OrderTombstone: fo.OrderTombstoneCID,
+	}
+	for _, link := range chain.Links {
+		if cid := recordCIDs[link.Part]; cid != "" && cid != link.CID {
+			chain.Breaks = append(chain.Breaks, common.OrderChainBreak{
+				CID:    link.CID,
+				Reason: link.Part + " isn't the document " + cid + " of the order record",
+			})
+		}
+	}
+}
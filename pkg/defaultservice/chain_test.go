@@ -0,0 +1,85 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+)
+
+func TestVerifyOrder(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	orderResponse, err := principal.Order(&api.OrderRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reference := orderResponse.OrderReference
+	if _, err := principal.OrderSecret(&api.OrderSecretRequest{OrderReference: reference}); err != nil {
+		t.Fatal(err)
+	}
+	otherResponse, err := principal.Order(&api.OrderRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifyResponse, err := principal.VerifyOrder(&api.VerifyOrderRequest{OrderReference: reference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verifyResponse.Valid || len(verifyResponse.Chains) != 1 {
+		t.Fatalf("invalid order chains: %v", verifyResponse)
+	}
+	chain := verifyResponse.Chains[0]
+	parts := []string{common.OrderPart1, common.OrderPart2, common.OrderPart3, common.OrderPart4}
+	if chain.FiduciaryCID != fiduciary.NodeID() || len(chain.Documents) != len(parts) {
+		t.Fatalf("invalid order chain: %v", chain)
+	}
+	for i, doc := range chain.Documents {
+		if doc.Part != parts[i] {
+			t.Fatalf("invalid document %v. Expected: %v, found: %v", i, parts[i], doc.Part)
+		}
+	}
+
+	//The order record points to the order part 2 of another order
+	rec, err := principal.loadOrderRecord(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := principal.loadOrderRecord(otherResponse.OrderReference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Fiduciaries[fiduciary.NodeID()].OrderPart2CID = other.Fiduciaries[fiduciary.NodeID()].OrderPart2CID
+	if err := principal.saveOrderRecord(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	verifyResponse, err = principal.VerifyOrder(&api.VerifyOrderRequest{OrderReference: reference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verifyResponse.Valid || verifyResponse.Chains[0].Valid || len(verifyResponse.Chains[0].Breaks) == 0 {
+		t.Fatalf("order chain not broken: %v", verifyResponse)
+	}
+}
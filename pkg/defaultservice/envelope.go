@@ -0,0 +1,155 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/libs/documents"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+	"github.com/apache/incubator-milagro-dta/pkg/service"
+	"github.com/pkg/errors"
+)
+
+const defaultEnvelopeMaxAge = 24 * time.Hour
+
+// envelopeClockSkew is the time an envelope can be signed ahead of the node clock
+const envelopeClockSkew = 5 * time.Minute
+
+// envelopeNonce is the nonce of an envelope processed by the node
+type envelopeNonce struct {
+	CID      string
+	DateTime int64
+}
+
+func (s *Service) maxEnvelopeAge() time.Duration {
+	if s.envelopeMaxAge > 0 {
+		return s.envelopeMaxAge
+	}
+	return defaultEnvelopeMaxAge
+}
+
+// acceptEnvelope returns an error if the envelope is too old or its nonce was already processed
+// The nonce is kept until the envelope expires, releaseEnvelope frees it if the processing fails
+func (s *Service) acceptEnvelope(header *documents.Header, now time.Time) error {
+	signedAt := time.Unix(header.DateTime, 0)
+	if now.Sub(signedAt) > s.maxEnvelopeAge() {
+		return errors.Wrapf(service.ErrEnvelopeExpired, "envelope %s signed at %s", header.IPFSID, signedAt.UTC().Format(time.RFC3339))
+	}
+	if signedAt.Sub(now) > envelopeClockSkew {
+		return errors.Wrapf(service.ErrEnvelopeExpired, "envelope %s signed in the future at %s", header.IPFSID, signedAt.UTC().Format(time.RFC3339))
+	}
+	if len(header.Nonce) != documents.EnvelopeNonceLength {
+		return errors.Errorf("envelope %s: invalid nonce", header.IPFSID)
+	}
+
+	s.envelopeMutex.Lock()
+	defer s.envelopeMutex.Unlock()
+
+	key := hex.EncodeToString(header.Nonce)
+	en := &envelopeNonce{}
+	switch err := s.Store.Get("envelopeNonce", key, en); err {
+	case nil:
+		return errors.Wrapf(service.ErrEnvelopeReplay, "envelope %s nonce used by %s", header.IPFSID, en.CID)
+	case datastore.ErrKeyNotFound:
+	default:
+		return err
+	}
+
+	en = &envelopeNonce{
+		CID:      header.IPFSID,
+		DateTime: header.DateTime,
+	}
+	if err := s.Store.Set("envelopeNonce", key, en, map[string]string{"dateTime": fmt.Sprintf("%020d", header.DateTime)}); err != nil {
+		return errors.Wrap(err, "Save envelope nonce")
+	}
+	return nil
+}
+
+// releaseEnvelope frees the nonce of an envelope that failed to be processed
+// so the same request can be retried
+func (s *Service) releaseEnvelope(header *documents.Header) {
+	if err := s.Store.Del("envelopeNonce", hex.EncodeToString(header.Nonce)); err != nil {
+		s.Logger.Error("Release envelope %s: %v", header.IPFSID, err)
+	}
+}
+
+// renewOrderEnvelope writes again the order document signed by the node if the fiduciary would reject it as expired
+// The order is unchanged, the new envelope has a new nonce and signing time
+func (s *Service) renewOrderEnvelope(orderCID string, recipientList map[string]*documents.IDDoc, now time.Time) (string, error) {
+	rawDoc, err := s.Ipfs.Get(orderCID)
+	if err != nil {
+		return "", err
+	}
+	se, err := documents.SmartDecodeEnvelope(rawDoc, orderCID)
+	if err != nil {
+		return "", err
+	}
+	//The request must reach the fiduciary before the envelope expires
+	if now.Sub(time.Unix(se.Header.DateTime, 0)) < s.maxEnvelopeAge()-envelopeClockSkew {
+		return orderCID, nil
+	}
+
+	nodeID := s.NodeID()
+	keyseed, err := s.KeyStore.Get("seed")
+	if err != nil {
+		return "", err
+	}
+	_, sikeSK, err := identity.GenerateSIKEKeys(keyseed)
+	if err != nil {
+		return "", err
+	}
+	order, signerCID, err := common.RetrieveSignedOrderFromIPFS(s.Ipfs, orderCID, sikeSK, nodeID)
+	if err != nil {
+		return "", err
+	}
+	if signerCID != nodeID {
+		return "", errors.Errorf("envelope %s not signed by the node", orderCID)
+	}
+	return common.WriteOrderToIPFS(nodeID, s.Ipfs, s.Store, s.KeyStore, nodeID, order, recipientList)
+}
+
+// pruneEnvelopeNonces deletes the nonces of the envelopes expired before now
+// The expired envelopes are rejected by their age
+func (s *Service) pruneEnvelopeNonces(now time.Time) {
+	keys, err := s.Store.ListKeys("envelopeNonce", "dateTime", 0, 0, false)
+	if err != nil {
+		s.Logger.Error("Envelope nonces: %v", err)
+		return
+	}
+
+	expiry := now.Add(-s.maxEnvelopeAge()).Unix()
+	for _, key := range keys {
+		en := &envelopeNonce{}
+		if err := s.Store.Get("envelopeNonce", key, en); err != nil {
+			s.Logger.Error("Envelope nonce %s: %v", key, err)
+			continue
+		}
+		//The nonces are sorted by time
+		if en.DateTime >= expiry {
+			return
+		}
+		if err := s.Store.Del("envelopeNonce", key); err != nil {
+			s.Logger.Error("Envelope nonce %s: %v", key, err)
+		}
+	}
+}
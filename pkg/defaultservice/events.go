@@ -0,0 +1,255 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/libs/transport"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/pkg/errors"
+)
+
+// orderEventsBatch is the number of events read from the store at once
+const orderEventsBatch = 100
+
+const defaultOrderEventRetention = 7 * 24 * time.Hour
+
+// orderEvent is an order event kept for the event streams
+type orderEvent struct {
+	Seq uint64
+	// Subject is the OIDC subject that created the order
+	Subject string
+	Event   *api.WebhookEvent
+}
+
+// orderEventLog keeps the order events in sequence and wakes up the streams
+type orderEventLog struct {
+	store       *datastore.Store
+	mutex       sync.Mutex
+	seq         uint64
+	loaded      bool
+	subscribers map[chan struct{}]struct{}
+}
+
+func newOrderEventLog(store *datastore.Store) *orderEventLog {
+	return &orderEventLog{
+		store:       store,
+		subscribers: map[chan struct{}]struct{}{},
+	}
+}
+
+// append stores the event with the next sequence number
+func (l *orderEventLog) append(subject string, event *api.WebhookEvent) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	seq, err := l.head()
+	if err != nil {
+		return err
+	}
+
+	e := &orderEvent{
+		Seq:     seq + 1,
+		Subject: subject,
+		Event:   event,
+	}
+	key := orderEventKey(e.Seq)
+	if err := l.store.Set("orderEvent", key, e, map[string]string{"seq": key}); err != nil {
+		return errors.Wrap(err, "Save Order event")
+	}
+	l.seq = e.Seq
+
+	for wake := range l.subscribers {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// head returns the sequence number of the last event
+// The caller holds the mutex
+func (l *orderEventLog) head() (uint64, error) {
+	if l.loaded {
+		return l.seq, nil
+	}
+
+	keys, err := l.store.ListKeys("orderEvent", "seq", 0, 1, true)
+	if err != nil {
+		return 0, err
+	}
+	if len(keys) > 0 {
+		e := &orderEvent{}
+		if err := l.store.Get("orderEvent", keys[0], e); err != nil {
+			return 0, err
+		}
+		l.seq = e.Seq
+	}
+	l.loaded = true
+	return l.seq, nil
+}
+
+// after returns the events following the sequence number
+// The store seeks the first event, the events before the retention are gone
+func (l *orderEventLog) after(seq uint64, limit int) ([]*orderEvent, error) {
+	result, err := l.store.Query("orderEvent", &datastore.Query{
+		Index: "seq",
+		From:  orderEventKey(seq + 1),
+		Limit: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := []*orderEvent{}
+	for _, key := range result.Keys {
+		e := &orderEvent{}
+		if err := l.store.Get("orderEvent", key, e); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// prune deletes the events appended before the time
+// The last event is kept so the sequence continues after a restart
+func (l *orderEventLog) prune(before time.Time) error {
+	l.mutex.Lock()
+	head, err := l.head()
+	l.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for {
+		events, err := l.after(0, orderEventsBatch)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if e.Seq >= head || e.Event.Timestamp >= before.Unix() {
+				return nil
+			}
+			if err := l.store.Del("orderEvent", orderEventKey(e.Seq)); err != nil {
+				return err
+			}
+		}
+		if len(events) < orderEventsBatch {
+			return nil
+		}
+	}
+}
+
+// subscribe returns a channel signalled when a new event is appended
+func (l *orderEventLog) subscribe() chan struct{} {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	wake := make(chan struct{}, 1)
+	l.subscribers[wake] = struct{}{}
+	return wake
+}
+
+func (l *orderEventLog) unsubscribe(wake chan struct{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.subscribers, wake)
+}
+
+// StreamOrderEvents sends the order events following the cursor until stop is closed
+// Without a cursor the stream starts from the next event
+// Only the orders of the subject are sent unless the subject is empty
+func (s *Service) StreamOrderEvents(stop <-chan struct{}, req *api.OrderEventsRequest, send func(cursor string, event *api.WebhookEvent) error) error {
+	if s.orderEvents == nil {
+		return errors.New("Order events not configured")
+	}
+
+	// Subscribe before reading the head so no event is missed
+	wake := s.orderEvents.subscribe()
+	defer s.orderEvents.unsubscribe(wake)
+
+	var cursor uint64
+	if req.Cursor != "" {
+		c, err := strconv.ParseUint(req.Cursor, 10, 64)
+		if err != nil {
+			return errors.Wrapf(transport.ErrInvalidRequest, "invalid cursor %s", req.Cursor)
+		}
+		cursor = c
+	} else {
+		s.orderEvents.mutex.Lock()
+		c, err := s.orderEvents.head()
+		s.orderEvents.mutex.Unlock()
+		if err != nil {
+			return err
+		}
+		cursor = c
+	}
+
+	for {
+		events, err := s.orderEvents.after(cursor, orderEventsBatch)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			cursor = e.Seq
+			if req.Subject != "" && e.Subject != req.Subject {
+				continue
+			}
+			if err := send(strconv.FormatUint(e.Seq, 10), e.Event); err != nil {
+				return err
+			}
+		}
+		if len(events) == orderEventsBatch {
+			continue
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-wake:
+		}
+	}
+}
+
+// pruneOrderEvents deletes the order events older than the retention
+// The streams resumed from an older cursor start from the first event kept
+func (s *Service) pruneOrderEvents(now time.Time) {
+	if s.orderEvents == nil {
+		return
+	}
+	retention := s.orderEventRetention
+	if retention <= 0 {
+		retention = defaultOrderEventRetention
+	}
+	if err := s.orderEvents.prune(now.Add(-retention)); err != nil {
+		s.Logger.Error("Order events: %v", err)
+	}
+}
+
+// orderEventKey returns a sortable key of the sequence number
+func orderEventKey(seq uint64) string {
+	return fmt.Sprintf("%020d", seq)
+}
@@ -0,0 +1,75 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+)
+
+func TestOrderEventLog(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	node := n.node("node")
+	log := newOrderEventLog(node.Store)
+
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		event := &api.WebhookEvent{Timestamp: start.Add(time.Duration(i) * time.Minute).Unix()}
+		if err := log.append("", event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkEvents := func(seq uint64, expected ...uint64) {
+		t.Helper()
+
+		events, err := log.after(seq, orderEventsBatch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != len(expected) {
+			t.Fatalf("invalid events after %v. Expected: %v, found: %v", seq, len(expected), len(events))
+		}
+		for i, e := range events {
+			if e.Seq != expected[i] {
+				t.Fatalf("invalid event after %v. Expected: %v, found: %v", seq, expected[i], e.Seq)
+			}
+		}
+	}
+	checkEvents(2, 3, 4, 5)
+
+	//The events before the retention are gone
+	if err := log.prune(start.Add(3 * time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	checkEvents(0, 4, 5)
+	checkEvents(1, 4, 5)
+
+	//The last event is kept for the sequence
+	if err := log.prune(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	checkEvents(0, 5)
+	log = newOrderEventLog(node.Store)
+	if err := log.append("", &api.WebhookEvent{Timestamp: time.Now().Unix()}); err != nil {
+		t.Fatal(err)
+	}
+	checkEvents(0, 5, 6)
+}
@@ -0,0 +1,162 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/libs/documents"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/service"
+	"github.com/pkg/errors"
+)
+
+const defaultReaperInterval = time.Minute
+
+// orderValidity is the redemption window of an order kept by the fiduciary
+type orderValidity struct {
+	NotBefore int64
+	Expiry    int64
+	// Threshold is true when the fiduciary holds a share instead of a seed
+	Threshold bool
+	// Purged is true once the expired secret is destroyed
+	Purged bool
+}
+
+// checkOrderWindow returns an error if the order secret can't be redeemed at the time
+func checkOrderWindow(notBefore, expiry int64, now time.Time) error {
+	if notBefore != 0 && now.Unix() < notBefore {
+		return errors.Wrapf(service.ErrOrderNotYetValid, "valid from %s", time.Unix(notBefore, 0).UTC().Format(time.RFC3339))
+	}
+	if expiry != 0 && now.Unix() >= expiry {
+		return errors.Wrapf(service.ErrOrderExpired, "expired at %s", time.Unix(expiry, 0).UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// storeOrderValidity keeps the redemption window of the order signed by the fiduciary
+// The window can't be changed by the later order parts
+func (s *Service) storeOrderValidity(order *documents.OrderDoc) error {
+	if order.NotBefore == 0 && order.Expiry == 0 {
+		return nil
+	}
+
+	validity := &orderValidity{
+		NotBefore: order.NotBefore,
+		Expiry:    order.Expiry,
+		Threshold: order.Threshold > 0,
+	}
+	var index map[string]string
+	if validity.Expiry != 0 {
+		index = map[string]string{"expiry": fmt.Sprintf("%020d", validity.Expiry)}
+	}
+	if err := s.Store.Set("orderValidity", order.Reference, validity, index); err != nil {
+		return errors.Wrap(err, "Save Order validity")
+	}
+	return nil
+}
+
+// checkOrderValidity returns an error if the order secret can't be redeemed now
+func (s *Service) checkOrderValidity(reference string) error {
+	validity := &orderValidity{}
+	switch err := s.Store.Get("orderValidity", reference, validity); err {
+	case nil:
+	case datastore.ErrKeyNotFound:
+		return nil
+	default:
+		return err
+	}
+	if validity.Purged {
+		return errors.Wrap(service.ErrOrderExpired, "secret destroyed")
+	}
+	return checkOrderWindow(validity.NotBefore, validity.Expiry, time.Now())
+}
+
+// RunOrderReaper destroys the secrets of the expired orders until stop is closed
+func (s *Service) RunOrderReaper(stop <-chan struct{}) {
+	reaperInterval := s.reaperInterval
+	if reaperInterval <= 0 {
+		reaperInterval = defaultReaperInterval
+	}
+
+	ticker := time.NewTicker(reaperInterval)
+	defer ticker.Stop()
+
+	for {
+		s.reapExpiredOrders(time.Now())
+		s.pruneEnvelopeNonces(time.Now())
+		s.pruneOrderEvents(time.Now())
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reapExpiredOrders destroys the secrets of the orders expired before now
+func (s *Service) reapExpiredOrders(now time.Time) {
+	references, err := s.Store.ListKeys("orderValidity", "expiry", 0, 0, false)
+	if err != nil {
+		s.Logger.Error("Order reaper: %v", err)
+		return
+	}
+
+	for _, reference := range references {
+		validity := &orderValidity{}
+		if err := s.Store.Get("orderValidity", reference, validity); err != nil {
+			s.Logger.Error("Order reaper %s: %v", reference, err)
+			continue
+		}
+		//The orders are sorted by expiry
+		if validity.Expiry > now.Unix() {
+			return
+		}
+
+		if err := s.purgeOrderSecret(reference, validity); err != nil {
+			s.Logger.Error("Order reaper %s: %v", reference, err)
+			continue
+		}
+		s.Logger.Info("Order %s expired, secret destroyed", reference)
+	}
+}
+
+// purgeOrderSecret destroys the order secret and removes the order from the expiry index
+func (s *Service) purgeOrderSecret(reference string, validity *orderValidity) error {
+	//A redemption released at the same time keeps the secret until it's done
+	defer s.orderLocks.lock(reference)()
+
+	var err error
+	if validity.Threshold {
+		err = common.DestroySecretShare(s.Store, reference)
+	} else {
+		err = common.DestroySeed(s.Store, reference)
+	}
+	if err != nil && err != datastore.ErrKeyNotFound {
+		return err
+	}
+
+	validity.Purged = true
+	if err := s.Store.Set("orderValidity", reference, validity, nil); err != nil {
+		return errors.Wrap(err, "Save Order validity")
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/service"
+	"github.com/pkg/errors"
+)
+
+func TestCheckOrderWindow(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name      string
+		notBefore int64
+		expiry    int64
+		err       error
+	}{
+		{"no window", 0, 0, nil},
+		{"in the window", now.Add(-time.Minute).Unix(), now.Add(time.Minute).Unix(), nil},
+		{"not yet valid", now.Add(time.Minute).Unix(), 0, service.ErrOrderNotYetValid},
+		{"expired", 0, now.Unix(), service.ErrOrderExpired},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := checkOrderWindow(tc.notBefore, tc.expiry, now); errors.Cause(err) != tc.err {
+				t.Fatalf("invalid error. Expected: %v, found: %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestReapExpiredOrders(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	expiry := time.Now().Add(time.Hour)
+	orderResponse, err := principal.Order(&api.OrderRequest{Expiry: expiry.Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reference := orderResponse.OrderReference
+
+	var seedHex string
+	//The orders not expired are kept
+	fiduciary.reapExpiredOrders(time.Now())
+	if err := fiduciary.Store.Get("keySeed", reference, &seedHex); err != nil {
+		t.Fatal(err)
+	}
+
+	//The secret of an order being redeemed is destroyed once the redemption is done
+	unlock := fiduciary.orderLocks.lock(reference)
+	reaped := make(chan struct{})
+	go func() {
+		fiduciary.reapExpiredOrders(expiry)
+		close(reaped)
+	}()
+	select {
+	case <-reaped:
+		t.Fatal("secret destroyed during the redemption")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := fiduciary.Store.Get("keySeed", reference, &seedHex); err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	<-reaped
+
+	if err := fiduciary.Store.Get("keySeed", reference, &seedHex); err != datastore.ErrKeyNotFound {
+		t.Fatalf("invalid error. Expected: %v, found: %v", datastore.ErrKeyNotFound, err)
+	}
+	if _, err := principal.OrderSecret(&api.OrderSecretRequest{OrderReference: reference}); errors.Cause(err) != service.ErrOrderExpired {
+		t.Fatalf("invalid error. Expected: %v, found: %v", service.ErrOrderExpired, err)
+	}
+}
+
+func TestOrderNotYetValid(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	orderResponse, err := principal.Order(&api.OrderRequest{NotBefore: time.Now().Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := principal.OrderSecret(&api.OrderSecretRequest{OrderReference: orderResponse.OrderReference}); errors.Cause(err) != service.ErrOrderNotYetValid {
+		t.Fatalf("invalid error. Expected: %v, found: %v", service.ErrOrderNotYetValid, err)
+	}
+
+	//The fiduciary checks the window signed in the order
+	if err := fiduciary.checkOrderValidity(orderResponse.OrderReference); errors.Cause(err) != service.ErrOrderNotYetValid {
+		t.Fatalf("invalid error. Expected: %v, found: %v", service.ErrOrderNotYetValid, err)
+	}
+}
@@ -18,69 +18,224 @@
 package defaultservice
 
 import (
+	"time"
+
 	"github.com/apache/incubator-milagro-dta/libs/cryptowallet"
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/libs/documents"
 	"github.com/apache/incubator-milagro-dta/pkg/api"
 	"github.com/apache/incubator-milagro-dta/pkg/common"
 	"github.com/apache/incubator-milagro-dta/pkg/identity"
+	"github.com/apache/incubator-milagro-dta/pkg/service"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
 )
 
 // FulfillOrder -
 func (s *Service) FulfillOrder(req *api.FulfillOrderRequest) (*api.FulfillOrderResponse, error) {
-	orderPart1CID := req.OrderPart1CID
-	nodeID := s.NodeID()
-	remoteIDDocCID := req.DocumentCID
-
-	// SIKE key
-	keyseed, err := s.KeyStore.Get("seed")
+	remoteIDDoc, sikeSK, blsSK, recipientList, err := s.fulfillOrderKeys(req.DocumentCID)
 	if err != nil {
 		return nil, err
 	}
-	_, sikeSK, err := identity.GenerateSIKEKeys(keyseed)
-	if err != nil {
+
+	return s.fulfillOrder(req.OrderPart1CID, req.ShareDealCID, remoteIDDoc, sikeSK, blsSK, recipientList)
+}
+
+// FulfillOrderBatch fulfills the orders of a batch sent by a principal
+// The keys and the IDDocs are loaded once for the whole batch
+// A failed order is reported in its result without failing the batch
+func (s *Service) FulfillOrderBatch(req *api.FulfillOrderBatchRequest) (*api.FulfillOrderBatchResponse, error) {
+	if err := s.checkBatchSize(len(req.Orders)); err != nil {
 		return nil, err
 	}
 
-	remoteIDDoc, err := common.RetrieveIDDocFromIPFS(s.Ipfs, remoteIDDocCID)
+	remoteIDDoc, sikeSK, blsSK, recipientList, err := s.fulfillOrderKeys(req.DocumentCID)
 	if err != nil {
 		return nil, err
 	}
 
+	response := &api.FulfillOrderBatchResponse{
+		Orders: make([]api.FulfillOrderBatchResult, len(req.Orders)),
+	}
+	for i, o := range req.Orders {
+		result := api.FulfillOrderBatchResult{
+			OrderPart1CID: o.OrderPart1CID,
+		}
+		fulfilled, err := s.fulfillOrder(o.OrderPart1CID, "", remoteIDDoc, sikeSK, blsSK, recipientList)
+		if err != nil {
+			s.Logger.Error("Batch order %s: %v", o.OrderPart1CID, err)
+			result.Error = err.Error()
+		} else {
+			result.OrderReference = fulfilled.OrderReference
+			result.OrderPart2CID = fulfilled.OrderPart2CID
+			result.Extension = fulfilled.Extension
+		}
+		response.Orders[i] = result
+	}
+	return response, nil
+}
+
+// fulfillOrderKeys loads the node keys and the IDDocs needed to fulfill the orders of a principal
+func (s *Service) fulfillOrderKeys(remoteIDDocCID string) (remoteIDDoc *documents.IDDoc, sikeSK, blsSK []byte, recipientList map[string]*documents.IDDoc, err error) {
+	// SIKE and BLS keys
+	keyseed, err := s.KeyStore.Get("seed")
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	_, sikeSK, err = identity.GenerateSIKEKeys(keyseed)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	_, blsSK, err = identity.GenerateBLSKeys(keyseed)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	//The response is readable by the principal who sent the request
+	recipientList, err = common.BuildRecipientList(s.Ipfs, remoteIDDocCID, s.NodeID())
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return recipientList[remoteIDDocCID], sikeSK, blsSK, recipientList, nil
+}
+
+// fulfillOrder writes the order part 2 of the order part 1 sent by the principal
+// A repeated request returns the order part 2 written for the first one
+// shareDealCID is the share dealt to the node for a k-of-n order, empty for the dealer
+func (s *Service) fulfillOrder(orderPart1CID, shareDealCID string, remoteIDDoc *documents.IDDoc, sikeSK, blsSK []byte, recipientList map[string]*documents.IDDoc) (response *api.FulfillOrderResponse, err error) {
+	nodeID := s.NodeID()
+
 	//Retrieve the order from IPFS
 	order, err := common.RetrieveOrderFromIPFS(s.Ipfs, orderPart1CID, sikeSK, nodeID, remoteIDDoc.BLSPublicKey)
 	if err != nil {
 		return nil, err
 	}
+	//The concurrent requests for the same order can't both generate the secret
+	defer s.orderLocks.lock(order.Reference)()
 
-	recipientList, err := common.BuildRecipientList(s.Ipfs, nodeID, nodeID)
-	if err != nil {
+	if err := s.checkOrderNotCancelled(order.Reference); err != nil {
 		return nil, err
 	}
 
-	//Generate the secret and store for later redemption
-	seed, err := common.MakeRandomSeedAndStore(s.Store, s.Rng, order.Reference)
+	fulfilment, err := s.loadOrderFulfilment(order.Reference)
 	if err != nil {
 		return nil, err
 	}
+	switch {
+	case fulfilment == nil:
+		//The pending fulfilment is saved before the nonce
+		//A fulfilment interrupted by a crash is resumed by the repeated request
+		fulfilment = &orderFulfilment{
+			OrderPart1CID: orderPart1CID,
+		}
+		if err := s.saveOrderFulfilment(order.Reference, fulfilment); err != nil {
+			return nil, err
+		}
+		if err := s.acceptEnvelope(order.Header, time.Now()); err != nil {
+			s.deleteOrderFulfilment(order.Reference)
+			return nil, err
+		}
+		defer func() {
+			if err != nil {
+				s.deleteOrderFulfilment(order.Reference)
+				s.releaseEnvelope(order.Header)
+			}
+		}()
+	case fulfilment.OrderPart1CID != orderPart1CID && !s.isRenewedOrder(fulfilment.OrderPart1CID, order, sikeSK, remoteIDDoc):
+		return nil, errors.Wrapf(service.ErrOrderConflict, "order %s fulfilled for %s", order.Reference, fulfilment.OrderPart1CID)
+	case fulfilment.OrderPart2CID != "":
+		return &api.FulfillOrderResponse{
+			OrderReference: order.Reference,
+			OrderPart2CID:  fulfilment.OrderPart2CID,
+		}, nil
+	}
+	//The pending fulfilment continues with the renewed order part 1
+	fulfilment.OrderPart1CID = orderPart1CID
+	if order.Expiry != 0 && time.Now().Unix() >= order.Expiry {
+		return nil, service.ErrOrderExpired
+	}
+	if err := s.storeOrderValidity(order); err != nil {
+		return nil, err
+	}
+	if err := s.storeOrderPolicy(order); err != nil {
+		return nil, err
+	}
 
-	//Generate the Public Key (Commitment) from the Seed/Secret
-	commitmentPublicKey, err := cryptowallet.RedeemPublicKey(seed)
+	var orderPart2CID string
+	if order.Threshold > 0 {
+		orderPart2CID, err = s.fulfillShareOrder(order, orderPart1CID, shareDealCID, sikeSK, blsSK, recipientList)
+	} else {
+		orderPart2CID, err = s.fulfillSeedOrder(order, orderPart1CID, blsSK, recipientList)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	//Create an order response in IPFS
-	orderPart2CID, err := common.CreateAndStoreOrderPart2(s.Ipfs, s.Store, s.KeyStore, order, orderPart1CID, commitmentPublicKey, nodeID, recipientList)
-	if err != nil {
+	fulfilment.OrderPart2CID = orderPart2CID
+	fulfilment.ShareDealCID = shareDealCID
+	if err := s.saveOrderFulfilment(order.Reference, fulfilment); err != nil {
 		return nil, err
 	}
+	s.listFiduciaryOrder(order, api.OrderStateFulfilled)
 
 	return &api.FulfillOrderResponse{
-		OrderPart2CID: orderPart2CID,
+		OrderReference: order.Reference,
+		OrderPart2CID:  orderPart2CID,
 	}, nil
 }
 
+// fulfillShareOrder keeps the node share of the secret of a k-of-n order and commits to the secret
+// The first fiduciary of the order deals the shares, the others receive the share dealt to them
+func (s *Service) fulfillShareOrder(order *documents.OrderDoc, orderPart1CID, shareDealCID string, sikeSK, blsSK []byte, recipientList map[string]*documents.IDDoc) (string, error) {
+	index, err := shareIndex(order, s.NodeID())
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		commitmentPublicKey string
+		shareCommitments    [][]byte
+		shareDeals          []*documents.ShareDeal
+	)
+	if index == 1 {
+		commitmentPublicKey, shareCommitments, shareDeals, err = s.dealOrderShares(order, blsSK)
+	} else {
+		commitmentPublicKey, shareCommitments, err = s.receiveOrderShare(order, shareDealCID, sikeSK)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return common.CreateAndStoreShareOrderPart2(s.Ipfs, s.Store, blsSK, order, orderPart1CID, commitmentPublicKey, shareCommitments, shareDeals, s.NodeID(), recipientList)
+}
+
+// fulfillSeedOrder generates the order secret and commits to it
+// The seed of an order fulfilment interrupted before the order part 2 is reused
+func (s *Service) fulfillSeedOrder(order *documents.OrderDoc, orderPart1CID string, blsSK []byte, recipientList map[string]*documents.IDDoc) (string, error) {
+	seed, err := common.RetrieveSeed(s.Store, order.Reference)
+	if err != nil {
+		return "", err
+	}
+	if seed == "" {
+		//Generate the secret and store for later redemption
+		seed, err = common.MakeRandomSeedAndStore(s.Store, s.Rng, order.Reference)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	//Generate the Public Key (Commitment) from the Seed/Secret
+	commitmentPublicKey, err := cryptowallet.RedeemPublicKey(seed)
+	if err != nil {
+		return "", err
+	}
+
+	//Create an order response in IPFS
+	return common.CreateAndStoreOrderPart2(s.Ipfs, s.Store, blsSK, order, orderPart1CID, commitmentPublicKey, s.NodeID(), recipientList)
+}
+
 // FulfillOrderSecret -
-func (s *Service) FulfillOrderSecret(req *api.FulfillOrderSecretRequest) (*api.FulfillOrderSecretResponse, error) {
+func (s *Service) FulfillOrderSecret(req *api.FulfillOrderSecretRequest) (response *api.FulfillOrderSecretResponse, err error) {
 	//Initialise values from Request object
 	orderPart3CID := req.OrderPart3CID
 	nodeID := s.NodeID()
@@ -106,31 +261,337 @@ func (s *Service) FulfillOrderSecret(req *api.FulfillOrderSecretRequest) (*api.F
 	if err != nil {
 		return nil, err
 	}
+	//The redemptions of the same order are released one at a time
+	defer s.orderLocks.lock(order.Reference)()
 
-	recipientList, err := common.BuildRecipientList(s.Ipfs, nodeID, nodeID)
+	if err := s.checkOrderNotCancelled(order.Reference); err != nil {
+		return nil, err
+	}
+	if err := s.checkOrderValidity(order.Reference); err != nil {
+		return nil, err
+	}
+
+	//A repeated redemption returns the order part 4 written for the first one
+	fulfilment, err := s.loadOrderFulfilment(order.Reference)
 	if err != nil {
 		return nil, err
 	}
+	if fulfilment != nil {
+		if order.OrderPart3 == nil || order.OrderPart3.PreviousOrderCID != fulfilment.OrderPart2CID {
+			return nil, errors.Wrapf(service.ErrOrderConflict, "order %s: %s isn't a redemption of %s", order.Reference, orderPart3CID, fulfilment.OrderPart2CID)
+		}
+		if fulfilment.OrderPart4CID != "" && (fulfilment.OrderPart3CID == orderPart3CID || s.isRenewedOrder(fulfilment.OrderPart3CID, order, sikeSK, remoteIDDoc)) {
+			return &api.FulfillOrderSecretResponse{
+				OrderReference: order.Reference,
+				OrderPart4CID:  fulfilment.OrderPart4CID,
+			}, nil
+		}
+	}
 
-	//Retrieve the Seed
-	seed, err := common.RetrieveSeed(s.Store, order.Reference)
+	//A redemption interrupted by a crash is resumed without checking the nonce again
+	if fulfilment == nil || fulfilment.PendingOrderPart3CID != orderPart3CID {
+		if fulfilment != nil {
+			fulfilment.PendingOrderPart3CID = orderPart3CID
+			if err := s.saveOrderFulfilment(order.Reference, fulfilment); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.acceptEnvelope(order.Header, time.Now()); err != nil {
+			s.clearPendingRedemption(order.Reference, fulfilment)
+			return nil, err
+		}
+		defer func() {
+			if err != nil {
+				s.clearPendingRedemption(order.Reference, fulfilment)
+				s.releaseEnvelope(order.Header)
+			}
+		}()
+	}
+
+	//The response is readable by the beneficiary of the order
+	beneficiaryCID, orderPart2, err := s.orderBeneficiary(order, sikeSK, remoteIDDocCID)
+	if err != nil {
+		return nil, err
+	}
+	op, err := s.checkOrderPolicy(order.Reference, order.OrderPart3.PreviousOrderCID, orderPart3CID, beneficiaryCID)
+	if err != nil {
+		return nil, err
+	}
+	recipientList, err := common.BuildRecipientList(s.Ipfs, beneficiaryCID, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var orderPart4CID string
+	if orderPart2.Threshold > 0 {
+		//k-of-n order - return the share of the secret
+		share, err := s.orderSecretShare(order.Reference)
+		if err != nil {
+			return nil, err
+		}
+		orderPart4CID, err = common.CreateAndStoreShareOrderPart4(s.Ipfs, s.Store, s.KeyStore, order, share, orderPart3CID, nodeID, recipientList)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		//Retrieve the Seed
+		seed, err := common.RetrieveSeed(s.Store, order.Reference)
+		if err != nil {
+			return nil, err
+		}
+
+		//Generate the Secert from the Seed
+		commitmentPrivateKey, err := cryptowallet.RedeemSecret(seed)
+		if err != nil {
+			return nil, err
+		}
+
+		//Create an order response in IPFS
+		orderPart4CID, err = common.CreateAndStoreOrderPart4(s.Ipfs, s.Store, s.KeyStore, order, commitmentPrivateKey, orderPart3CID, nodeID, recipientList)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.recordOrderRedemption(order.Reference, orderPart3CID, op); err != nil {
+		return nil, err
+	}
+
+	if fulfilment != nil {
+		fulfilment.OrderPart3CID = orderPart3CID
+		fulfilment.OrderPart4CID = orderPart4CID
+		fulfilment.PendingOrderPart3CID = ""
+		if err := s.saveOrderFulfilment(order.Reference, fulfilment); err != nil {
+			return nil, err
+		}
+	}
+	s.setFiduciaryOrderState(order.Reference, api.OrderStateRedeemed)
+
+	return &api.FulfillOrderSecretResponse{
+		OrderReference: order.Reference,
+		OrderPart4CID:  orderPart4CID,
+	}, nil
+}
+
+// isRenewedOrder returns true if the order document was signed again by the principal with the same content
+// The principal renews the envelope of a retried order part before it expires
+func (s *Service) isRenewedOrder(previousCID string, order *documents.OrderDoc, sikeSK []byte, remoteIDDoc *documents.IDDoc) bool {
+	previous, err := common.RetrieveOrderFromIPFS(s.Ipfs, previousCID, sikeSK, s.NodeID(), remoteIDDoc.BLSPublicKey)
+	if err != nil {
+		return false
+	}
+	return proto.Equal(previous.OrderDocument, order.OrderDocument)
+}
+
+// orderFulfilment is the order chain written by the fiduciary
+// It's kept per order reference, a repeated request returns the documents written for the first one
+// The fulfilment is pending until the order part 2 is written
+type orderFulfilment struct {
+	OrderPart1CID string
+	OrderPart2CID string
+	// OrderPart3CID and OrderPart4CID are the last redemption released for the order
+	OrderPart3CID string
+	OrderPart4CID string
+	// PendingOrderPart3CID is the redemption accepted and not released yet
+	PendingOrderPart3CID string
+	// ShareDealCID is the share of a k-of-n order dealt to the node
+	ShareDealCID string
+}
+
+// loadOrderFulfilment returns nil if the order wasn't fulfilled by the node
+func (s *Service) loadOrderFulfilment(reference string) (*orderFulfilment, error) {
+	fulfilment := &orderFulfilment{}
+	switch err := s.Store.Get("orderFulfilment", reference, fulfilment); err {
+	case nil:
+		return fulfilment, nil
+	case datastore.ErrKeyNotFound:
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+func (s *Service) saveOrderFulfilment(reference string, fulfilment *orderFulfilment) error {
+	if err := s.Store.Set("orderFulfilment", reference, fulfilment, nil); err != nil {
+		return errors.Wrap(err, "Save Order fulfilment")
+	}
+	return nil
+}
+
+// deleteOrderFulfilment removes the pending fulfilment of a failed request
+func (s *Service) deleteOrderFulfilment(reference string) {
+	if err := s.Store.Del("orderFulfilment", reference); err != nil && err != datastore.ErrKeyNotFound {
+		s.Logger.Error("Delete Order fulfilment %s: %v", reference, err)
+	}
+}
+
+// clearPendingRedemption removes the pending redemption of a failed request
+func (s *Service) clearPendingRedemption(reference string, fulfilment *orderFulfilment) {
+	if fulfilment == nil {
+		return
+	}
+	fulfilment.PendingOrderPart3CID = ""
+	if err := s.saveOrderFulfilment(reference, fulfilment); err != nil {
+		s.Logger.Error("Clear pending redemption %s: %v", reference, err)
+	}
+}
+
+// FulfillOrderCancel destroys the order secret and writes a signed tombstone
+func (s *Service) FulfillOrderCancel(req *api.FulfillOrderCancelRequest) (*api.FulfillOrderCancelResponse, error) {
+	//Initialise values from Request object
+	orderCancelCID := req.OrderCancelCID
+	nodeID := s.NodeID()
+	remoteIDDocCID := req.SenderDocumentCID
+
+	// SIKE key
+	keyseed, err := s.KeyStore.Get("seed")
+	if err != nil {
+		return nil, err
+	}
+	_, sikeSK, err := identity.GenerateSIKEKeys(keyseed)
 	if err != nil {
 		return nil, err
 	}
 
-	//Generate the Secert from the Seed
-	commitmentPrivateKey, err := cryptowallet.RedeemSecret(seed)
+	remoteIDDoc, err := common.RetrieveIDDocFromIPFS(s.Ipfs, remoteIDDocCID)
 	if err != nil {
 		return nil, err
 	}
 
-	//Create an order response in IPFS
-	orderPart4ID, err := common.CreateAndStoreOrderPart4(s.Ipfs, s.Store, s.KeyStore, order, commitmentPrivateKey, orderPart3CID, nodeID, recipientList)
+	//Retrieve the cancel request signed by the principal
+	order, err := common.RetrieveOrderFromIPFS(s.Ipfs, orderCancelCID, sikeSK, nodeID, remoteIDDoc.BLSPublicKey)
 	if err != nil {
 		return nil, err
 	}
+	if order.OrderCancel == nil {
+		return nil, errors.New("Invalid cancel request")
+	}
+	defer s.orderLocks.lock(order.Reference)()
 
-	return &api.FulfillOrderSecretResponse{
-		OrderPart4CID: orderPart4ID,
+	//The cancel request must follow an order document signed by this node or sent to it by the principal
+	previousOrder, previousSignerCID, err := common.RetrieveSignedOrderFromIPFS(s.Ipfs, order.OrderCancel.PreviousOrderCID, sikeSK, nodeID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid cancel request")
+	}
+	if previousSignerCID != nodeID && previousSignerCID != remoteIDDocCID {
+		return nil, errors.New("Cancel request doesn't follow an order document")
+	}
+	if previousOrder.Reference != order.Reference {
+		return nil, errors.New("Cancel request doesn't match the order")
+	}
+
+	fulfilment, err := s.loadOrderFulfilment(order.Reference)
+	if err != nil {
+		return nil, err
+	}
+	if fulfilment == nil {
+		//The order may be cancelled before this node fulfilled it
+		//The order part 1 claims the reference for the principal as a fulfil request would
+		if previousSignerCID != remoteIDDocCID || previousOrder.OrderPart2 != nil {
+			return nil, errors.New("Cancel request doesn't follow an order part 1")
+		}
+		fulfilment = &orderFulfilment{
+			OrderPart1CID: order.OrderCancel.PreviousOrderCID,
+		}
+		if err := s.saveOrderFulfilment(order.Reference, fulfilment); err != nil {
+			return nil, err
+		}
+	}
+
+	//Only the principal of the order part 1 fulfilled by this node can cancel it
+	orderPart1, orderPart1SignerCID, err := common.RetrieveSignedOrderFromIPFS(s.Ipfs, fulfilment.OrderPart1CID, sikeSK, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if orderPart1SignerCID != remoteIDDocCID || orderPart1.PrincipalCID != remoteIDDocCID {
+		return nil, errors.New("Cancel request not sent by the principal of the order")
+	}
+
+	orderTombstoneCID, err := s.orderTombstone(order.Reference)
+	if err != nil {
+		return nil, err
+	}
+	if orderTombstoneCID == "" {
+		//The response is readable by the principal who sent the request
+		recipientList, err := common.BuildRecipientList(s.Ipfs, remoteIDDocCID, nodeID)
+		if err != nil {
+			return nil, err
+		}
+
+		orderTombstoneCID, err = common.CreateAndStoreOrderTombstone(s.Ipfs, s.Store, s.KeyStore, order, orderCancelCID, nodeID, recipientList)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.Store.Set("orderTombstone", order.Reference, orderTombstoneCID, nil); err != nil {
+			return nil, errors.Wrap(err, "Save Order tombstone")
+		}
+	}
+
+	//Destroy the order secret, it may be already gone if the cancel is repeated
+	//or never generated if the order wasn't fulfilled
+	if err := common.DestroySecretShare(s.Store, order.Reference); err != nil && err != datastore.ErrKeyNotFound {
+		return nil, err
+	}
+	if err := common.DestroySeed(s.Store, order.Reference); err != nil && err != datastore.ErrKeyNotFound {
+		return nil, err
+	}
+	s.setFiduciaryOrderState(order.Reference, api.OrderStateCancelled)
+
+	return &api.FulfillOrderCancelResponse{
+		OrderReference:    order.Reference,
+		OrderTombstoneCID: orderTombstoneCID,
 	}, nil
 }
+
+// orderBeneficiary returns the node that can read the order secret and the order part 2 signed by this node
+// The beneficiary named in the order part 2 can't be changed on redemption
+func (s *Service) orderBeneficiary(order *documents.OrderDoc, sikeSK []byte, principalCID string) (string, *documents.OrderDoc, error) {
+	if order.OrderPart3 == nil {
+		return "", nil, errors.New("Invalid redemption request")
+	}
+
+	localIDDoc, err := common.RetrieveIDDocFromIPFS(s.Ipfs, s.NodeID())
+	if err != nil {
+		return "", nil, err
+	}
+	orderPart2, err := common.RetrieveOrderFromIPFS(s.Ipfs, order.OrderPart3.PreviousOrderCID, sikeSK, s.NodeID(), localIDDoc.BLSPublicKey)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "Invalid redemption request")
+	}
+	if orderPart2.Reference != order.Reference || orderPart2.PrincipalCID != principalCID {
+		return "", nil, errors.New("Redemption request doesn't match the order")
+	}
+
+	beneficiaryCID := orderPart2.BeneficiaryCID
+	if beneficiaryCID == "" {
+		beneficiaryCID = order.OrderPart3.BeneficiaryCID
+	}
+	if beneficiaryCID == "" {
+		return principalCID, orderPart2, nil
+	}
+	return beneficiaryCID, orderPart2, nil
+}
+
+// orderTombstone returns the tombstone CID of a cancelled order or empty string
+func (s *Service) orderTombstone(reference string) (string, error) {
+	var orderTombstoneCID string
+	switch err := s.Store.Get("orderTombstone", reference, &orderTombstoneCID); err {
+	case nil:
+		return orderTombstoneCID, nil
+	case datastore.ErrKeyNotFound:
+		return "", nil
+	default:
+		return "", err
+	}
+}
+
+// checkOrderNotCancelled returns ErrOrderCancelled if the order was cancelled
+func (s *Service) checkOrderNotCancelled(reference string) error {
+	orderTombstoneCID, err := s.orderTombstone(reference)
+	if err != nil {
+		return err
+	}
+	if orderTombstoneCID != "" {
+		return errors.Wrapf(service.ErrOrderCancelled, "tombstone %s", orderTombstoneCID)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/service"
+	"github.com/pkg/errors"
+)
+
+func TestFulfillOrderConcurrent(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	//Different order parts 1 of the same order
+	order, err := common.CreateNewDepositOrder("", principal.NodeID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	const requests = 8
+	orderPart1CIDs := make([]string, requests)
+	for i := range orderPart1CIDs {
+		order.Timestamp++
+		orderPart1CIDs[i] = writeOrderPart1(t, principal, fiduciary, order)
+	}
+
+	var wg sync.WaitGroup
+	responses := make([]*api.FulfillOrderResponse, requests)
+	errs := make([]error, requests)
+	for i := range orderPart1CIDs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i], errs[i] = fiduciary.FulfillOrder(&api.FulfillOrderRequest{
+				DocumentCID:   principal.NodeID(),
+				OrderPart1CID: orderPart1CIDs[i],
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	fulfilled := -1
+	for i, err := range errs {
+		switch errors.Cause(err) {
+		case nil:
+			if fulfilled >= 0 {
+				t.Fatalf("order fulfilled twice for %s and %s", orderPart1CIDs[fulfilled], orderPart1CIDs[i])
+			}
+			fulfilled = i
+		case service.ErrOrderConflict:
+		default:
+			t.Fatalf("FulfillOrder %v: %v", i, err)
+		}
+	}
+	if fulfilled < 0 {
+		t.Fatal("order not fulfilled")
+	}
+
+	//The repeated request returns the same order part 2
+	response, err := fiduciary.FulfillOrder(&api.FulfillOrderRequest{
+		DocumentCID:   principal.NodeID(),
+		OrderPart1CID: orderPart1CIDs[fulfilled],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.OrderPart2CID != responses[fulfilled].OrderPart2CID {
+		t.Fatalf("invalid repeated order part 2. Expected: %v, found: %v", responses[fulfilled].OrderPart2CID, response.OrderPart2CID)
+	}
+}
+
+func TestFulfillOrderSecretRepeated(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	orderResponse, err := principal.Order(&api.OrderRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secretResponse, err := principal.OrderSecret(&api.OrderSecretRequest{OrderReference: orderResponse.OrderReference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secretResponse.Commitment != orderResponse.Commitment {
+		t.Fatalf("invalid secret commitment. Expected: %v, found: %v", orderResponse.Commitment, secretResponse.Commitment)
+	}
+
+	//The repeated redemption returns the same order part 4
+	rec, err := principal.loadOrderRecord(orderResponse.OrderReference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fo := rec.Fiduciaries[fiduciary.NodeID()]
+	response, err := fiduciary.FulfillOrderSecret(&api.FulfillOrderSecretRequest{
+		SenderDocumentCID: principal.NodeID(),
+		OrderPart3CID:     fo.OrderPart3CID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.OrderPart4CID != fo.OrderPart4CID {
+		t.Fatalf("invalid repeated order part 4. Expected: %v, found: %v", fo.OrderPart4CID, response.OrderPart4CID)
+	}
+}
+
+func TestFulfillOrderInterrupted(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	order, err := common.CreateNewDepositOrder("", principal.NodeID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderPart1CID := writeOrderPart1(t, principal, fiduciary, order)
+
+	//The fiduciary stopped after accepting the envelope
+	if err := fiduciary.saveOrderFulfilment(order.Reference, &orderFulfilment{OrderPart1CID: orderPart1CID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fiduciary.acceptEnvelope(order.Header, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := fiduciary.FulfillOrder(&api.FulfillOrderRequest{
+		DocumentCID:   principal.NodeID(),
+		OrderPart1CID: orderPart1CID,
+	})
+	if err != nil {
+		t.Fatalf("interrupted fulfilment not resumed: %v", err)
+	}
+
+	fulfilment, err := fiduciary.loadOrderFulfilment(order.Reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fulfilment.OrderPart2CID != response.OrderPart2CID {
+		t.Fatalf("invalid order part 2. Expected: %v, found: %v", response.OrderPart2CID, fulfilment.OrderPart2CID)
+	}
+}
+
+func TestFulfillOrderSecretInterrupted(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	orderResponse, err := principal.Order(&api.OrderRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reference := orderResponse.OrderReference
+	if _, err := principal.OrderSecret(&api.OrderSecretRequest{OrderReference: reference}); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := principal.loadOrderRecord(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderPart3CID := rec.Fiduciaries[fiduciary.NodeID()].OrderPart3CID
+
+	//The fiduciary stopped after accepting the envelope of the redemption
+	fulfilment, err := fiduciary.loadOrderFulfilment(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fulfilment.OrderPart3CID = ""
+	fulfilment.OrderPart4CID = ""
+	fulfilment.PendingOrderPart3CID = orderPart3CID
+	if err := fiduciary.saveOrderFulfilment(reference, fulfilment); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := fiduciary.FulfillOrderSecret(&api.FulfillOrderSecretRequest{
+		SenderDocumentCID: principal.NodeID(),
+		OrderPart3CID:     orderPart3CID,
+	})
+	if err != nil {
+		t.Fatalf("interrupted redemption not resumed: %v", err)
+	}
+	if response.OrderPart4CID == "" {
+		t.Fatal("missing order part 4")
+	}
+
+	//A redemption not accepted before is still a replay
+	fulfilment.PendingOrderPart3CID = ""
+	fulfilment.OrderPart3CID = ""
+	if err := fiduciary.saveOrderFulfilment(reference, fulfilment); err != nil {
+		t.Fatal(err)
+	}
+	_, err = fiduciary.FulfillOrderSecret(&api.FulfillOrderSecretRequest{
+		SenderDocumentCID: principal.NodeID(),
+		OrderPart3CID:     orderPart3CID,
+	})
+	if errors.Cause(err) != service.ErrEnvelopeReplay {
+		t.Fatalf("invalid error. Expected: %v, found: %v", service.ErrEnvelopeReplay, err)
+	}
+}
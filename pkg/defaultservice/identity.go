@@ -0,0 +1,96 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+	"github.com/pkg/errors"
+)
+
+// CreateIdentity creates a new identity and writes the IDDocument to IPFS
+// The secret seed is returned to the caller and not kept by the node
+func (s *Service) CreateIdentity(req *api.CreateIdentityRequest) (*api.CreateIdentityResponse, error) {
+	name := req.Name
+
+	_, rawIDDoc, seed, err := identity.CreateIdentity(name)
+	if err != nil {
+		return nil, err
+	}
+
+	idDocumentCID, err := s.Ipfs.Add(rawIDDoc)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to Save Raw Document into IPFS")
+	}
+
+	if err := s.Store.Set("identity", idDocumentCID, name, map[string]string{"time": time.Now().UTC().Format(time.RFC3339)}); err != nil {
+		return nil, errors.Wrap(err, "Save Identity to store")
+	}
+
+	return &api.CreateIdentityResponse{
+		IDDocumentCID: idDocumentCID,
+		Seed:          hex.EncodeToString(seed),
+	}, nil
+}
+
+// GetIdentity retrieves an identity document from IPFS
+func (s *Service) GetIdentity(req *api.GetIdentityRequest) (*api.GetIdentityResponse, error) {
+	idDocumentCID := req.IDDocumentCID
+
+	idDoc, err := common.RetrieveIDDocFromIPFS(s.Ipfs, idDocumentCID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.GetIdentityResponse{
+		IDDocumentCID:           idDocumentCID,
+		AuthenticationReference: idDoc.AuthenticationReference,
+		BeneficiaryECPublicKey:  hex.EncodeToString(idDoc.BeneficiaryECPublicKey),
+		SikePublicKey:           hex.EncodeToString(idDoc.SikePublicKey),
+		BLSPublicKey:            hex.EncodeToString(idDoc.BLSPublicKey),
+		Timestamp:               idDoc.Timestamp,
+	}, nil
+}
+
+// IdentityList retrieves the list of identities created by this node
+func (s *Service) IdentityList(req *api.IdentityListRequest) (*api.IdentityListResponse, error) {
+	q := listQuery(req.Page, req.PerPage, req.SortBy, req.Cursor, req.Count)
+	result, err := s.Store.Query("identity", q)
+	if err != nil {
+		return nil, err
+	}
+
+	idDocumentList := make([]api.GetIdentityResponse, 0, len(result.Keys))
+	for _, idDocumentCID := range result.Keys {
+		idDoc, err := s.GetIdentity(&api.GetIdentityRequest{IDDocumentCID: idDocumentCID})
+		if err != nil {
+			return nil, err
+		}
+		idDocumentList = append(idDocumentList, *idDoc)
+	}
+
+	return &api.IdentityListResponse{
+		IDDocumentList: idDocumentList,
+		Cursor:         result.Cursor,
+		Total:          result.Total,
+	}, nil
+}
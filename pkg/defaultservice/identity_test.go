@@ -0,0 +1,162 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+)
+
+func TestCreateIdentity(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	node := n.node("node")
+
+	nodeSeed, err := node.KeyStore.Get("seed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := node.CreateIdentity(&api.CreateIdentityRequest{Name: "beneficiary"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed, err := hex.DecodeString(response.Seed)
+	if err != nil || len(seed) == 0 {
+		t.Fatalf("invalid seed: %q", response.Seed)
+	}
+
+	//The identity keys are derived from the returned seed
+	idDoc, err := node.GetIdentity(&api.GetIdentityRequest{IDDocumentCID: response.IDDocumentCID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idDoc.IDDocumentCID != response.IDDocumentCID || idDoc.AuthenticationReference != "beneficiary" {
+		t.Fatalf("invalid identity: %v", idDoc)
+	}
+	sikePublicKey, _, err := identity.GenerateSIKEKeys(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blsPublicKey, _, err := identity.GenerateBLSKeys(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPublicKey, err := identity.GenerateECPublicKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idDoc.SikePublicKey != hex.EncodeToString(sikePublicKey) ||
+		idDoc.BLSPublicKey != hex.EncodeToString(blsPublicKey) ||
+		idDoc.BeneficiaryECPublicKey != hex.EncodeToString(ecPublicKey) {
+		t.Fatal("identity keys don't match the seed")
+	}
+
+	//The seed is not kept by the node
+	keptSeed, err := node.KeyStore.Get("seed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(keptSeed, nodeSeed) {
+		t.Fatal("node seed replaced by the new identity")
+	}
+	if _, err := node.KeyStore.Get(response.IDDocumentCID); err == nil {
+		t.Fatal("identity seed kept in the keystore")
+	}
+	var stored string
+	if err := node.Store.Get("identity", response.IDDocumentCID, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored != "beneficiary" {
+		t.Fatalf("invalid stored identity: %q", stored)
+	}
+}
+
+func TestGetIdentityNotFound(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	node := n.node("node")
+
+	if _, err := node.GetIdentity(&api.GetIdentityRequest{IDDocumentCID: "QmNotFound"}); err == nil {
+		t.Fatal("unknown identity returned")
+	}
+}
+
+func TestIdentityList(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	node := n.node("node")
+
+	names := map[string]string{}
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("identity-%v", i)
+		response, err := node.CreateIdentity(&api.CreateIdentityRequest{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		names[response.IDDocumentCID] = name
+	}
+
+	//The identities are listed once, page by page
+	listed := map[string]bool{}
+	req := &api.IdentityListRequest{PerPage: 2, Count: true}
+	pages := 0
+	for {
+		response, err := node.IdentityList(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.Total != len(names) {
+			t.Fatalf("invalid total. Expected: %v, found: %v", len(names), response.Total)
+		}
+		if len(response.IDDocumentList) > req.PerPage {
+			t.Fatalf("invalid page size: %v", len(response.IDDocumentList))
+		}
+		for _, idDoc := range response.IDDocumentList {
+			if listed[idDoc.IDDocumentCID] {
+				t.Fatalf("identity %s listed twice", idDoc.IDDocumentCID)
+			}
+			if idDoc.AuthenticationReference != names[idDoc.IDDocumentCID] {
+				t.Fatalf("invalid identity: %v", idDoc)
+			}
+			listed[idDoc.IDDocumentCID] = true
+		}
+		pages++
+		if response.Cursor == "" {
+			break
+		}
+		req.Cursor = response.Cursor
+	}
+	if len(listed) != len(names) || pages != 3 {
+		t.Fatalf("invalid identities. Expected: %v in 3 pages, found: %v in %v pages", len(names), len(listed), pages)
+	}
+
+	//The page number is used without a cursor
+	response, err := node.IdentityList(&api.IdentityListRequest{Page: 2, PerPage: 2, SortBy: "dateCreatedAsc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.IDDocumentList) != 1 || response.Cursor != "" {
+		t.Fatalf("invalid last page: %v", response)
+	}
+}
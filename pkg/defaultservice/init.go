@@ -20,12 +20,14 @@ package defaultservice
 import (
 	"io"
 
+	"github.com/apache/incubator-milagro-dta/libs/audit"
 	"github.com/apache/incubator-milagro-dta/libs/datastore"
 	"github.com/apache/incubator-milagro-dta/libs/ipfs"
 	"github.com/apache/incubator-milagro-dta/libs/keystore"
 	"github.com/apache/incubator-milagro-dta/libs/logger"
 	"github.com/apache/incubator-milagro-dta/pkg/api"
 	"github.com/apache/incubator-milagro-dta/pkg/config"
+	"github.com/pkg/errors"
 )
 
 // ServiceOption function to set Service properties
@@ -40,6 +42,16 @@ func (s *Service) Init(plugin Plugable, options ...ServiceOption) error {
 			return err
 		}
 	}
+	s.webhookClient = newWebhookClient(s.webhookConfig)
+
+	if s.Store != nil {
+		if err := s.migrateLegacyOrders(); err != nil {
+			return errors.Wrap(err, "migrate legacy orders")
+		}
+		if err := s.indexOrderList(); err != nil {
+			return errors.Wrap(err, "index order list")
+		}
+	}
 
 	return nil
 }
@@ -64,6 +76,8 @@ func WithRng(rng io.Reader) ServiceOption {
 func WithDataStore(store *datastore.Store) ServiceOption {
 	return func(s *Service) error {
 		s.Store = store
+		s.auditLog = audit.NewLog(store)
+		s.orderEvents = newOrderEventLog(store)
 		return nil
 	}
 }
@@ -92,11 +106,32 @@ func WithMasterFiduciary(masterFiduciaryServer api.ClientService) ServiceOption
 	}
 }
 
+// WithFiduciaries adds the fiduciary connectors for k-of-n orders
+// Any threshold of the fiduciaries is required to redeem an order
+func WithFiduciaries(fiduciaries map[string]api.ClientService, threshold int) ServiceOption {
+	return func(s *Service) error {
+		if len(fiduciaries) > 0 && (threshold < 1 || threshold > len(fiduciaries)) {
+			return errors.Errorf("invalid fiduciary threshold %v of %v", threshold, len(fiduciaries))
+		}
+		s.Fiduciaries = fiduciaries
+		s.fiduciaryThreshold = threshold
+		return nil
+	}
+}
+
 // WithConfig adds config settings to the Service
 func WithConfig(cfg *config.Config) ServiceOption {
 	return func(s *Service) error {
 		s.SetNodeID(cfg.Node.NodeID)
 		s.SetMasterFiduciaryNodeID(cfg.Node.MasterFiduciaryNodeID)
+		s.queueConfig = cfg.Node.OrderQueue
+		s.reaperInterval = cfg.Node.OrderReaperInterval
+		s.auditSignInterval = cfg.Node.AuditSignInterval
+		s.webhookConfig = cfg.Node.Webhooks
+		s.operators = cfg.Node.Operators
+		s.envelopeMaxAge = cfg.Node.EnvelopeMaxAge
+		s.orderEventRetention = cfg.Node.OrderEventRetention
+		s.maxBatchSize = cfg.Node.MaxBatchSize
 		return nil
 	}
 }
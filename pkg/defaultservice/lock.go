@@ -0,0 +1,60 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import "sync"
+
+// orderLocks serialises the processing of the same order
+// The check of the order records and their update can't interleave
+// The principal and the fiduciary keep separate locks, a node can be its own fiduciary
+type orderLocks struct {
+	mutex sync.Mutex
+	locks map[string]*orderLock
+}
+
+type orderLock struct {
+	sync.Mutex
+	waiters int
+}
+
+// lock locks the order reference and returns the function to unlock it
+func (l *orderLocks) lock(reference string) (unlock func()) {
+	l.mutex.Lock()
+	if l.locks == nil {
+		l.locks = map[string]*orderLock{}
+	}
+	ol, ok := l.locks[reference]
+	if !ok {
+		ol = &orderLock{}
+		l.locks[reference] = ol
+	}
+	ol.waiters++
+	l.mutex.Unlock()
+
+	ol.Lock()
+	return func() {
+		ol.Unlock()
+
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		//The lock is dropped once nobody waits for it
+		if ol.waiters--; ol.waiters == 0 {
+			delete(l.locks, reference)
+		}
+	}
+}
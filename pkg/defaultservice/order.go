@@ -22,10 +22,12 @@ import (
 	"time"
 
 	"github.com/apache/incubator-milagro-dta/libs/cryptowallet"
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
 	"github.com/apache/incubator-milagro-dta/libs/documents"
 	"github.com/apache/incubator-milagro-dta/pkg/api"
 	"github.com/apache/incubator-milagro-dta/pkg/common"
 	"github.com/apache/incubator-milagro-dta/pkg/identity"
+	"github.com/apache/incubator-milagro-dta/pkg/service"
 	"github.com/pkg/errors"
 )
 
@@ -62,35 +64,114 @@ func (s *Service) GetOrder(req *api.GetOrderRequest) (*api.GetOrderResponse, err
 	if err != nil {
 		return nil, err
 	}
-	return &api.GetOrderResponse{
+
+	response := &api.GetOrderResponse{
 		OrderCID: cid,
 		Order:    string(orderByte),
-	}, nil
+	}
+
+	//Only the principal keeps the order state
+	rec, err := s.loadOrderRecord(orderReference)
+	switch err {
+	case nil:
+		response.State = rec.State
+		response.Commitment = rec.Commitment
+		for fiduciaryCID, fo := range rec.Fiduciaries {
+			if fo.OrderPart2CID == "" {
+				continue
+			}
+			if response.OrderPart2CIDs == nil {
+				response.OrderPart2CIDs = map[string]string{}
+			}
+			response.OrderPart2CIDs[fiduciaryCID] = fo.OrderPart2CID
+		}
+	case datastore.ErrKeyNotFound:
+	default:
+		return nil, err
+	}
+
+	return response, nil
 }
 
-// OrderList retrieves the list of orders
+// OrderList retrieves the list of orders with their metadata
+// The orders are filtered by the indexes of the order records
 func (s *Service) OrderList(req *api.OrderListRequest) (*api.OrderListResponse, error) {
-	page := req.Page
-	perPage := req.PerPage
-	sortBy := req.SortBy
+	q := listQuery(req.Page, req.PerPage, req.SortBy, req.Cursor, req.Count)
+	if req.From > 0 {
+		q.From = orderIndexTime(req.From)
+	}
+	if req.To > 0 {
+		//The range includes the To time
+		q.To = orderIndexTime(req.To + 1)
+	}
+	if req.BeneficiaryCID != "" {
+		q.Where["beneficiary"] = req.BeneficiaryCID
+	}
+	if req.Type != "" {
+		q.Where["type"] = req.Type
+	}
+	if req.Coin != "" {
+		q.Where["coin"] = req.Coin
+	}
+	if req.State != "" {
+		q.Where["state"] = req.State
+	}
+	if req.Role != "" {
+		q.Where["role"] = req.Role
+	}
 
-	orderref, err := s.Store.ListKeys("order", "time", page*perPage, perPage, sortBy != "dateCreatedAsc")
+	result, err := s.Store.Query("orderList", q)
 	if err != nil {
 		return nil, err
 	}
 
-	//Pagnination - Show everything by default
-	start := 0
-	stop := len(orderref)
-
-	if perPage != 0 && page < len(orderref)/perPage && page*perPage < len(orderref) {
-		start = page
-		stop = perPage
-	}
+	response := &api.OrderListResponse{
+		OrderReference: result.Keys,
+		Orders:         []api.OrderListItem{},
+		Cursor:         result.Cursor,
+		Total:          result.Total,
+	}
+	for _, reference := range result.Keys {
+		entry, err := s.loadOrderListEntry(reference)
+		if err != nil {
+			return nil, err
+		}
+
+		var latestCID string
+		if err := s.Store.Get("order", reference, &latestCID); err != nil && err != datastore.ErrKeyNotFound {
+			return nil, err
+		}
+
+		response.Orders = append(response.Orders, api.OrderListItem{
+			OrderReference: entry.Reference,
+			LatestCID:      latestCID,
+			Role:           entry.Role,
+			State:          entry.State,
+			Type:           entry.Type,
+			Coin:           entry.Coin,
+			BeneficiaryCID: entry.BeneficiaryCID,
+			CreatedAt:      entry.CreatedAt,
+			UpdatedAt:      entry.UpdatedAt,
+		})
+	}
+	return response, nil
+}
 
-	return &api.OrderListResponse{
-		OrderReference: orderref[start:stop],
-	}, nil
+// listQuery returns the query of a listing sorted by the time index
+// The cursor continues the previous listing and replaces the page
+func listQuery(page, perPage int, sortBy, cursor string, count bool) *datastore.Query {
+	q := &datastore.Query{
+		Index:   "time",
+		Where:   map[string]string{},
+		Limit:   perPage,
+		Reverse: sortBy != "dateCreatedAsc",
+		Cursor:  cursor,
+		Count:   count,
+	}
+	if cursor == "" {
+		q.Skip = page * perPage
+	}
+	return q
 }
 
 // ValidateOrderRequest returns error if the request values are invalid
@@ -115,78 +196,101 @@ func (s *Service) PrepareOrderResponse(orderPart2 *documents.OrderDoc, reqExtens
 
 // Order -
 func (s *Service) Order(req *api.OrderRequest) (*api.OrderResponse, error) {
-	if err := s.Plugin.ValidateOrderRequest(req); err != nil {
-		return nil, err
-	}
-
-	//Initialise values from Request object
-	beneficiaryIDDocumentCID := req.BeneficiaryIDDocumentCID
-	iDDocID := s.NodeID()
-
-	recipientList, err := common.BuildRecipientList(s.Ipfs, iDDocID, s.MasterFiduciaryNodeID())
+	rec, order, err := s.createOrder(req, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	remoteIDDoc, err := common.RetrieveIDDocFromIPFS(s.Ipfs, s.MasterFiduciaryNodeID())
-	if err != nil {
-		return nil, err
-	}
+	defer s.orderRecordLocks.lock(rec.Reference)()
+	return s.startOrder(rec, order, req.Async)
+}
 
-	//Create Order
-	order, err := common.CreateNewDepositOrder(beneficiaryIDDocumentCID, iDDocID)
-	if err != nil {
-		return nil, err
+// startOrder processes a created order, an async order is queued for the background fulfilment
+func (s *Service) startOrder(rec *orderRecord, order *documents.OrderDoc, async bool) (*api.OrderResponse, error) {
+	if !async {
+		return s.processOrder(rec, order)
 	}
 
-	fulfillExtension, err := s.Plugin.PrepareOrderPart1(order, req.Extension)
-	if err != nil {
-		return nil, err
+	if rec.currentState() == api.OrderStateCreated {
+		if err := s.writeOrderPart1(rec, order); err != nil {
+			return nil, s.failOrder(rec, err)
+		}
 	}
-
-	//Write Order to IPFS
-	orderPart1CID, err := common.WriteOrderToIPFS(iDDocID, s.Ipfs, s.Store, s.KeyStore, iDDocID, order, recipientList)
-	if err != nil {
+	//The fiduciaries are contacted in the background
+	if err := s.queueOrder(rec.Reference); err != nil {
 		return nil, err
 	}
+	return &api.OrderResponse{
+		OrderReference: rec.Reference,
+		State:          rec.State,
+		CreatedAt:      rec.CreatedAt,
+	}, nil
+}
 
-	//Fullfill the order on the remote Server
-	request := &api.FulfillOrderRequest{
-		DocumentCID:   iDDocID,
-		OrderPart1CID: orderPart1CID,
-		Extension:     fulfillExtension,
-	}
-	response, err := s.MasterFiduciaryServer.FulfillOrder(request)
-	if err != nil {
-		return nil, errors.Wrap(err, "Contacting Fiduciary")
-	}
-
-	// SIKE key
-	keyseed, err := s.KeyStore.Get("seed")
-	if err != nil {
-		return nil, err
-	}
-	_, sikeSK, err := identity.GenerateSIKEKeys(keyseed)
-	if err != nil {
-		return nil, err
+// createOrder validates the order request and saves the record of the new order
+// policies caches the policies already checked in a batch, it can be nil
+func (s *Service) createOrder(req *api.OrderRequest, policies map[string]*documents.PolicyDoc) (*orderRecord, *documents.OrderDoc, error) {
+	if err := s.Plugin.ValidateOrderRequest(req); err != nil {
+		return nil, nil, err
 	}
-
-	updatedOrder, err := common.RetrieveOrderFromIPFS(s.Ipfs, response.OrderPart2CID, sikeSK, iDDocID, remoteIDDoc.BLSPublicKey)
-	if err != nil {
-		return nil, errors.Wrap(err, "Fail to retrieve Order from IPFS")
+	if req.Expiry != 0 && req.Expiry <= time.Now().Unix() {
+		return nil, nil, errors.Wrap(service.ErrOrderExpired, "expiry in the past")
 	}
 
-	commitment, extension, err := s.Plugin.PrepareOrderResponse(updatedOrder, req.Extension, response.Extension)
+	//Create Order
+	order, err := common.CreateNewDepositOrder(req.BeneficiaryIDDocumentCID, s.NodeID())
 	if err != nil {
-		return nil, errors.Wrap(err, "Generating Final Public Key")
-	}
-
-	return &api.OrderResponse{
-		OrderReference: order.Reference,
-		Commitment:     commitment,
-		CreatedAt:      time.Now().Unix(),
-		Extension:      extension,
-	}, nil
+		return nil, nil, err
+	}
+
+	rec := &orderRecord{
+		Reference:                order.Reference,
+		State:                    api.OrderStateCreated,
+		Fiduciaries:              map[string]*fiduciaryOrder{},
+		BeneficiaryIDDocumentCID: req.BeneficiaryIDDocumentCID,
+		NotBefore:                req.NotBefore,
+		Expiry:                   req.Expiry,
+		PolicyCID:                req.PolicyCID,
+		Extension:                req.Extension,
+		Subject:                  req.Subject,
+		Type:                     order.Type,
+		CreatedAt:                order.Timestamp,
+	}
+	if s.isThreshold() {
+		rec.Threshold = s.fiduciaryThreshold
+		for _, fiduciaryCID := range s.fiduciaryCIDs() {
+			rec.Fiduciaries[fiduciaryCID] = &fiduciaryOrder{}
+		}
+	} else {
+		rec.Fiduciaries[s.MasterFiduciaryNodeID()] = &fiduciaryOrder{}
+	}
+	//The fiduciaries enforce only the policies they signed
+	if rec.PolicyCID != "" {
+		if rec.Threshold > 0 {
+			return nil, nil, errors.Wrap(service.ErrPolicyViolation, "a policy is enforced by a single fiduciary")
+		}
+		policy, ok := policies[rec.PolicyCID]
+		if !ok {
+			policy, err = s.retrieveOrderPolicy(rec.PolicyCID, s.MasterFiduciaryNodeID())
+			if err != nil {
+				return nil, nil, err
+			}
+			if policies != nil {
+				policies[rec.PolicyCID] = policy
+			}
+		}
+		if rec.BeneficiaryIDDocumentCID != "" {
+			if err := checkPolicyBeneficiary(policy, rec.BeneficiaryIDDocumentCID); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if err := s.saveOrderRecord(rec); err != nil {
+		return nil, nil, err
+	}
+	s.notifyOrderEvent(rec)
+
+	return rec, order, nil
 }
 
 // ProduceBeneficiaryEncryptedData -
@@ -204,89 +308,196 @@ func (s *Service) ProduceFinalSecret(seed, sikeSK []byte, order, orderPart4 *doc
 
 // OrderSecret -
 func (s *Service) OrderSecret(req *api.OrderSecretRequest) (*api.OrderSecretResponse, error) {
-	orderReference := req.OrderReference
-	var orderPart2CID string
-	if err := s.Store.Get("order", orderReference, &orderPart2CID); err != nil {
-		return nil, err
-	}
+	defer s.orderRecordLocks.lock(req.OrderReference)()
 
-	nodeID := s.NodeID()
-	recipientList, err := common.BuildRecipientList(s.Ipfs, nodeID, s.MasterFiduciaryNodeID())
+	rec, err := s.loadOrderRecord(req.OrderReference)
 	if err != nil {
 		return nil, err
 	}
-	remoteIDDoc, err := common.RetrieveIDDocFromIPFS(s.Ipfs, s.MasterFiduciaryNodeID())
-	if err != nil {
+
+	//A new redemption can start once the order is fulfilled
+	switch rec.currentState() {
+	case api.OrderStateFulfilled, api.OrderStateRedemptionRequested, api.OrderStateRedeemed:
+	default:
+		return nil, errors.Wrapf(service.ErrOrderState, "order %s", rec.currentState())
+	}
+	if err := checkOrderWindow(rec.NotBefore, rec.Expiry, time.Now()); err != nil {
 		return nil, err
 	}
 
-	// SIKE and BLS keys
-	keyseed, err := s.KeyStore.Get("seed")
+	fiduciaryCIDs := rec.fiduciaryCIDs()
+	orderPart2, err := s.retrieveFiduciaryOrder(fiduciaryCIDs[0], rec.Fiduciaries[fiduciaryCIDs[0]].OrderPart2CID)
 	if err != nil {
 		return nil, err
 	}
-	_, sikeSK, err := identity.GenerateSIKEKeys(keyseed)
-	if err != nil {
+	if err := s.Plugin.ValidateOrderSecretRequest(req, *orderPart2); err != nil {
 		return nil, err
 	}
-	_, blsSK, err := identity.GenerateBLSKeys(keyseed)
-	if err != nil {
+
+	for _, fo := range rec.Fiduciaries {
+		fo.OrderPart3CID = ""
+		fo.OrderPart4CID = ""
+		fo.FulfillSecretExtension = nil
+	}
+	rec.SecretBeneficiaryIDDocumentCID = req.BeneficiaryIDDocumentCID
+	rec.SecretExtension = req.Extension
+	if err := s.setOrderState(rec, api.OrderStateFulfilled); err != nil {
 		return nil, err
 	}
 
-	//Retrieve the order from IPFS
-	order, err := common.RetrieveOrderFromIPFS(s.Ipfs, orderPart2CID, sikeSK, nodeID, remoteIDDoc.BLSPublicKey)
-	if err != nil {
-		return nil, errors.Wrap(err, "Fail to retrieve Order from IPFS")
+	if err := s.requestRedemption(rec, req); err != nil {
+		return nil, s.failOrder(rec, err)
 	}
 
-	// TODO: Split Beneficiary and Principal
-	beneficiariesSeed := keyseed
-	beneficiariesSikeSK := sikeSK
+	return s.processOrderSecret(rec)
+}
 
-	if err := s.Plugin.ValidateOrderSecretRequest(req, *order); err != nil {
+// ResumeOrder continues a pending or failed order from the last completed step
+func (s *Service) ResumeOrder(req *api.ResumeOrderRequest) (*api.ResumeOrderResponse, error) {
+	defer s.orderRecordLocks.lock(req.OrderReference)()
+
+	rec, err := s.loadOrderRecord(req.OrderReference)
+	if err != nil {
 		return nil, err
 	}
 
-	//Create a piece of data that is destined for the beneficiary, passed via the Master Fiduciary
+	switch rec.currentState() {
+	case api.OrderStateCreated, api.OrderStatePart1Written, api.OrderStateFulfilled:
+		response, err := s.processOrder(rec, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &api.ResumeOrderResponse{
+			OrderReference: rec.Reference,
+			State:          rec.State,
+			Commitment:     response.Commitment,
+			Extension:      response.Extension,
+		}, nil
+	default:
+		response, err := s.processOrderSecret(rec)
+		if err != nil {
+			return nil, err
+		}
+		return &api.ResumeOrderResponse{
+			OrderReference: rec.Reference,
+			State:          rec.State,
+			Commitment:     response.Commitment,
+			Secret:         response.Secret,
+			BeneficiaryCID: response.BeneficiaryCID,
+			OrderPart4CIDs: response.OrderPart4CIDs,
+			Extension:      response.Extension,
+		}, nil
+	}
+}
+
+// CancelOrder abandons the order and asks the fiduciaries to destroy the order secret
+func (s *Service) CancelOrder(req *api.CancelOrderRequest) (*api.CancelOrderResponse, error) {
+	defer s.orderRecordLocks.lock(req.OrderReference)()
 
-	beneficiaryEncryptedData, extension, err := s.Plugin.ProduceBeneficiaryEncryptedData(blsSK, order, req)
+	rec, err := s.loadOrderRecord(req.OrderReference)
 	if err != nil {
 		return nil, err
 	}
+	if rec.State == api.OrderStateCancelled {
+		return nil, errors.Wrapf(service.ErrOrderState, "order %s", rec.State)
+	}
 
-	//Create a request Object in IPFS
-	orderPart3CID, err := common.CreateAndStorePart3(s.Ipfs, s.Store, s.KeyStore, order, orderPart2CID, nodeID, beneficiaryEncryptedData, recipientList)
-	if err != nil {
+	//Stop the background fulfilment
+	if err := s.ordersQueue().Done(rec.Reference); err != nil {
 		return nil, err
 	}
 
-	//Post the address of the updated doc to the custody node
-	request := &api.FulfillOrderSecretRequest{
-		SenderDocumentCID: nodeID,
-		OrderPart3CID:     orderPart3CID,
-		Extension:         extension,
-	}
-	response, err := s.MasterFiduciaryServer.FulfillOrderSecret(request)
-	if err != nil {
+	orderTombstoneCIDs := []string{}
+	for _, fiduciaryCID := range rec.fiduciaryCIDs() {
+		fo := rec.Fiduciaries[fiduciaryCID]
+		//A fiduciary without an order part 1 was never contacted
+		//The others may have fulfilled the order without the response reaching the principal
+		if fo.OrderPart1CID == "" {
+			continue
+		}
+
+		if fo.OrderTombstoneCID == "" {
+			if err := s.cancelFiduciaryOrder(fiduciaryCID, fo, req.Reason); err != nil {
+				//Keep the cancelled fiduciaries, the cancel can be repeated
+				if serr := s.saveOrderRecord(rec); serr != nil {
+					s.Logger.Error("Order %s: %v", rec.Reference, serr)
+				}
+				return nil, err
+			}
+		}
+		orderTombstoneCIDs = append(orderTombstoneCIDs, fo.OrderTombstoneCID)
+	}
+
+	if err := s.setOrderState(rec, api.OrderStateCancelled); err != nil {
 		return nil, err
 	}
 
-	//Retrieve the response Order from IPFS
-	orderPart4, err := common.RetrieveOrderFromIPFS(s.Ipfs, response.OrderPart4CID, sikeSK, nodeID, remoteIDDoc.BLSPublicKey)
+	return &api.CancelOrderResponse{
+		OrderReference:     rec.Reference,
+		State:              rec.State,
+		OrderTombstoneCIDs: orderTombstoneCIDs,
+	}, nil
+}
+
+// cancelFiduciaryOrder sends the signed cancel request to a fiduciary
+func (s *Service) cancelFiduciaryOrder(fiduciaryCID string, fo *fiduciaryOrder, reason string) error {
+	nodeID := s.NodeID()
+
+	fiduciary, err := s.fiduciaryServer(fiduciaryCID)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	if fo.OrderCancelCID == "" {
+		//The cancel request follows the order part 2 of the fiduciary or the order part 1 sent to it
+		//The order part 4 may be readable by the beneficiary only
+		previousOrderCID := fo.OrderPart2CID
+		if previousOrderCID == "" {
+			previousOrderCID = fo.OrderPart1CID
+		}
+
+		// SIKE key
+		keyseed, err := s.KeyStore.Get("seed")
+		if err != nil {
+			return err
+		}
+		_, sikeSK, err := identity.GenerateSIKEKeys(keyseed)
+		if err != nil {
+			return err
+		}
+		order, _, err := common.RetrieveSignedOrderFromIPFS(s.Ipfs, previousOrderCID, sikeSK, nodeID)
+		if err != nil {
+			return err
+		}
+
+		recipientList, err := common.BuildRecipientList(s.Ipfs, nodeID, fiduciaryCID)
+		if err != nil {
+			return err
+		}
+		fo.OrderCancelCID, err = common.CreateAndStoreOrderCancel(s.Ipfs, s.Store, s.KeyStore, order, previousOrderCID, reason, nodeID, recipientList)
+		if err != nil {
+			return err
+		}
+	}
+
+	request := &api.FulfillOrderCancelRequest{
+		SenderDocumentCID: nodeID,
+		OrderCancelCID:    fo.OrderCancelCID,
+	}
+	response, err := fiduciary.FulfillOrderCancel(request)
+	if err != nil {
+		return errors.Wrapf(err, "Contacting Fiduciary %s", fiduciaryCID)
 	}
 
-	finalPrivateKey, finalPublicKey, ext, err := s.Plugin.ProduceFinalSecret(beneficiariesSeed, beneficiariesSikeSK, order, orderPart4, req, response)
+	//Check the tombstone is signed by the fiduciary
+	orderTombstone, err := s.retrieveFiduciaryOrder(fiduciaryCID, response.OrderTombstoneCID)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if orderTombstone.OrderTombstone == nil || orderTombstone.OrderTombstone.PreviousOrderCID != fo.OrderCancelCID {
+		return errors.Errorf("Invalid tombstone from Fiduciary %s", fiduciaryCID)
 	}
 
-	return &api.OrderSecretResponse{
-		Secret:         finalPrivateKey,
-		Commitment:     finalPublicKey,
-		OrderReference: order.Reference,
-		Extension:      ext,
-	}, nil
+	fo.OrderTombstoneCID = response.OrderTombstoneCID
+	return nil
 }
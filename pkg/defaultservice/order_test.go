@@ -0,0 +1,208 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/service"
+	"github.com/pkg/errors"
+)
+
+func TestCancelOrder(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciaries := []*Service{n.node("fiduciary1"), n.node("fiduciary2"), n.node("fiduciary3")}
+	principal := n.node("principal", withFiduciaries(2, fiduciaries...))
+
+	orderResponse, err := principal.Order(&api.OrderRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reference := orderResponse.OrderReference
+
+	//The response of the first fiduciary didn't reach the principal
+	//The second fiduciary never fulfilled the order
+	rec, err := principal.loadOrderRecord(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Fiduciaries[fiduciaries[0].NodeID()].OrderPart2CID = ""
+	rec.Fiduciaries[fiduciaries[1].NodeID()].OrderPart2CID = ""
+	if err := principal.saveOrderRecord(rec); err != nil {
+		t.Fatal(err)
+	}
+	fiduciaries[1].deleteOrderFulfilment(reference)
+
+	cancelResponse, err := principal.CancelOrder(&api.CancelOrderRequest{OrderReference: reference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelResponse.State != api.OrderStateCancelled || len(cancelResponse.OrderTombstoneCIDs) != len(fiduciaries) {
+		t.Fatalf("invalid cancel response: %v", cancelResponse)
+	}
+
+	//No fiduciary fulfils the cancelled order
+	for _, fiduciary := range fiduciaries {
+		_, err := fiduciary.FulfillOrder(&api.FulfillOrderRequest{
+			DocumentCID:   principal.NodeID(),
+			OrderPart1CID: rec.Fiduciaries[fiduciary.NodeID()].OrderPart1CID,
+		})
+		if errors.Cause(err) != service.ErrOrderCancelled {
+			t.Fatalf("invalid error. Expected: %v, found: %v", service.ErrOrderCancelled, err)
+		}
+	}
+}
+
+func TestCancelOrderBeneficiary(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	beneficiary := n.node("beneficiary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	orderResponse, err := principal.Order(&api.OrderRequest{BeneficiaryIDDocumentCID: beneficiary.NodeID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := principal.OrderSecret(&api.OrderSecretRequest{OrderReference: orderResponse.OrderReference}); err != nil {
+		t.Fatal(err)
+	}
+
+	//The order part 4 is readable by the beneficiary only
+	if _, err := principal.CancelOrder(&api.CancelOrderRequest{OrderReference: orderResponse.OrderReference}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCancelOrderOtherPrincipal(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	rogue := n.node("rogue")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	orderResponse, err := principal.Order(&api.OrderRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reference := orderResponse.OrderReference
+
+	//The rogue node writes its own order with the reference of the principal order
+	order, err := common.CreateNewDepositOrder("", rogue.NodeID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	order.Reference = reference
+	orderPart1CID := writeOrderPart1(t, rogue, fiduciary, order)
+	recipientList, err := common.BuildRecipientList(rogue.Ipfs, rogue.NodeID(), fiduciary.NodeID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderCancelCID, err := common.CreateAndStoreOrderCancel(rogue.Ipfs, rogue.Store, rogue.KeyStore, order, orderPart1CID, "", rogue.NodeID(), recipientList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fiduciary.FulfillOrderCancel(&api.FulfillOrderCancelRequest{
+		SenderDocumentCID: rogue.NodeID(),
+		OrderCancelCID:    orderCancelCID,
+	}); err == nil {
+		t.Fatal("order cancelled by another principal")
+	}
+
+	//The order secret is kept
+	if err := fiduciary.checkOrderNotCancelled(reference); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := principal.OrderSecret(&api.OrderSecretRequest{OrderReference: reference}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOrderList(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	states := map[string]string{}
+	for i := 0; i < 3; i++ {
+		orderResponse, err := principal.Order(&api.OrderRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		states[orderResponse.OrderReference] = api.OrderStateFulfilled
+		if i == 0 {
+			if _, err := principal.OrderSecret(&api.OrderSecretRequest{OrderReference: orderResponse.OrderReference}); err != nil {
+				t.Fatal(err)
+			}
+			states[orderResponse.OrderReference] = api.OrderStateRedeemed
+		}
+	}
+
+	//The principal lists its orders and the fiduciary the orders it fulfilled, page by page
+	checkOrderList := func(node *Service, role string) {
+		t.Helper()
+
+		listed := map[string]bool{}
+		req := &api.OrderListRequest{PerPage: 2, Count: true}
+		for {
+			response, err := node.OrderList(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.Total != len(states) {
+				t.Fatalf("invalid %s total. Expected: %v, found: %v", role, len(states), response.Total)
+			}
+			for _, item := range response.Orders {
+				if listed[item.OrderReference] {
+					t.Fatalf("%s order %s listed twice", role, item.OrderReference)
+				}
+				if item.Role != role || item.State != states[item.OrderReference] {
+					t.Fatalf("invalid %s order: %v", role, item)
+				}
+				listed[item.OrderReference] = true
+			}
+			if response.Cursor == "" {
+				break
+			}
+			req.Cursor = response.Cursor
+		}
+		if len(listed) != len(states) {
+			t.Fatalf("invalid %s orders. Expected: %v, found: %v", role, len(states), len(listed))
+		}
+	}
+	checkOrderList(principal, orderRolePrincipal)
+	checkOrderList(fiduciary, orderRoleFiduciary)
+
+	//The orders kept before the listing are indexed once
+	for reference := range states {
+		if err := fiduciary.Store.Del("orderList", reference); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fiduciary.Store.Del("orderListIndex", "indexed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fiduciary.indexOrderList(); err != nil {
+		t.Fatal(err)
+	}
+	checkOrderList(fiduciary, orderRoleFiduciary)
+}
@@ -0,0 +1,218 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/libs/documents"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+	"github.com/pkg/errors"
+)
+
+// Roles of the node in a listed order
+const (
+	orderRolePrincipal = "principal"
+	orderRoleFiduciary = "fiduciary"
+)
+
+// orderListEntry is the listing metadata of an order of the node as principal or fiduciary
+// The orders are sorted by their creation time, it never changes so the list cursors stay valid
+type orderListEntry struct {
+	Reference      string
+	Role           string
+	State          string
+	Type           string
+	Coin           int64
+	BeneficiaryCID string
+	CreatedAt      int64
+	UpdatedAt      int64
+}
+
+// indexes returns the store indexes of the order listing
+func (e *orderListEntry) indexes() map[string]string {
+	indexes := map[string]string{
+		"time":  orderIndexTime(e.CreatedAt),
+		"role":  e.Role,
+		"state": e.State,
+		"type":  e.Type,
+		"coin":  strconv.FormatInt(e.Coin, 10),
+	}
+	if e.BeneficiaryCID != "" {
+		indexes["beneficiary"] = e.BeneficiaryCID
+	}
+	return indexes
+}
+
+// orderIndexTime returns the sortable time index value of Unix time
+func orderIndexTime(t int64) string {
+	return time.Unix(t, 0).UTC().Format(time.RFC3339)
+}
+
+// listEntry returns the listing metadata of the order of the principal
+func (r *orderRecord) listEntry() *orderListEntry {
+	return &orderListEntry{
+		Reference:      r.Reference,
+		Role:           orderRolePrincipal,
+		State:          r.State,
+		Type:           r.Type,
+		Coin:           r.Coin,
+		BeneficiaryCID: r.beneficiaryCID(),
+		CreatedAt:      r.CreatedAt,
+		UpdatedAt:      r.UpdatedAt,
+	}
+}
+
+func (s *Service) loadOrderListEntry(reference string) (*orderListEntry, error) {
+	entry := &orderListEntry{}
+	if err := s.Store.Get("orderList", reference, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *Service) saveOrderListEntry(entry *orderListEntry) error {
+	if err := s.Store.Set("orderList", entry.Reference, entry, entry.indexes()); err != nil {
+		return errors.Wrap(err, "Save Order list")
+	}
+	return nil
+}
+
+// listFiduciaryOrder lists the order fulfilled by the node
+// The state of the principal is listed when the node is also the principal of the order
+// The listing doesn't fail the fulfilment
+func (s *Service) listFiduciaryOrder(order *documents.OrderDoc, state string) {
+	switch _, err := s.loadOrderListEntry(order.Reference); err {
+	case nil:
+		return
+	case datastore.ErrKeyNotFound:
+	default:
+		s.Logger.Error("Order list %s: %v", order.Reference, err)
+		return
+	}
+
+	entry := &orderListEntry{
+		Reference:      order.Reference,
+		Role:           orderRoleFiduciary,
+		State:          state,
+		Type:           order.Type,
+		Coin:           order.Coin,
+		BeneficiaryCID: order.BeneficiaryCID,
+		CreatedAt:      order.Timestamp,
+		UpdatedAt:      time.Now().Unix(),
+	}
+	if err := s.saveOrderListEntry(entry); err != nil {
+		s.Logger.Error("Order list %s: %v", order.Reference, err)
+	}
+}
+
+// setFiduciaryOrderState updates the listed state of an order fulfilled by the node
+func (s *Service) setFiduciaryOrderState(reference, state string) {
+	entry, err := s.loadOrderListEntry(reference)
+	switch err {
+	case nil:
+	case datastore.ErrKeyNotFound:
+		return
+	default:
+		s.Logger.Error("Order list %s: %v", reference, err)
+		return
+	}
+	if entry.Role != orderRoleFiduciary {
+		return
+	}
+
+	entry.State = state
+	entry.UpdatedAt = time.Now().Unix()
+	if err := s.saveOrderListEntry(entry); err != nil {
+		s.Logger.Error("Order list %s: %v", reference, err)
+	}
+}
+
+// indexOrderList lists the orders of the principal and the fiduciary kept before the listing
+// It runs once, the orderListIndex marker is set when it's done
+func (s *Service) indexOrderList() error {
+	var indexed bool
+	switch err := s.Store.Get("orderListIndex", "indexed", &indexed); err {
+	case nil:
+		return nil
+	case datastore.ErrKeyNotFound:
+	default:
+		return err
+	}
+
+	//The node writes an order document for every order it's principal or fiduciary of
+	references, err := s.Store.ListKeys("order", "time", 0, 0, false)
+	if err != nil {
+		return err
+	}
+	var sikeSK []byte
+	for _, reference := range references {
+		rec, err := s.loadOrderRecord(reference)
+		switch err {
+		case nil:
+			if err := s.saveOrderListEntry(rec.listEntry()); err != nil {
+				return err
+			}
+			continue
+		case datastore.ErrKeyNotFound:
+		default:
+			return err
+		}
+
+		fulfilment, err := s.loadOrderFulfilment(reference)
+		if err != nil {
+			return err
+		}
+		if fulfilment == nil || fulfilment.OrderPart2CID == "" {
+			continue
+		}
+		if sikeSK == nil {
+			keyseed, err := s.KeyStore.Get("seed")
+			if err != nil {
+				return err
+			}
+			if _, sikeSK, err = identity.GenerateSIKEKeys(keyseed); err != nil {
+				return err
+			}
+		}
+		//The order documents may be unavailable, the other orders are listed
+		order, _, err := common.RetrieveSignedOrderFromIPFS(s.Ipfs, fulfilment.OrderPart2CID, sikeSK, s.NodeID())
+		if err != nil {
+			s.Logger.Error("Order list %s: %v", reference, err)
+			continue
+		}
+		s.listFiduciaryOrder(order, api.OrderStateFulfilled)
+
+		orderTombstoneCID, err := s.orderTombstone(reference)
+		if err != nil {
+			return err
+		}
+		switch {
+		case orderTombstoneCID != "":
+			s.setFiduciaryOrderState(reference, api.OrderStateCancelled)
+		case fulfilment.OrderPart4CID != "":
+			s.setFiduciaryOrderState(reference, api.OrderStateRedeemed)
+		}
+	}
+
+	return s.Store.Set("orderListIndex", "indexed", true, nil)
+}
@@ -0,0 +1,845 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"sort"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/libs/documents"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+	"github.com/apache/incubator-milagro-dta/pkg/service"
+	"github.com/pkg/errors"
+)
+
+// fiduciaryOrder is the order chain with a single fiduciary
+type fiduciaryOrder struct {
+	OrderPart1CID string
+	OrderPart2CID string
+	OrderPart3CID string
+	OrderPart4CID string
+	// Cancellation of the order
+	OrderCancelCID    string
+	OrderTombstoneCID string
+	// ShareDeals maps the fiduciary node CID to the share of a k-of-n secret dealt to it
+	// Only the first fiduciary of the order deals the shares
+	ShareDeals map[string]string
+	// Extensions returned by the fiduciary
+	FulfillExtension       map[string]string
+	FulfillSecretExtension map[string]string
+}
+
+// orderRecord is the persisted processing state of an order
+// It holds everything needed to resume the order from the last completed step
+type orderRecord struct {
+	Reference string
+	State     string
+	// LastState is the last completed state of a failed order
+	LastState string
+	Error     string
+	// Threshold is the number of fiduciaries required to redeem a k-of-n order
+	Threshold           int
+	CommitmentPublicKey string
+	// ShareCommitments are the BLS public keys of the fiduciary shares of a k-of-n order
+	ShareCommitments [][]byte
+	// Commitment is the order response commitment once fulfilled
+	Commitment string
+	// Fiduciaries maps the fiduciary node CID to the order chain
+	Fiduciaries map[string]*fiduciaryOrder
+	// Order request
+	BeneficiaryIDDocumentCID string
+	NotBefore                int64
+	Expiry                   int64
+	PolicyCID                string
+	Extension                map[string]string
+	// Subject is the OIDC subject of the order creator
+	Subject string
+	// Type and Coin of the order document
+	Type             string
+	Coin             int64
+	FulfillExtension map[string]string
+	// Order secret request
+	SecretBeneficiaryIDDocumentCID string
+	SecretExtension                map[string]string
+	FulfillSecretExtension         map[string]string
+	CreatedAt                      int64
+	UpdatedAt                      int64
+}
+
+// currentState returns the last completed state of the order
+func (r *orderRecord) currentState() string {
+	if r.State == api.OrderStateFailed {
+		return r.LastState
+	}
+	return r.State
+}
+
+// fiduciaryCIDs returns the fiduciary node CIDs in a stable order
+func (r *orderRecord) fiduciaryCIDs() []string {
+	fiduciaryCIDs := make([]string, 0, len(r.Fiduciaries))
+	for fiduciaryCID := range r.Fiduciaries {
+		fiduciaryCIDs = append(fiduciaryCIDs, fiduciaryCID)
+	}
+	sort.Strings(fiduciaryCIDs)
+	return fiduciaryCIDs
+}
+
+// threshold returns the number of fiduciary responses required
+func (r *orderRecord) threshold() int {
+	if r.Threshold > 0 {
+		return r.Threshold
+	}
+	return 1
+}
+
+// redeemingBeneficiaryCID returns the beneficiary node that produces the order secret
+// It is empty when the principal is the beneficiary
+func (r *orderRecord) redeemingBeneficiaryCID(principalCID string) string {
+	beneficiaryCID := r.BeneficiaryIDDocumentCID
+	if beneficiaryCID == "" {
+		beneficiaryCID = r.SecretBeneficiaryIDDocumentCID
+	}
+	if beneficiaryCID == principalCID {
+		return ""
+	}
+	return beneficiaryCID
+}
+
+// beneficiaryCID returns the beneficiary of the order or of the redemption
+func (r *orderRecord) beneficiaryCID() string {
+	if r.BeneficiaryIDDocumentCID != "" {
+		return r.BeneficiaryIDDocumentCID
+	}
+	return r.SecretBeneficiaryIDDocumentCID
+}
+
+// orderPart4CIDs returns the order parts 4 required to produce the order secret
+func (r *orderRecord) orderPart4CIDs() []string {
+	orderPart4CIDs := []string{}
+	for _, fiduciaryCID := range r.fiduciaryCIDs() {
+		if len(orderPart4CIDs) == r.threshold() {
+			break
+		}
+		if fo := r.Fiduciaries[fiduciaryCID]; fo.OrderPart4CID != "" {
+			orderPart4CIDs = append(orderPart4CIDs, fo.OrderPart4CID)
+		}
+	}
+	return orderPart4CIDs
+}
+
+// orderSecretRequest rebuilds the order secret request of the redemption in progress
+func (r *orderRecord) orderSecretRequest() *api.OrderSecretRequest {
+	return &api.OrderSecretRequest{
+		OrderReference:           r.Reference,
+		BeneficiaryIDDocumentCID: r.SecretBeneficiaryIDDocumentCID,
+		Extension:                r.SecretExtension,
+	}
+}
+
+func (s *Service) saveOrderRecord(rec *orderRecord) error {
+	rec.UpdatedAt = time.Now().Unix()
+	if err := s.Store.Set("orderState", rec.Reference, rec, nil); err != nil {
+		return errors.Wrap(err, "Save Order state")
+	}
+	return s.saveOrderListEntry(rec.listEntry())
+}
+
+// migrateLegacyOrders creates the order state of the orders written before it was kept
+// The last order document of the legacy order store gives the completed steps
+// It runs once, the orderStateIndex marker is set when it's done
+func (s *Service) migrateLegacyOrders() error {
+	var migrated bool
+	switch err := s.Store.Get("orderStateIndex", "migrated", &migrated); err {
+	case nil:
+		return nil
+	case datastore.ErrKeyNotFound:
+	default:
+		return err
+	}
+
+	references, err := s.Store.ListKeys("order", "time", 0, 0, false)
+	if err != nil {
+		return err
+	}
+	if len(references) > 0 {
+		keyseed, err := s.KeyStore.Get("seed")
+		if err != nil {
+			return err
+		}
+		_, sikeSK, err := identity.GenerateSIKEKeys(keyseed)
+		if err != nil {
+			return err
+		}
+		for _, reference := range references {
+			//The order documents may be unavailable, the other orders are migrated
+			if err := s.migrateLegacyOrder(reference, sikeSK); err != nil {
+				s.Logger.Error("Migrate order %s: %v", reference, err)
+			}
+		}
+	}
+
+	return s.Store.Set("orderStateIndex", "migrated", true, nil)
+}
+
+// migrateLegacyOrder creates the order state of the principal and the fulfilment of the fiduciary
+func (s *Service) migrateLegacyOrder(reference string, sikeSK []byte) error {
+	nodeID := s.NodeID()
+
+	//The orders written since keep the state of the principal or the fulfilment of the fiduciary
+	switch _, err := s.loadOrderRecord(reference); err {
+	case nil:
+		return nil
+	case datastore.ErrKeyNotFound:
+	default:
+		return err
+	}
+	fulfilment, err := s.loadOrderFulfilment(reference)
+	if err != nil || fulfilment != nil {
+		return err
+	}
+
+	var cid string
+	if err := s.Store.Get("order", reference, &cid); err != nil {
+		return err
+	}
+	order, signerCID, err := common.RetrieveSignedOrderFromIPFS(s.Ipfs, cid, sikeSK, nodeID)
+	if err != nil {
+		return err
+	}
+	if signerCID != nodeID {
+		return errors.Errorf("order document %s not signed by the node", cid)
+	}
+
+	//Every order part copies the previous ones
+	fo := &fiduciaryOrder{}
+	state := api.OrderStatePart1Written
+	switch {
+	case order.OrderPart4 != nil && order.OrderPart3 != nil:
+		fo.OrderPart4CID = cid
+		fo.OrderPart3CID = order.OrderPart4.PreviousOrderCID
+		fo.OrderPart2CID = order.OrderPart3.PreviousOrderCID
+		state = api.OrderStateRedeemed
+	case order.OrderPart3 != nil:
+		fo.OrderPart3CID = cid
+		fo.OrderPart2CID = order.OrderPart3.PreviousOrderCID
+		state = api.OrderStateRedemptionRequested
+	case order.OrderPart2 != nil:
+		fo.OrderPart2CID = cid
+		state = api.OrderStateFulfilled
+	default:
+		fo.OrderPart1CID = cid
+	}
+
+	//The legacy orders have a single fiduciary
+	fiduciaryCID := s.MasterFiduciaryNodeID()
+	if fo.OrderPart2CID != "" {
+		fo.OrderPart1CID = order.OrderPart2.PreviousOrderCID
+		rawDoc, err := s.Ipfs.Get(fo.OrderPart2CID)
+		if err != nil {
+			return err
+		}
+		if fiduciaryCID, err = documents.DecodeSignerCID(rawDoc); err != nil {
+			return err
+		}
+	}
+
+	//A repeated request of the principal returns the fiduciary order parts
+	if fiduciaryCID == nodeID && fo.OrderPart2CID != "" {
+		fulfilment = &orderFulfilment{
+			OrderPart1CID: fo.OrderPart1CID,
+			OrderPart2CID: fo.OrderPart2CID,
+		}
+		if fo.OrderPart4CID != "" {
+			fulfilment.OrderPart3CID = fo.OrderPart3CID
+			fulfilment.OrderPart4CID = fo.OrderPart4CID
+		}
+		if err := s.saveOrderFulfilment(reference, fulfilment); err != nil {
+			return err
+		}
+	}
+
+	if order.PrincipalCID != nodeID {
+		return nil
+	}
+	rec := &orderRecord{
+		Reference:                reference,
+		State:                    state,
+		Fiduciaries:              map[string]*fiduciaryOrder{fiduciaryCID: fo},
+		BeneficiaryIDDocumentCID: order.BeneficiaryCID,
+		Type:                     order.Type,
+		Coin:                     order.Coin,
+		CreatedAt:                order.Timestamp,
+	}
+	if order.OrderPart2 != nil {
+		rec.CommitmentPublicKey = order.OrderPart2.CommitmentPublicKey
+		if rec.Commitment, _, err = s.Plugin.PrepareOrderResponse(order, nil, nil); err != nil {
+			return err
+		}
+	}
+	return s.saveOrderRecord(rec)
+}
+
+func (s *Service) loadOrderRecord(orderReference string) (*orderRecord, error) {
+	rec := &orderRecord{}
+	if err := s.Store.Get("orderState", orderReference, rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// orderTransitions lists the states an order moves to from its last completed state
+// A cancelled order doesn't move on
+var orderTransitions = map[string][]string{
+	api.OrderStateCreated:             {api.OrderStatePart1Written, api.OrderStateCancelled},
+	api.OrderStatePart1Written:        {api.OrderStateFulfilled, api.OrderStateCancelled},
+	api.OrderStateFulfilled:           {api.OrderStateFulfilled, api.OrderStateRedemptionRequested, api.OrderStateCancelled},
+	api.OrderStateRedemptionRequested: {api.OrderStateFulfilled, api.OrderStateRedeemed, api.OrderStateCancelled},
+	api.OrderStateRedeemed:            {api.OrderStateFulfilled, api.OrderStateCancelled},
+}
+
+// checkOrderTransition checks the stored order can move to the state
+func (s *Service) checkOrderTransition(reference, state string) error {
+	stored, err := s.loadOrderRecord(reference)
+	if err != nil {
+		return err
+	}
+	for _, next := range orderTransitions[stored.currentState()] {
+		if next == state {
+			return nil
+		}
+	}
+	return errors.Wrapf(service.ErrOrderState, "order %s, not %s", stored.State, state)
+}
+
+// setOrderState moves the order to the next state
+func (s *Service) setOrderState(rec *orderRecord, state string) error {
+	if err := s.checkOrderTransition(rec.Reference, state); err != nil {
+		return err
+	}
+	rec.State = state
+	rec.LastState = ""
+	rec.Error = ""
+	if err := s.saveOrderRecord(rec); err != nil {
+		return err
+	}
+	s.notifyOrderEvent(rec)
+	return nil
+}
+
+// failOrder marks the order as failed keeping the last completed state
+func (s *Service) failOrder(rec *orderRecord, err error) error {
+	//A cancelled order stays cancelled
+	if stored, serr := s.loadOrderRecord(rec.Reference); serr == nil && stored.State == api.OrderStateCancelled {
+		return err
+	}
+	if rec.State != api.OrderStateFailed {
+		rec.LastState = rec.State
+	}
+	rec.State = api.OrderStateFailed
+	rec.Error = err.Error()
+	if serr := s.saveOrderRecord(rec); serr != nil {
+		s.Logger.Error("Order %s: %v", rec.Reference, serr)
+	}
+	s.notifyOrderEvent(rec)
+	return err
+}
+
+// fiduciaryServer returns the connector to a fiduciary of the order
+func (s *Service) fiduciaryServer(fiduciaryCID string) (api.ClientService, error) {
+	if fiduciary, ok := s.Fiduciaries[fiduciaryCID]; ok {
+		return fiduciary, nil
+	}
+	if fiduciaryCID == s.MasterFiduciaryNodeID() && s.MasterFiduciaryServer != nil {
+		return s.MasterFiduciaryServer, nil
+	}
+	return nil, errors.Errorf("Fiduciary %s not configured", fiduciaryCID)
+}
+
+// retrieveFiduciaryOrder retrieves an order document written by a fiduciary
+func (s *Service) retrieveFiduciaryOrder(fiduciaryCID, orderCID string) (*documents.OrderDoc, error) {
+	fiduciaryIDDoc, err := common.RetrieveIDDocFromIPFS(s.Ipfs, fiduciaryCID)
+	if err != nil {
+		return nil, err
+	}
+
+	// SIKE key
+	keyseed, err := s.KeyStore.Get("seed")
+	if err != nil {
+		return nil, err
+	}
+	_, sikeSK, err := identity.GenerateSIKEKeys(keyseed)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := common.RetrieveOrderFromIPFS(s.Ipfs, orderCID, sikeSK, s.NodeID(), fiduciaryIDDoc.BLSPublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "Fail to retrieve Order from IPFS")
+	}
+	return order, nil
+}
+
+// processOrder runs the order steps from the last completed state until the order is fulfilled
+// order is nil when resuming
+func (s *Service) processOrder(rec *orderRecord, order *documents.OrderDoc) (*api.OrderResponse, error) {
+	if rec.currentState() == api.OrderStateCreated {
+		if err := s.writeOrderPart1(rec, order); err != nil {
+			return nil, s.failOrder(rec, err)
+		}
+	}
+
+	if rec.currentState() == api.OrderStatePart1Written {
+		if err := s.fulfillOrderPart1(rec); err != nil {
+			return nil, s.failOrder(rec, err)
+		}
+	}
+
+	if rec.currentState() != api.OrderStateFulfilled {
+		return nil, service.ErrOrderState
+	}
+
+	response, err := s.orderResponse(rec)
+	if err != nil {
+		return nil, s.failOrder(rec, err)
+	}
+	return response, nil
+}
+
+// writeOrderPart1 writes the order part 1 for each fiduciary
+func (s *Service) writeOrderPart1(rec *orderRecord, order *documents.OrderDoc) error {
+	nodeID := s.NodeID()
+
+	if order == nil {
+		//The order was never written, create it again with the same reference
+		var err error
+		order, err = common.CreateNewDepositOrder(rec.BeneficiaryIDDocumentCID, nodeID)
+		if err != nil {
+			return err
+		}
+		order.Reference = rec.Reference
+	}
+
+	fulfillExtension, err := s.prepareOrderPart1(rec, order)
+	if err != nil {
+		return err
+	}
+
+	if rec.Threshold > 0 {
+		//The fiduciaries deal the shares of the secret between them
+		order.Threshold = int64(rec.Threshold)
+		order.FiduciaryCIDs = rec.fiduciaryCIDs()
+	}
+	for fiduciaryCID, fo := range rec.Fiduciaries {
+		recipientList, err := common.BuildRecipientList(s.Ipfs, nodeID, fiduciaryCID)
+		if err != nil {
+			return err
+		}
+		fo.OrderPart1CID, err = common.WriteOrderToIPFS(nodeID, s.Ipfs, s.Store, s.KeyStore, nodeID, order, recipientList)
+		if err != nil {
+			return err
+		}
+	}
+
+	rec.FulfillExtension = fulfillExtension
+	return s.setOrderState(rec, api.OrderStatePart1Written)
+}
+
+// prepareOrderPart1 sets the order values of the record and lets the plugin prepare the order part 1
+func (s *Service) prepareOrderPart1(rec *orderRecord, order *documents.OrderDoc) (fulfillExtension map[string]string, err error) {
+	order.NotBefore = rec.NotBefore
+	order.Expiry = rec.Expiry
+	order.PolicyCID = rec.PolicyCID
+
+	fulfillExtension, err = s.Plugin.PrepareOrderPart1(order, rec.Extension)
+	if err != nil {
+		return nil, err
+	}
+	rec.Coin = order.Coin
+	return fulfillExtension, nil
+}
+
+// fulfillOrderPart1 sends the order part 1 to the fiduciaries that haven't fulfilled it yet
+func (s *Service) fulfillOrderPart1(rec *orderRecord) error {
+	nodeID := s.NodeID()
+
+	for _, fiduciaryCID := range rec.fiduciaryCIDs() {
+		fo := rec.Fiduciaries[fiduciaryCID]
+		if fo.OrderPart2CID != "" {
+			continue
+		}
+
+		fiduciary, err := s.fiduciaryServer(fiduciaryCID)
+		if err != nil {
+			return err
+		}
+
+		//The order part 1 of a retried order can be too old for the fiduciary
+		recipientList, err := common.BuildRecipientList(s.Ipfs, nodeID, fiduciaryCID)
+		if err != nil {
+			return err
+		}
+		orderPart1CID, err := s.renewOrderEnvelope(fo.OrderPart1CID, recipientList, time.Now())
+		if err != nil {
+			return err
+		}
+		if orderPart1CID != fo.OrderPart1CID {
+			fo.OrderPart1CID = orderPart1CID
+			if err := s.saveOrderRecord(rec); err != nil {
+				return err
+			}
+		}
+
+		//Fullfill the order on the remote Server
+		request := &api.FulfillOrderRequest{
+			DocumentCID:   nodeID,
+			OrderPart1CID: fo.OrderPart1CID,
+			ShareDealCID:  rec.shareDealCID(fiduciaryCID),
+			Extension:     rec.FulfillExtension,
+		}
+		response, err := fiduciary.FulfillOrder(request)
+		if err != nil {
+			return errors.Wrapf(err, "Contacting Fiduciary %s", fiduciaryCID)
+		}
+
+		orderPart2, err := s.retrieveFiduciaryOrder(fiduciaryCID, response.OrderPart2CID)
+		if err != nil {
+			return err
+		}
+		if rec.Threshold > 0 {
+			if fo.ShareDeals, err = rec.checkShareOrderPart2(fiduciaryCID, orderPart2); err != nil {
+				return errors.Wrapf(err, "Fiduciary %s", fiduciaryCID)
+			}
+			//The dealer is fulfilled first, the others commit to the same secret
+			rec.CommitmentPublicKey = orderPart2.OrderPart2.CommitmentPublicKey
+			rec.ShareCommitments = orderPart2.OrderPart2.ShareCommitments
+		}
+
+		fo.OrderPart2CID = response.OrderPart2CID
+		fo.FulfillExtension = response.Extension
+		if err := s.saveOrderRecord(rec); err != nil {
+			return err
+		}
+	}
+
+	//The commitment is notified with the fulfilled order
+	if _, err := s.orderResponse(rec); err != nil {
+		return err
+	}
+	return s.setOrderState(rec, api.OrderStateFulfilled)
+}
+
+// orderResponse prepares the order response from the fulfilled order
+func (s *Service) orderResponse(rec *orderRecord) (*api.OrderResponse, error) {
+	fiduciaryCIDs := rec.fiduciaryCIDs()
+	lastFiduciaryCID := fiduciaryCIDs[len(fiduciaryCIDs)-1]
+	orderPart2, err := s.retrieveFiduciaryOrder(lastFiduciaryCID, rec.Fiduciaries[lastFiduciaryCID].OrderPart2CID)
+	if err != nil {
+		return nil, err
+	}
+	return s.fulfilledOrderResponse(rec, orderPart2)
+}
+
+// fulfilledOrderResponse prepares the order response from the order part 2 of the last fiduciary
+func (s *Service) fulfilledOrderResponse(rec *orderRecord, orderPart2 *documents.OrderDoc) (*api.OrderResponse, error) {
+	fulfillExtension := map[string]string{}
+	for _, fiduciaryCID := range rec.fiduciaryCIDs() {
+		for k, v := range rec.Fiduciaries[fiduciaryCID].FulfillExtension {
+			fulfillExtension[k] = v
+		}
+	}
+
+	if rec.Threshold > 0 {
+		//The plugin gets the commitment of the whole secret
+		orderPart2.OrderPart2.CommitmentPublicKey = rec.CommitmentPublicKey
+	}
+
+	commitment, extension, err := s.Plugin.PrepareOrderResponse(orderPart2, rec.Extension, fulfillExtension)
+	if err != nil {
+		return nil, errors.Wrap(err, "Generating Final Public Key")
+	}
+
+	rec.Commitment = commitment
+	if err := s.saveOrderRecord(rec); err != nil {
+		return nil, err
+	}
+
+	return &api.OrderResponse{
+		OrderReference: rec.Reference,
+		Commitment:     commitment,
+		State:          rec.State,
+		CreatedAt:      time.Now().Unix(),
+		Extension:      extension,
+	}, nil
+}
+
+// requestRedemption writes the order part 3 for each fiduciary
+func (s *Service) requestRedemption(rec *orderRecord, req *api.OrderSecretRequest) error {
+	nodeID := s.NodeID()
+
+	// BLS key
+	keyseed, err := s.KeyStore.Get("seed")
+	if err != nil {
+		return err
+	}
+	_, blsSK, err := identity.GenerateBLSKeys(keyseed)
+	if err != nil {
+		return err
+	}
+
+	//The beneficiary node reads the redemption instead of the principal
+	var beneficiaryIDDoc *documents.IDDoc
+	beneficiaryCID := rec.redeemingBeneficiaryCID(nodeID)
+	if beneficiaryCID != "" {
+		beneficiaryIDDoc, err = common.RetrieveIDDocFromIPFS(s.Ipfs, beneficiaryCID)
+		if err != nil {
+			return err
+		}
+	}
+
+	var beneficiaryEncryptedData []byte
+	for i, fiduciaryCID := range rec.fiduciaryCIDs() {
+		fo := rec.Fiduciaries[fiduciaryCID]
+
+		orderPart2, err := s.retrieveFiduciaryOrder(fiduciaryCID, fo.OrderPart2CID)
+		if err != nil {
+			return err
+		}
+
+		//Create a piece of data that is destined for the beneficiary, passed via the Fiduciaries
+		if i == 0 {
+			beneficiaryEncryptedData, rec.FulfillSecretExtension, err = s.Plugin.ProduceBeneficiaryEncryptedData(blsSK, orderPart2, req)
+			if err != nil {
+				return err
+			}
+		}
+
+		recipientList, err := common.BuildRecipientList(s.Ipfs, nodeID, fiduciaryCID)
+		if err != nil {
+			return err
+		}
+		if beneficiaryIDDoc != nil {
+			recipientList[beneficiaryCID] = beneficiaryIDDoc
+		}
+		fo.OrderPart3CID, err = common.CreateAndStorePart3(s.Ipfs, s.Store, s.KeyStore, orderPart2, fo.OrderPart2CID, nodeID, req.BeneficiaryIDDocumentCID, beneficiaryEncryptedData, recipientList)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.setOrderState(rec, api.OrderStateRedemptionRequested)
+}
+
+// processOrderSecret runs the redemption steps from the last completed state
+func (s *Service) processOrderSecret(rec *orderRecord) (*api.OrderSecretResponse, error) {
+	if rec.currentState() == api.OrderStateRedemptionRequested {
+		if err := s.fulfillOrderPart3(rec); err != nil {
+			return nil, s.failOrder(rec, err)
+		}
+	}
+
+	if rec.currentState() != api.OrderStateRedeemed {
+		return nil, service.ErrOrderState
+	}
+
+	response, err := s.orderSecretResponse(rec)
+	if err != nil {
+		return nil, s.failOrder(rec, err)
+	}
+	return response, nil
+}
+
+// fulfillOrderPart3 sends the order part 3 to the fiduciaries until enough of them have responded
+func (s *Service) fulfillOrderPart3(rec *orderRecord) error {
+	nodeID := s.NodeID()
+	threshold := rec.threshold()
+
+	fulfilled := 0
+	var lastErr error
+	for _, fiduciaryCID := range rec.fiduciaryCIDs() {
+		if fulfilled == threshold {
+			break
+		}
+
+		fo := rec.Fiduciaries[fiduciaryCID]
+		if fo.OrderPart4CID != "" {
+			fulfilled++
+			continue
+		}
+
+		if err := s.fulfillFiduciaryOrderPart3(rec, fiduciaryCID, fo, nodeID); err != nil {
+			lastErr = err
+			s.Logger.Error("Order %s: %v", rec.Reference, err)
+			continue
+		}
+		if err := s.saveOrderRecord(rec); err != nil {
+			return err
+		}
+		fulfilled++
+	}
+
+	if fulfilled < threshold {
+		if lastErr == nil {
+			lastErr = errors.New("No fiduciary available")
+		}
+		return errors.Wrapf(lastErr, "Fiduciary responses %v of %v", fulfilled, threshold)
+	}
+
+	return s.setOrderState(rec, api.OrderStateRedeemed)
+}
+
+func (s *Service) fulfillFiduciaryOrderPart3(rec *orderRecord, fiduciaryCID string, fo *fiduciaryOrder, nodeID string) error {
+	fiduciary, err := s.fiduciaryServer(fiduciaryCID)
+	if err != nil {
+		return err
+	}
+
+	//The order part 3 of a resumed redemption can be too old for the fiduciary
+	recipientList, err := common.BuildRecipientList(s.Ipfs, nodeID, fiduciaryCID)
+	if err != nil {
+		return err
+	}
+	if beneficiaryCID := rec.redeemingBeneficiaryCID(nodeID); beneficiaryCID != "" {
+		if recipientList[beneficiaryCID], err = common.RetrieveIDDocFromIPFS(s.Ipfs, beneficiaryCID); err != nil {
+			return err
+		}
+	}
+	orderPart3CID, err := s.renewOrderEnvelope(fo.OrderPart3CID, recipientList, time.Now())
+	if err != nil {
+		return err
+	}
+	if orderPart3CID != fo.OrderPart3CID {
+		fo.OrderPart3CID = orderPart3CID
+		if err := s.saveOrderRecord(rec); err != nil {
+			return err
+		}
+	}
+
+	//Post the address of the updated doc to the custody node
+	request := &api.FulfillOrderSecretRequest{
+		SenderDocumentCID: nodeID,
+		OrderPart3CID:     fo.OrderPart3CID,
+		Extension:         rec.FulfillSecretExtension,
+	}
+	response, err := fiduciary.FulfillOrderSecret(request)
+	if err != nil {
+		return errors.Wrapf(err, "Contacting Fiduciary %s", fiduciaryCID)
+	}
+
+	//The share of a beneficiary redemption is verified by the beneficiary
+	if rec.Threshold > 0 && rec.redeemingBeneficiaryCID(nodeID) == "" {
+		orderPart4, err := s.retrieveFiduciaryOrder(fiduciaryCID, response.OrderPart4CID)
+		if err != nil {
+			return err
+		}
+		if err := rec.checkFiduciaryShare(fiduciaryCID, orderPart4.OrderPart4.Share); err != nil {
+			return errors.Wrapf(err, "Fiduciary %s", fiduciaryCID)
+		}
+	}
+
+	fo.OrderPart4CID = response.OrderPart4CID
+	fo.FulfillSecretExtension = response.Extension
+	return nil
+}
+
+// orderSecretResponse produces the final secret from the redeemed order
+func (s *Service) orderSecretResponse(rec *orderRecord) (*api.OrderSecretResponse, error) {
+	//The order parts 4 are readable only by the beneficiary, it produces the secret on its own node
+	if beneficiaryCID := rec.redeemingBeneficiaryCID(s.NodeID()); beneficiaryCID != "" {
+		return &api.OrderSecretResponse{
+			OrderReference: rec.Reference,
+			BeneficiaryCID: beneficiaryCID,
+			OrderPart4CIDs: rec.orderPart4CIDs(),
+		}, nil
+	}
+
+	// SIKE key
+	keyseed, err := s.KeyStore.Get("seed")
+	if err != nil {
+		return nil, err
+	}
+	_, sikeSK, err := identity.GenerateSIKEKeys(keyseed)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		order, orderPart4 *documents.OrderDoc
+		response          *api.FulfillOrderSecretResponse
+		shares            []*documents.SecretShare
+	)
+	for _, fiduciaryCID := range rec.fiduciaryCIDs() {
+		fo := rec.Fiduciaries[fiduciaryCID]
+		if fo.OrderPart4CID == "" {
+			continue
+		}
+
+		if order == nil {
+			order, err = s.retrieveFiduciaryOrder(fiduciaryCID, fo.OrderPart2CID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		orderPart4, err = s.retrieveFiduciaryOrder(fiduciaryCID, fo.OrderPart4CID)
+		if err != nil {
+			return nil, err
+		}
+		response = &api.FulfillOrderSecretResponse{
+			OrderPart4CID: fo.OrderPart4CID,
+			Extension:     fo.FulfillSecretExtension,
+		}
+
+		shares = append(shares, orderPart4.OrderPart4.Share)
+		if len(shares) == rec.threshold() {
+			break
+		}
+	}
+	if orderPart4 == nil {
+		return nil, service.ErrOrderState
+	}
+
+	commitmentPublicKey := order.OrderPart2.CommitmentPublicKey
+	if rec.Threshold > 0 {
+		//The plugin gets the secret recovered from the shares
+		secret, err := common.RecoverSecretShares(rec.Threshold, shares)
+		if err != nil {
+			return nil, err
+		}
+		orderPart4.OrderPart4.Secret = secret
+		orderPart4.OrderPart4.Share = nil
+		commitmentPublicKey = rec.CommitmentPublicKey
+	}
+	if err := checkOrderSecret(orderPart4.OrderPart4.Secret, commitmentPublicKey); err != nil {
+		return nil, err
+	}
+
+	//The principal is the beneficiary
+	finalPrivateKey, finalPublicKey, ext, err := s.Plugin.ProduceFinalSecret(keyseed, sikeSK, order, orderPart4, rec.orderSecretRequest(), response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.OrderSecretResponse{
+		Secret:         finalPrivateKey,
+		Commitment:     finalPublicKey,
+		OrderReference: order.Reference,
+		Extension:      ext,
+	}, nil
+}
@@ -0,0 +1,231 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-milagro-dta/libs/cryptowallet"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/service"
+	"github.com/pkg/errors"
+)
+
+var errFiduciaryUnavailable = errors.New("fiduciary unavailable")
+
+// unavailableFiduciary fails the fulfil requests while down is set
+type unavailableFiduciary struct {
+	fiduciaryClient
+	down *bool
+}
+
+func (c unavailableFiduciary) FulfillOrder(req *api.FulfillOrderRequest) (*api.FulfillOrderResponse, error) {
+	if *c.down {
+		return nil, errFiduciaryUnavailable
+	}
+	return c.fiduciaryClient.FulfillOrder(req)
+}
+
+func (c unavailableFiduciary) FulfillOrderSecret(req *api.FulfillOrderSecretRequest) (*api.FulfillOrderSecretResponse, error) {
+	if *c.down {
+		return nil, errFiduciaryUnavailable
+	}
+	return c.fiduciaryClient.FulfillOrderSecret(req)
+}
+
+func TestOrderStateResume(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	down := true
+	principal := n.node("principal", func(s *Service) error {
+		s.SetMasterFiduciaryNodeID(fiduciary.NodeID())
+		return WithMasterFiduciary(unavailableFiduciary{fiduciaryClient{fiduciary}, &down})(s)
+	})
+
+	checkState := func(reference, state, lastState string) {
+		t.Helper()
+
+		rec, err := principal.loadOrderRecord(reference)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.State != state || rec.LastState != lastState {
+			t.Fatalf("invalid order state. Expected: %v/%v, found: %v/%v", state, lastState, rec.State, rec.LastState)
+		}
+		if state == api.OrderStateFailed && rec.Error == "" {
+			t.Fatal("no error of the failed order")
+		}
+	}
+
+	//The order fails after the order part 1 is written
+	if _, err := principal.Order(&api.OrderRequest{}); err == nil {
+		t.Fatal("order fulfilled by an unavailable fiduciary")
+	}
+	list, err := principal.OrderList(&api.OrderListRequest{PerPage: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.OrderReference) != 1 {
+		t.Fatalf("invalid orders: %v", list.OrderReference)
+	}
+	reference := list.OrderReference[0]
+	checkState(reference, api.OrderStateFailed, api.OrderStatePart1Written)
+
+	//The secret can't be requested before the order is fulfilled
+	if _, err := principal.OrderSecret(&api.OrderSecretRequest{OrderReference: reference}); errors.Cause(err) != service.ErrOrderState {
+		t.Fatalf("invalid error. Expected: %v, found: %v", service.ErrOrderState, err)
+	}
+
+	down = false
+	resumeResponse, err := principal.ResumeOrder(&api.ResumeOrderRequest{OrderReference: reference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumeResponse.State != api.OrderStateFulfilled || resumeResponse.Commitment == "" {
+		t.Fatalf("invalid resumed order: %v", resumeResponse)
+	}
+	checkState(reference, api.OrderStateFulfilled, "")
+
+	//The redemption fails after it is requested
+	down = true
+	if _, err := principal.OrderSecret(&api.OrderSecretRequest{OrderReference: reference}); err == nil {
+		t.Fatal("secret returned by an unavailable fiduciary")
+	}
+	checkState(reference, api.OrderStateFailed, api.OrderStateRedemptionRequested)
+
+	down = false
+	resumeResponse, err = principal.ResumeOrder(&api.ResumeOrderRequest{OrderReference: reference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumeResponse.State != api.OrderStateRedeemed || resumeResponse.Secret == "" {
+		t.Fatalf("invalid resumed order: %v", resumeResponse)
+	}
+	checkState(reference, api.OrderStateRedeemed, "")
+	commitment, _, err := cryptowallet.PublicKeyFromPrivate(resumeResponse.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commitment != resumeResponse.Commitment {
+		t.Fatalf("invalid secret commitment. Expected: %v, found: %v", resumeResponse.Commitment, commitment)
+	}
+
+	//The redeemed order can be resumed again
+	secretResponse, err := principal.ResumeOrder(&api.ResumeOrderRequest{OrderReference: reference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secretResponse.Secret != resumeResponse.Secret {
+		t.Fatal("invalid secret of the resumed redeemed order")
+	}
+}
+
+func TestMigrateLegacyOrders(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	//The legacy order is kept in the order store only
+	order, err := common.CreateNewDepositOrder("", principal.NodeID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderPart1CID := writeOrderPart1(t, principal, fiduciary, order)
+	fulfillResponse, err := fiduciary.FulfillOrder(&api.FulfillOrderRequest{
+		DocumentCID:   principal.NodeID(),
+		OrderPart1CID: orderPart1CID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fiduciary.deleteOrderFulfilment(order.Reference)
+
+	for _, node := range []*Service{principal, fiduciary} {
+		if err := node.Store.Del("orderStateIndex", "migrated"); err != nil {
+			t.Fatal(err)
+		}
+		if err := node.migrateLegacyOrders(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fulfilment, err := fiduciary.loadOrderFulfilment(order.Reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fulfilment == nil || fulfilment.OrderPart1CID != orderPart1CID || fulfilment.OrderPart2CID != fulfillResponse.OrderPart2CID {
+		t.Fatalf("invalid fulfilment: %v", fulfilment)
+	}
+	rec, err := principal.loadOrderRecord(order.Reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.State != api.OrderStatePart1Written || rec.Fiduciaries[fiduciary.NodeID()].OrderPart1CID != orderPart1CID {
+		t.Fatalf("invalid order record: %v", rec)
+	}
+
+	//The migrated order continues with the secret generated by the fiduciary
+	resumeResponse, err := principal.ResumeOrder(&api.ResumeOrderRequest{OrderReference: order.Reference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec, err = principal.loadOrderRecord(order.Reference); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Fiduciaries[fiduciary.NodeID()].OrderPart2CID != fulfillResponse.OrderPart2CID {
+		t.Fatalf("invalid order part 2. Expected: %v, found: %v", fulfillResponse.OrderPart2CID, rec.Fiduciaries[fiduciary.NodeID()].OrderPart2CID)
+	}
+	secretResponse, err := principal.OrderSecret(&api.OrderSecretRequest{OrderReference: order.Reference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitment, _, err := cryptowallet.PublicKeyFromPrivate(secretResponse.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commitment != resumeResponse.Commitment {
+		t.Fatalf("invalid secret commitment. Expected: %v, found: %v", resumeResponse.Commitment, commitment)
+	}
+
+	//The redeemed order is migrated from the last order part
+	if err := principal.Store.Del("orderState", order.Reference); err != nil {
+		t.Fatal(err)
+	}
+	if err := principal.Store.Del("orderStateIndex", "migrated"); err != nil {
+		t.Fatal(err)
+	}
+	if err := principal.migrateLegacyOrders(); err != nil {
+		t.Fatal(err)
+	}
+	if rec, err = principal.loadOrderRecord(order.Reference); err != nil {
+		t.Fatal(err)
+	}
+	if rec.State != api.OrderStateRedemptionRequested || rec.Commitment != resumeResponse.Commitment {
+		t.Fatalf("invalid order record: %v", rec)
+	}
+	resumeResponse, err = principal.ResumeOrder(&api.ResumeOrderRequest{OrderReference: order.Reference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumeResponse.Secret != secretResponse.Secret {
+		t.Fatal("invalid secret of the migrated redemption")
+	}
+}
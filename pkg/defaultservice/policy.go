@@ -0,0 +1,220 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/libs/documents"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+	"github.com/apache/incubator-milagro-dta/pkg/service"
+	"github.com/pkg/errors"
+)
+
+// orderPolicy is the policy of an order kept by the fiduciary
+type orderPolicy struct {
+	PolicyCID string
+	// FulfilledAt is the time the order was fulfilled by the fiduciary
+	FulfilledAt int64
+	// OrderPart3CIDs are the redemptions released by the fiduciary
+	OrderPart3CIDs []string
+}
+
+// CreatePolicy signs a policy document with the node key and publishes it to IPFS
+func (s *Service) CreatePolicy(req *api.CreatePolicyRequest) (*api.CreatePolicyResponse, error) {
+	nodeID := s.NodeID()
+
+	// BLS key
+	keyseed, err := s.KeyStore.Get("seed")
+	if err != nil {
+		return nil, err
+	}
+	_, blsSK, err := identity.GenerateBLSKeys(keyseed)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := documents.NewPolicyDoc()
+	policy.Policy.Version = 1.0
+	policy.Name = req.Name
+	policy.AllowedBeneficiaryCIDs = req.AllowedBeneficiaryCIDs
+	policy.MinRedemptionDelay = req.MinRedemptionDelay
+	policy.RequiredApproverCIDs = req.RequiredApproverCIDs
+	policy.MaxRedemptions = req.MaxRedemptions
+	policy.ApprovalQuorum = req.ApprovalQuorum
+	policy.Policy.Timestamp = time.Now().Unix()
+
+	rawPolicy, err := documents.EncodePolicyDocument(nodeID, policy, blsSK)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode Policy")
+	}
+	policyCID, err := s.Ipfs.Add(rawPolicy)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to Save Raw Document into IPFS")
+	}
+
+	if err := s.Store.Set("policy", policyCID, req.Name, map[string]string{"time": time.Now().UTC().Format(time.RFC3339)}); err != nil {
+		return nil, errors.Wrap(err, "Save Policy to store")
+	}
+
+	return &api.CreatePolicyResponse{
+		PolicyCID: policyCID,
+	}, nil
+}
+
+// GetPolicy retrieves a policy document from IPFS
+func (s *Service) GetPolicy(req *api.GetPolicyRequest) (*api.GetPolicyResponse, error) {
+	policy, signerCID, err := common.RetrievePolicyFromIPFS(s.Ipfs, req.PolicyCID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.GetPolicyResponse{
+		PolicyCID:              req.PolicyCID,
+		SignerCID:              signerCID,
+		Name:                   policy.Name,
+		AllowedBeneficiaryCIDs: policy.AllowedBeneficiaryCIDs,
+		MinRedemptionDelay:     policy.MinRedemptionDelay,
+		RequiredApproverCIDs:   policy.RequiredApproverCIDs,
+		MaxRedemptions:         policy.MaxRedemptions,
+		ApprovalQuorum:         policy.ApprovalQuorum,
+		Timestamp:              policy.Policy.Timestamp,
+	}, nil
+}
+
+// retrieveOrderPolicy retrieves a policy signed by the fiduciary enforcing it
+func (s *Service) retrieveOrderPolicy(policyCID, fiduciaryCID string) (*documents.PolicyDoc, error) {
+	policy, signerCID, err := common.RetrievePolicyFromIPFS(s.Ipfs, policyCID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Policy %s", policyCID)
+	}
+	if signerCID != fiduciaryCID {
+		return nil, errors.Wrapf(service.ErrPolicyViolation, "policy %s not signed by %s", policyCID, fiduciaryCID)
+	}
+	return policy, nil
+}
+
+// storeOrderPolicy checks the order against its policy and keeps the policy for the redemptions
+func (s *Service) storeOrderPolicy(order *documents.OrderDoc) error {
+	if order.PolicyCID == "" {
+		return nil
+	}
+
+	//The node enforces only the policies it signed
+	policy, err := s.retrieveOrderPolicy(order.PolicyCID, s.NodeID())
+	if err != nil {
+		return err
+	}
+	if order.BeneficiaryCID != "" {
+		if err := checkPolicyBeneficiary(policy, order.BeneficiaryCID); err != nil {
+			return err
+		}
+	}
+
+	//A repeated fulfilment keeps the redemptions already released
+	op := &orderPolicy{}
+	switch err := s.Store.Get("orderPolicy", order.Reference, op); err {
+	case nil:
+		return nil
+	case datastore.ErrKeyNotFound:
+	default:
+		return err
+	}
+
+	op = &orderPolicy{
+		PolicyCID:   order.PolicyCID,
+		FulfilledAt: time.Now().Unix(),
+	}
+	if err := s.Store.Set("orderPolicy", order.Reference, op, nil); err != nil {
+		return errors.Wrap(err, "Save Order policy")
+	}
+	return nil
+}
+
+// checkOrderPolicy returns ErrPolicyViolation if the redemption breaks the order policy
+// The policy is nil if the order has no policy
+// The caller holds the order lock until the redemption is recorded
+func (s *Service) checkOrderPolicy(reference, orderPart2CID, orderPart3CID, beneficiaryCID string) (*orderPolicy, error) {
+	op := &orderPolicy{}
+	switch err := s.Store.Get("orderPolicy", reference, op); err {
+	case nil:
+	case datastore.ErrKeyNotFound:
+		return nil, nil
+	default:
+		return nil, err
+	}
+
+	policy, _, err := common.RetrievePolicyFromIPFS(s.Ipfs, op.PolicyCID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Policy %s", op.PolicyCID)
+	}
+
+	if err := checkPolicyBeneficiary(policy, beneficiaryCID); err != nil {
+		return nil, err
+	}
+
+	if redeemAt := op.FulfilledAt + policy.MinRedemptionDelay; time.Now().Unix() < redeemAt {
+		return nil, errors.Wrapf(service.ErrPolicyViolation, "redemption not allowed before %s", time.Unix(redeemAt, 0).UTC().Format(time.RFC3339))
+	}
+
+	//A repeated request for the same redemption isn't a new redemption
+	if policy.MaxRedemptions > 0 && !containsCID(op.OrderPart3CIDs, orderPart3CID) && int64(len(op.OrderPart3CIDs)) >= policy.MaxRedemptions {
+		return nil, errors.Wrapf(service.ErrPolicyViolation, "maximum %v redemptions", policy.MaxRedemptions)
+	}
+
+	if len(policy.RequiredApproverCIDs) > 0 {
+		if err := s.checkOrderApprovals(reference, orderPart2CID, policy); err != nil {
+			return nil, err
+		}
+	}
+
+	return op, nil
+}
+
+// recordOrderRedemption counts the redemption released by the fiduciary
+func (s *Service) recordOrderRedemption(reference, orderPart3CID string, op *orderPolicy) error {
+	if op == nil || containsCID(op.OrderPart3CIDs, orderPart3CID) {
+		return nil
+	}
+
+	op.OrderPart3CIDs = append(op.OrderPart3CIDs, orderPart3CID)
+	if err := s.Store.Set("orderPolicy", reference, op, nil); err != nil {
+		return errors.Wrap(err, "Save Order policy")
+	}
+	return nil
+}
+
+// checkPolicyBeneficiary returns ErrPolicyViolation if the beneficiary isn't allowed by the policy
+func checkPolicyBeneficiary(policy *documents.PolicyDoc, beneficiaryCID string) error {
+	if len(policy.AllowedBeneficiaryCIDs) == 0 || containsCID(policy.AllowedBeneficiaryCIDs, beneficiaryCID) {
+		return nil
+	}
+	return errors.Wrapf(service.ErrPolicyViolation, "beneficiary %s not allowed", beneficiaryCID)
+}
+
+func containsCID(cids []string, cid string) bool {
+	for _, c := range cids {
+		if c == cid {
+			return true
+		}
+	}
+	return false
+}
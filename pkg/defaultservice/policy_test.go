@@ -0,0 +1,175 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/service"
+	"github.com/pkg/errors"
+)
+
+// writeOrderPart3 writes a new redemption request of the principal for the fiduciary
+func writeOrderPart3(t *testing.T, principal, fiduciary *Service, reference string) string {
+	t.Helper()
+
+	rec, err := principal.loadOrderRecord(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderPart2CID := rec.Fiduciaries[fiduciary.NodeID()].OrderPart2CID
+	orderPart2, err := principal.retrieveFiduciaryOrder(fiduciary.NodeID(), orderPart2CID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipientList, err := common.BuildRecipientList(principal.Ipfs, principal.NodeID(), fiduciary.NodeID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	//The requests signed in the same second differ by the data for the beneficiary
+	data := make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	orderPart3CID, err := common.CreateAndStorePart3(principal.Ipfs, principal.Store, principal.KeyStore, orderPart2, orderPart2CID, principal.NodeID(), "", data, recipientList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return orderPart3CID
+}
+
+func TestOrderPolicyMaxRedemptions(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	policy, err := fiduciary.CreatePolicy(&api.CreatePolicyRequest{
+		Name:           "single",
+		MaxRedemptions: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderResponse, err := principal.Order(&api.OrderRequest{PolicyCID: policy.PolicyCID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const requests = 8
+	orderPart3CIDs := make([]string, requests)
+	for i := range orderPart3CIDs {
+		orderPart3CIDs[i] = writeOrderPart3(t, principal, fiduciary, orderResponse.OrderReference)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, requests)
+	for i := range orderPart3CIDs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = fiduciary.FulfillOrderSecret(&api.FulfillOrderSecretRequest{
+				SenderDocumentCID: principal.NodeID(),
+				OrderPart3CID:     orderPart3CIDs[i],
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	redeemed := 0
+	for i, err := range errs {
+		switch errors.Cause(err) {
+		case nil:
+			redeemed++
+		case service.ErrPolicyViolation:
+		default:
+			t.Fatalf("FulfillOrderSecret %v: %v", i, err)
+		}
+	}
+	if redeemed != 1 {
+		t.Fatalf("invalid redemptions. Expected: 1, found: %v", redeemed)
+	}
+}
+
+func TestOrderPolicyBeneficiary(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	beneficiary := n.node("beneficiary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	policy, err := fiduciary.CreatePolicy(&api.CreatePolicyRequest{
+		Name:                   "beneficiary",
+		AllowedBeneficiaryCIDs: []string{beneficiary.NodeID()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = principal.Order(&api.OrderRequest{PolicyCID: policy.PolicyCID, BeneficiaryIDDocumentCID: principal.NodeID()})
+	if errors.Cause(err) != service.ErrPolicyViolation {
+		t.Fatalf("invalid error. Expected: %v, found: %v", service.ErrPolicyViolation, err)
+	}
+
+	//The principal can't redeem an order restricted to the beneficiary
+	orderResponse, err := principal.Order(&api.OrderRequest{PolicyCID: policy.PolicyCID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = principal.OrderSecret(&api.OrderSecretRequest{OrderReference: orderResponse.OrderReference})
+	if errors.Cause(err) != service.ErrPolicyViolation {
+		t.Fatalf("invalid error. Expected: %v, found: %v", service.ErrPolicyViolation, err)
+	}
+}
+
+func TestOrderPolicySigner(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	//The principal names itself as a fiduciary of the order to pass its own policy
+	policy, err := principal.CreatePolicy(&api.CreatePolicyRequest{Name: "lax"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = principal.Order(&api.OrderRequest{PolicyCID: policy.PolicyCID})
+	if errors.Cause(err) != service.ErrPolicyViolation {
+		t.Fatalf("invalid error. Expected: %v, found: %v", service.ErrPolicyViolation, err)
+	}
+
+	order, err := common.CreateNewDepositOrder("", principal.NodeID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	order.PolicyCID = policy.PolicyCID
+	order.FiduciaryCIDs = []string{fiduciary.NodeID(), principal.NodeID()}
+	orderPart1CID := writeOrderPart1(t, principal, fiduciary, order)
+
+	_, err = fiduciary.FulfillOrder(&api.FulfillOrderRequest{
+		DocumentCID:   principal.NodeID(),
+		OrderPart1CID: orderPart1CID,
+	})
+	if errors.Cause(err) != service.ErrPolicyViolation {
+		t.Fatalf("invalid error. Expected: %v, found: %v", service.ErrPolicyViolation, err)
+	}
+}
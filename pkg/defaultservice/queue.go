@@ -0,0 +1,139 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+)
+
+const (
+	defaultQueuePollInterval     = 5 * time.Second
+	defaultQueueRetryInterval    = 10 * time.Second
+	defaultQueueMaxRetryInterval = 10 * time.Minute
+)
+
+// ordersQueue is the durable queue of the orders waiting for the fiduciaries
+func (s *Service) ordersQueue() *datastore.Queue {
+	return datastore.NewQueue(s.Store, "orderQueue")
+}
+
+// queueOrder schedules the order for fulfilment in the background
+func (s *Service) queueOrder(orderReference string) error {
+	return s.ordersQueue().Push(orderReference, nil)
+}
+
+// RunOrderQueue fulfils the queued orders until stop is closed
+func (s *Service) RunOrderQueue(stop <-chan struct{}) {
+	pollInterval := s.queueConfig.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultQueuePollInterval
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.processOrderQueue()
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processOrderQueue processes the orders due for another attempt
+func (s *Service) processOrderQueue() {
+	queue := s.ordersQueue()
+	items, err := queue.Due(time.Now(), 0)
+	if err != nil {
+		s.Logger.Error("Order queue: %v", err)
+		return
+	}
+
+	for _, item := range items {
+		if err := s.processQueuedOrder(queue, item); err != nil {
+			s.Logger.Error("Order queue %s: %v", item.Key, err)
+		}
+	}
+}
+
+func (s *Service) processQueuedOrder(queue *datastore.Queue, item *datastore.QueueItem) error {
+	//The order can be resumed or cancelled while the fiduciaries are contacted
+	defer s.orderRecordLocks.lock(item.Key)()
+
+	rec, err := s.loadOrderRecord(item.Key)
+	switch err {
+	case nil:
+	case datastore.ErrKeyNotFound:
+		return queue.Done(item.Key)
+	default:
+		return err
+	}
+
+	//The order was already fulfilled, i.e. resumed manually
+	switch rec.currentState() {
+	case api.OrderStateCreated, api.OrderStatePart1Written:
+	default:
+		return queue.Done(item.Key)
+	}
+
+	//The fiduciaries don't fulfil an expired order
+	if err := checkOrderWindow(0, rec.Expiry, time.Now()); err != nil {
+		s.Logger.Error("Order %s not fulfilled: %v", item.Key, s.failOrder(rec, err))
+		return queue.Done(item.Key)
+	}
+
+	if _, err := s.processOrder(rec, nil); err != nil {
+		if s.queueConfig.MaxAttempts > 0 && item.Attempts+1 >= s.queueConfig.MaxAttempts {
+			s.Logger.Error("Order %s failed after %v attempts: %v", item.Key, item.Attempts+1, err)
+			return queue.Done(item.Key)
+		}
+		delay := s.queueRetryDelay(item.Attempts)
+		s.Logger.Info("Order %s attempt %v failed, retry in %v: %v", item.Key, item.Attempts+1, delay, err)
+		return queue.Retry(item, delay, err)
+	}
+
+	s.Logger.Info("Order %s fulfilled", item.Key)
+	return queue.Done(item.Key)
+}
+
+// queueRetryDelay returns the exponential backoff delay after the failed attempts
+func (s *Service) queueRetryDelay(attempts int) time.Duration {
+	retryInterval := s.queueConfig.RetryInterval
+	if retryInterval <= 0 {
+		retryInterval = defaultQueueRetryInterval
+	}
+	maxRetryInterval := s.queueConfig.MaxRetryInterval
+	if maxRetryInterval <= 0 {
+		maxRetryInterval = defaultQueueMaxRetryInterval
+	}
+
+	delay := retryInterval
+	for i := 0; i < attempts && delay < maxRetryInterval; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryInterval {
+		delay = maxRetryInterval
+	}
+	return delay
+}
@@ -0,0 +1,173 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/service"
+	"github.com/pkg/errors"
+)
+
+func TestOrderQueueExpired(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	orderResponse, err := principal.Order(&api.OrderRequest{Async: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//The order expired while queued
+	rec, err := principal.loadOrderRecord(orderResponse.OrderReference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Expiry = time.Now().Add(-time.Minute).Unix()
+	if err := principal.saveOrderRecord(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	principal.processOrderQueue()
+
+	if rec, err = principal.loadOrderRecord(orderResponse.OrderReference); err != nil {
+		t.Fatal(err)
+	}
+	if rec.State != api.OrderStateFailed {
+		t.Fatalf("invalid state. Expected: %v, found: %v", api.OrderStateFailed, rec.State)
+	}
+	items, err := principal.ordersQueue().Due(time.Now().Add(defaultQueueMaxRetryInterval), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 0 {
+		t.Fatalf("expired order still queued: %v", items[0].Key)
+	}
+}
+
+func TestOrderQueueCancelled(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	orderResponse, err := principal.Order(&api.OrderRequest{Async: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reference := orderResponse.OrderReference
+
+	//The queued order is cancelled after the worker loaded it
+	queued, err := principal.loadOrderRecord(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := principal.CancelOrder(&api.CancelOrderRequest{OrderReference: reference}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := principal.processOrder(queued, nil); err == nil {
+		t.Fatal("cancelled order fulfilled")
+	}
+	if err := principal.setOrderState(queued, api.OrderStateFulfilled); errors.Cause(err) != service.ErrOrderState {
+		t.Fatalf("invalid error. Expected: %v, found: %v", service.ErrOrderState, err)
+	}
+	rec, err := principal.loadOrderRecord(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.State != api.OrderStateCancelled {
+		t.Fatalf("invalid state. Expected: %v, found: %v", api.OrderStateCancelled, rec.State)
+	}
+}
+
+func TestOrderQueueEnvelopeRenewed(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	order, err := common.CreateNewDepositOrder("", principal.NodeID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderPart1CID := writeOrderPart1(t, principal, fiduciary, order)
+	response, err := fiduciary.FulfillOrder(&api.FulfillOrderRequest{
+		DocumentCID:   principal.NodeID(),
+		OrderPart1CID: orderPart1CID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.OrderReference != order.Reference {
+		t.Fatalf("invalid order reference. Expected: %v, found: %v", order.Reference, response.OrderReference)
+	}
+	recipientList, err := common.BuildRecipientList(principal.Ipfs, principal.NodeID(), fiduciary.NodeID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//A recent order part 1 is sent again as it is
+	renewedCID, err := principal.renewOrderEnvelope(orderPart1CID, recipientList, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewedCID != orderPart1CID {
+		t.Fatalf("recent order part 1 renewed as %s", renewedCID)
+	}
+
+	//An order part 1 about to expire is signed again
+	later := time.Now().Add(defaultEnvelopeMaxAge)
+	if err := fiduciary.acceptEnvelope(order.Header, later); errors.Cause(err) != service.ErrEnvelopeExpired {
+		t.Fatalf("invalid error. Expected: %v, found: %v", service.ErrEnvelopeExpired, err)
+	}
+	renewedCID, err = principal.renewOrderEnvelope(orderPart1CID, recipientList, later)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewedCID == orderPart1CID {
+		t.Fatal("expiring order part 1 not renewed")
+	}
+
+	//The fiduciary returns the order part 2 of the order it already fulfilled
+	renewedResponse, err := fiduciary.FulfillOrder(&api.FulfillOrderRequest{
+		DocumentCID:   principal.NodeID(),
+		OrderPart1CID: renewedCID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewedResponse.OrderPart2CID != response.OrderPart2CID {
+		t.Fatalf("invalid order part 2. Expected: %v, found: %v", response.OrderPart2CID, renewedResponse.OrderPart2CID)
+	}
+
+	//A different order with the same reference is still rejected
+	order.Coin++
+	_, err = fiduciary.FulfillOrder(&api.FulfillOrderRequest{
+		DocumentCID:   principal.NodeID(),
+		OrderPart1CID: writeOrderPart1(t, principal, fiduciary, order),
+	})
+	if errors.Cause(err) != service.ErrOrderConflict {
+		t.Fatalf("invalid error. Expected: %v, found: %v", service.ErrOrderConflict, err)
+	}
+}
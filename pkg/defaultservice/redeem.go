@@ -0,0 +1,208 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"bytes"
+
+	"github.com/apache/incubator-milagro-dta/libs/cryptowallet"
+	"github.com/apache/incubator-milagro-dta/libs/documents"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+	"github.com/pkg/errors"
+)
+
+// RedeemOrder produces the order secret on the beneficiary node
+// The order parts 4 are written by the fiduciaries for the beneficiary only
+func (s *Service) RedeemOrder(req *api.RedeemOrderRequest) (*api.RedeemOrderResponse, error) {
+	nodeID := s.NodeID()
+
+	// SIKE key
+	keyseed, err := s.KeyStore.Get("seed")
+	if err != nil {
+		return nil, err
+	}
+	_, sikeSK, err := identity.GenerateSIKEKeys(keyseed)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		orderPart4 *documents.OrderDoc
+		shares     []*documents.SecretShare
+	)
+	signers := map[string]bool{}
+	for _, orderPart4CID := range req.OrderPart4CIDs {
+		order, signerCID, err := common.RetrieveSignedOrderFromIPFS(s.Ipfs, orderPart4CID, sikeSK, nodeID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Fail to retrieve Order %s", orderPart4CID)
+		}
+		if err := checkRedeemedOrder(order, signerCID, nodeID); err != nil {
+			return nil, errors.Wrapf(err, "Order %s", orderPart4CID)
+		}
+		if err := s.checkOrderPart2Signer(order, signerCID); err != nil {
+			return nil, errors.Wrapf(err, "Order %s", orderPart4CID)
+		}
+		if orderPart4 != nil && order.Reference != orderPart4.Reference {
+			return nil, errors.Errorf("Order %s doesn't match the order %s", orderPart4CID, orderPart4.Reference)
+		}
+		if signers[signerCID] {
+			return nil, errors.Errorf("Order %s: duplicated fiduciary %s", orderPart4CID, signerCID)
+		}
+		signers[signerCID] = true
+
+		if order.Threshold > 0 {
+			//The fiduciaries of a k-of-n order commit to the same secret and shares
+			if orderPart4 != nil && !sameShareCommitments(order.OrderPart2, orderPart4.OrderPart2) {
+				return nil, errors.Wrapf(common.ErrInvalidShare, "Fiduciary %s: commitments don't match the order", signerCID)
+			}
+			if err := checkRedeemedShare(order, signerCID); err != nil {
+				return nil, errors.Wrapf(err, "Fiduciary %s", signerCID)
+			}
+			shares = append(shares, order.OrderPart4.Share)
+		}
+		orderPart4 = order
+	}
+	if orderPart4 == nil {
+		return nil, errors.New("No order part 4")
+	}
+
+	if orderPart4.Threshold > 0 {
+		if len(shares) < int(orderPart4.Threshold) {
+			return nil, errors.Errorf("Fiduciary responses %v of %v", len(shares), orderPart4.Threshold)
+		}
+		//The plugin gets the secret recovered from the shares
+		secret, err := common.RecoverSecretShares(int(orderPart4.Threshold), shares)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkOrderSecret(secret, orderPart4.OrderPart2.CommitmentPublicKey); err != nil {
+			return nil, err
+		}
+		orderPart4.OrderPart4.Secret = secret
+		orderPart4.OrderPart4.Share = nil
+	}
+
+	//The plugins expect the beneficiary nominated on redemption in the order secret request
+	orderSecretRequest := &api.OrderSecretRequest{
+		OrderReference:           orderPart4.Reference,
+		BeneficiaryIDDocumentCID: orderPart4.OrderPart3.BeneficiaryCID,
+		Extension:                req.Extension,
+	}
+	fulfillSecretResponse := &api.FulfillOrderSecretResponse{
+		OrderPart4CID: req.OrderPart4CIDs[len(req.OrderPart4CIDs)-1],
+	}
+
+	finalPrivateKey, finalPublicKey, ext, err := s.Plugin.ProduceFinalSecret(keyseed, sikeSK, orderPart4, orderPart4, orderSecretRequest, fulfillSecretResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.RedeemOrderResponse{
+		OrderReference: orderPart4.Reference,
+		Secret:         finalPrivateKey,
+		Commitment:     finalPublicKey,
+		Extension:      ext,
+	}, nil
+}
+
+// checkRedeemedOrder returns an error if the order part 4 isn't a fiduciary response for the beneficiary
+func checkRedeemedOrder(order *documents.OrderDoc, signerCID, beneficiaryCID string) error {
+	if order.OrderPart2 == nil || order.OrderPart3 == nil || order.OrderPart4 == nil {
+		return errors.New("Invalid order part 4")
+	}
+	if order.BeneficiaryCID != beneficiaryCID && (order.BeneficiaryCID != "" || order.OrderPart3.BeneficiaryCID != beneficiaryCID) {
+		return errors.New("Not the beneficiary of the order")
+	}
+	if signerCID == order.PrincipalCID {
+		return errors.New("Not signed by a fiduciary")
+	}
+	if order.Threshold > 0 {
+		if !containsCID(order.FiduciaryCIDs, signerCID) {
+			return errors.New("Not signed by a fiduciary")
+		}
+		return nil
+	}
+	//The secret of a single fiduciary must match the commitment it signed
+	return checkOrderSecret(order.OrderPart4.Secret, order.OrderPart2.CommitmentPublicKey)
+}
+
+// checkRedeemedShare returns an error if the share of the order part 4 doesn't match the commitment of the fiduciary
+func checkRedeemedShare(order *documents.OrderDoc, signerCID string) error {
+	shareCommitments := order.OrderPart2.ShareCommitments
+	if len(shareCommitments) != len(order.FiduciaryCIDs) {
+		return common.ErrInvalidShare
+	}
+	for i, fiduciaryCID := range order.FiduciaryCIDs {
+		if fiduciaryCID == signerCID {
+			return common.VerifySecretShare(order.OrderPart4.Share, shareCommitments[i])
+		}
+	}
+	return common.ErrInvalidShare
+}
+
+// sameShareCommitments returns true if both order parts 2 commit to the same k-of-n secret
+func sameShareCommitments(a, b *documents.OrderPart2) bool {
+	if a.CommitmentPublicKey != b.CommitmentPublicKey || len(a.ShareCommitments) != len(b.ShareCommitments) {
+		return false
+	}
+	for i := range a.ShareCommitments {
+		if !bytes.Equal(a.ShareCommitments[i], b.ShareCommitments[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkOrderPart2Signer returns an error if the order part 2 redeemed wasn't signed by the fiduciary of the order part 4
+// The order part 2 isn't readable by the beneficiary, only its signature is verified
+func (s *Service) checkOrderPart2Signer(order *documents.OrderDoc, signerCID string) error {
+	orderPart2CID := order.OrderPart3.PreviousOrderCID
+	rawDoc, err := s.Ipfs.Get(orderPart2CID)
+	if err != nil {
+		return errors.Wrapf(err, "Retrieve order part 2 %s", orderPart2CID)
+	}
+	se, err := documents.SmartDecodeEnvelope(rawDoc, orderPart2CID)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid order part 2 %s", orderPart2CID)
+	}
+	if se.SignerCID != signerCID {
+		return errors.Errorf("Order part 2 %s signed by %s", orderPart2CID, se.SignerCID)
+	}
+	signerIDDoc, err := common.RetrieveIDDocFromIPFS(s.Ipfs, signerCID)
+	if err != nil {
+		return err
+	}
+	if err := se.Verify(signerIDDoc.BLSPublicKey); err != nil {
+		return errors.Wrapf(err, "Invalid order part 2 %s", orderPart2CID)
+	}
+	return nil
+}
+
+// checkOrderSecret returns an error if the secret doesn't match the commitment of the order
+func checkOrderSecret(secret, commitmentPublicKey string) error {
+	publicKey, _, err := cryptowallet.PublicKeyFromPrivate(secret)
+	if err != nil {
+		return err
+	}
+	if commitmentPublicKey == "" || publicKey != commitmentPublicKey {
+		return errors.New("Secret doesn't match the order commitment")
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-milagro-dta/libs/cryptowallet"
+	"github.com/apache/incubator-milagro-dta/libs/documents"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+)
+
+// rewriteOrderPart4 writes the order part 4 read by the beneficiary again with another secret and signer
+func rewriteOrderPart4(t *testing.T, beneficiary, signer *Service, orderPart4CID, secret string) string {
+	t.Helper()
+
+	keyseed, err := beneficiary.KeyStore.Get("seed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, sikeSK, err := identity.GenerateSIKEKeys(keyseed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, _, err := common.RetrieveSignedOrderFromIPFS(beneficiary.Ipfs, orderPart4CID, sikeSK, beneficiary.NodeID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	order.OrderPart4.Secret = secret
+
+	beneficiaryIDDoc, err := common.RetrieveIDDocFromIPFS(beneficiary.Ipfs, beneficiary.NodeID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipientList := map[string]*documents.IDDoc{beneficiary.NodeID(): beneficiaryIDDoc}
+	cid, err := common.WriteOrderToIPFS(signer.NodeID(), signer.Ipfs, signer.Store, signer.KeyStore, signer.NodeID(), order, recipientList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cid
+}
+
+func TestRedeemOrder(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	rogue := n.node("rogue")
+	beneficiary := n.node("beneficiary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary))
+
+	orderResponse, err := principal.Order(&api.OrderRequest{BeneficiaryIDDocumentCID: beneficiary.NodeID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secretResponse, err := principal.OrderSecret(&api.OrderSecretRequest{OrderReference: orderResponse.OrderReference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderPart4CID := secretResponse.OrderPart4CIDs[0]
+
+	redeemResponse, err := beneficiary.RedeemOrder(&api.RedeemOrderRequest{OrderPart4CIDs: []string{orderPart4CID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redeemResponse.Commitment != orderResponse.Commitment {
+		t.Fatalf("invalid secret commitment. Expected: %v, found: %v", orderResponse.Commitment, redeemResponse.Commitment)
+	}
+
+	otherSecret, err := cryptowallet.RedeemSecret("00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name   string
+		signer *Service
+		secret string
+	}{
+		{"not the order fiduciary", rogue, redeemResponse.Secret},
+		{"secret not committed", fiduciary, otherSecret},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cid := rewriteOrderPart4(t, beneficiary, tc.signer, orderPart4CID, tc.secret)
+			if _, err := beneficiary.RedeemOrder(&api.RedeemOrderRequest{OrderPart4CIDs: []string{cid}}); err == nil {
+				t.Fatal("order part 4 accepted")
+			}
+		})
+	}
+}
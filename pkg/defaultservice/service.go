@@ -22,14 +22,18 @@ package defaultservice
 
 import (
 	"io"
+	"net/http"
+	"sync"
 	"time"
 
+	"github.com/apache/incubator-milagro-dta/libs/audit"
 	"github.com/apache/incubator-milagro-dta/libs/datastore"
 	"github.com/apache/incubator-milagro-dta/libs/ipfs"
 	"github.com/apache/incubator-milagro-dta/libs/keystore"
 	"github.com/apache/incubator-milagro-dta/libs/logger"
 	"github.com/apache/incubator-milagro-dta/libs/transport"
 	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/config"
 )
 
 var (
@@ -47,8 +51,24 @@ type Service struct {
 	KeyStore              keystore.Store
 	Ipfs                  ipfs.Connector
 	MasterFiduciaryServer api.ClientService
+	Fiduciaries           map[string]api.ClientService
 	nodeID                string
 	masterFiduciaryNodeID string
+	fiduciaryThreshold    int
+	queueConfig           config.OrderQueueConfig
+	reaperInterval        time.Duration
+	auditLog              *audit.Log
+	auditSignInterval     time.Duration
+	webhookConfig         config.WebhookConfig
+	webhookClient         *http.Client
+	operators             []string
+	orderEvents           *orderEventLog
+	orderEventRetention   time.Duration
+	envelopeMaxAge        time.Duration
+	maxBatchSize          int
+	envelopeMutex         sync.Mutex
+	orderLocks            orderLocks
+	orderRecordLocks      orderLocks
 }
 
 //NewService returns a default implementation of Service
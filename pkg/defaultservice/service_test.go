@@ -0,0 +1,155 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/libs/documents"
+	"github.com/apache/incubator-milagro-dta/libs/ipfs"
+	"github.com/apache/incubator-milagro-dta/libs/keystore"
+	"github.com/apache/incubator-milagro-dta/libs/logger"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+)
+
+// testNetwork is a set of nodes sharing the same IPFS network
+type testNetwork struct {
+	t      *testing.T
+	ipfs   ipfs.Connector
+	stores []*datastore.Store
+	files  []string
+}
+
+func newTestNetwork(t *testing.T) *testNetwork {
+	ipfsConnector, err := ipfs.NewMemoryConnector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testNetwork{
+		t:    t,
+		ipfs: ipfsConnector,
+	}
+}
+
+// close closes the datastores of the nodes and deletes their files
+func (n *testNetwork) close() {
+	for _, store := range n.stores {
+		store.Close()
+	}
+	for _, file := range n.files {
+		os.Remove(file)
+	}
+}
+
+// node creates a node with a new identity
+func (n *testNetwork) node(name string, options ...ServiceOption) *Service {
+	n.t.Helper()
+
+	_, rawIDDoc, seed, err := identity.CreateIdentity(name)
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	keyStore, err := keystore.NewMemoryStore()
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	nodeID, err := identity.StoreIdentity(rawIDDoc, seed, n.ipfs, keyStore)
+	if err != nil {
+		n.t.Fatal(err)
+	}
+
+	dbName := filepath.Join(os.TempDir(), fmt.Sprintf("milagro-test-%s-%v.db", name, time.Now().UnixNano()))
+	n.files = append(n.files, dbName)
+	backend, err := datastore.NewBoltBackend(dbName)
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	store, err := datastore.NewStore(datastore.WithBackend(backend), datastore.WithCodec(datastore.NewGOBCodec()))
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	n.stores = append(n.stores, store)
+
+	log, err := logger.NewLogger("none", "none")
+	if err != nil {
+		n.t.Fatal(err)
+	}
+
+	s := NewService()
+	s.SetNodeID(nodeID)
+	options = append([]ServiceOption{
+		WithLogger(log),
+		WithRng(rand.Reader),
+		WithDataStore(store),
+		WithKeyStore(keyStore),
+		WithIPFS(n.ipfs),
+	}, options...)
+	if err := s.Init(s, options...); err != nil {
+		n.t.Fatal(err)
+	}
+	return s
+}
+
+// withMasterFiduciary sets the fiduciary of the single fiduciary orders
+func withMasterFiduciary(fiduciary *Service) ServiceOption {
+	return func(s *Service) error {
+		s.SetMasterFiduciaryNodeID(fiduciary.NodeID())
+		return WithMasterFiduciary(fiduciaryClient{fiduciary})(s)
+	}
+}
+
+// withFiduciaries sets the fiduciaries of the k-of-n orders
+func withFiduciaries(threshold int, fiduciaries ...*Service) ServiceOption {
+	clients := map[string]api.ClientService{}
+	for _, fiduciary := range fiduciaries {
+		clients[fiduciary.NodeID()] = fiduciaryClient{fiduciary}
+	}
+	return WithFiduciaries(clients, threshold)
+}
+
+// fiduciaryClient calls the fiduciary service in process
+type fiduciaryClient struct {
+	*Service
+}
+
+func (c fiduciaryClient) Status(token string) (*api.StatusResponse, error) {
+	return c.Service.Status("v1", "fiduciary")
+}
+
+// writeOrderPart1 writes an order part 1 of the principal for the fiduciary
+func writeOrderPart1(t *testing.T, principal, fiduciary *Service, order *documents.OrderDoc) string {
+	t.Helper()
+
+	recipientList, err := common.BuildRecipientList(principal.Ipfs, principal.NodeID(), fiduciary.NodeID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderPart1CID, err := common.WriteOrderToIPFS(principal.NodeID(), principal.Ipfs, principal.Store, principal.KeyStore, principal.NodeID(), order, recipientList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return orderPart1CID
+}
@@ -0,0 +1,212 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/libs/documents"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/pkg/errors"
+)
+
+// isThreshold returns true when the orders are shared across multiple fiduciaries
+func (s *Service) isThreshold() bool {
+	return len(s.Fiduciaries) > 0 && s.fiduciaryThreshold > 0
+}
+
+// fiduciaryCIDs returns the configured fiduciary node CIDs in a stable order
+func (s *Service) fiduciaryCIDs() []string {
+	fiduciaryCIDs := make([]string, 0, len(s.Fiduciaries))
+	for fiduciaryCID := range s.Fiduciaries {
+		fiduciaryCIDs = append(fiduciaryCIDs, fiduciaryCID)
+	}
+	sort.Strings(fiduciaryCIDs)
+	return fiduciaryCIDs
+}
+
+// shareIndex returns the position of the fiduciary in a k-of-n order, the shares are dealt from 1
+func shareIndex(order *documents.OrderDoc, fiduciaryCID string) (int, error) {
+	if order.Threshold < 1 || order.Threshold > int64(len(order.FiduciaryCIDs)) {
+		return 0, errors.Errorf("Invalid threshold %v of %v", order.Threshold, len(order.FiduciaryCIDs))
+	}
+	index := 0
+	for i, cid := range order.FiduciaryCIDs {
+		if containsCID(order.FiduciaryCIDs[:i], cid) {
+			return 0, errors.Errorf("Duplicated fiduciary %s", cid)
+		}
+		if cid == fiduciaryCID {
+			index = i + 1
+		}
+	}
+	if index == 0 {
+		return 0, errors.Errorf("Not a fiduciary of the order")
+	}
+	return index, nil
+}
+
+// dealOrderShares generates the secret of a k-of-n order and deals its shares, it runs on the first fiduciary of the order
+// The node keeps its own share, the other shares are readable only by the fiduciary they're dealt to
+// The secret is held by the node only while it's dealt, it isn't kept
+func (s *Service) dealOrderShares(order *documents.OrderDoc, blsSK []byte) (commitmentPublicKey string, shareCommitments [][]byte, shareDeals []*documents.ShareDeal, err error) {
+	commitmentPublicKey, shareCommitments, shares, err := common.DealSecretShares(s.Rng, int(order.Threshold), len(order.FiduciaryCIDs))
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	for i, fiduciaryCID := range order.FiduciaryCIDs {
+		if fiduciaryCID == s.NodeID() {
+			if err := common.StoreSecretShare(s.Store, order.Reference, shares[i]); err != nil {
+				return "", nil, nil, err
+			}
+			continue
+		}
+
+		fiduciaryIDDoc, err := common.RetrieveIDDocFromIPFS(s.Ipfs, fiduciaryCID)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		recipientList := map[string]*documents.IDDoc{
+			fiduciaryCID: fiduciaryIDDoc,
+		}
+		dealCID, err := common.CreateAndStoreShareDeal(s.Ipfs, blsSK, order, shares[i], commitmentPublicKey, shareCommitments, s.NodeID(), recipientList)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		shareDeals = append(shareDeals, &documents.ShareDeal{
+			FiduciaryCID: fiduciaryCID,
+			DealCID:      dealCID,
+		})
+	}
+	return commitmentPublicKey, shareCommitments, shareDeals, nil
+}
+
+// receiveOrderShare verifies and keeps the share dealt to the node by the first fiduciary of a k-of-n order
+// It returns the commitments of the dealer, the node commits to the same secret
+func (s *Service) receiveOrderShare(order *documents.OrderDoc, shareDealCID string, sikeSK []byte) (commitmentPublicKey string, shareCommitments [][]byte, err error) {
+	nodeID := s.NodeID()
+	index, err := shareIndex(order, nodeID)
+	if err != nil {
+		return "", nil, err
+	}
+	if shareDealCID == "" {
+		return "", nil, errors.Wrap(common.ErrInvalidShare, "no share deal")
+	}
+
+	deal, dealerCID, err := common.RetrieveSignedOrderFromIPFS(s.Ipfs, shareDealCID, sikeSK, nodeID)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "Share deal %s", shareDealCID)
+	}
+	//The deal must be written by the dealer of this order
+	if dealerCID != order.FiduciaryCIDs[0] || deal.Reference != order.Reference || deal.PrincipalCID != order.PrincipalCID ||
+		deal.Threshold != order.Threshold || !equalCIDs(deal.FiduciaryCIDs, order.FiduciaryCIDs) {
+		return "", nil, errors.Wrapf(common.ErrInvalidShare, "share deal %s", shareDealCID)
+	}
+	if deal.OrderPart2 == nil || deal.OrderPart2.CommitmentPublicKey == "" || len(deal.OrderPart2.ShareCommitments) != len(order.FiduciaryCIDs) {
+		return "", nil, errors.Wrapf(common.ErrInvalidShare, "share deal %s", shareDealCID)
+	}
+	if err := common.VerifySecretShare(deal.Share, deal.OrderPart2.ShareCommitments[index-1]); err != nil {
+		return "", nil, errors.Wrapf(err, "share deal %s", shareDealCID)
+	}
+
+	if err := common.StoreSecretShare(s.Store, order.Reference, deal.Share); err != nil {
+		return "", nil, err
+	}
+	return deal.OrderPart2.CommitmentPublicKey, deal.OrderPart2.ShareCommitments, nil
+}
+
+// orderSecretShare returns the node share of a k-of-n order
+// The share is kept from the order fulfilment, it's never dealt again once destroyed
+func (s *Service) orderSecretShare(reference string) (*documents.SecretShare, error) {
+	share, err := common.RetrieveSecretShare(s.Store, reference)
+	if err == datastore.ErrKeyNotFound {
+		return nil, errors.Wrap(common.ErrInvalidShare, "order share destroyed")
+	}
+	return share, err
+}
+
+// dealerCID returns the fiduciary that deals the shares of a k-of-n order
+func (rec *orderRecord) dealerCID() string {
+	return rec.fiduciaryCIDs()[0]
+}
+
+// shareDealCID returns the share dealt to the fiduciary by the dealer, empty for the dealer
+func (rec *orderRecord) shareDealCID(fiduciaryCID string) string {
+	if rec.Threshold == 0 || fiduciaryCID == rec.dealerCID() {
+		return ""
+	}
+	return rec.Fiduciaries[rec.dealerCID()].ShareDeals[fiduciaryCID]
+}
+
+// checkShareOrderPart2 checks the fiduciary committed to the secret of the order
+// The dealer must deal a share to every other fiduciary, the others must commit to the dealt secret
+func (rec *orderRecord) checkShareOrderPart2(fiduciaryCID string, orderPart2 *documents.OrderDoc) (map[string]string, error) {
+	part2 := orderPart2.OrderPart2
+	if part2.CommitmentPublicKey == "" || len(part2.ShareCommitments) != len(rec.Fiduciaries) {
+		return nil, common.ErrInvalidShare
+	}
+
+	if fiduciaryCID != rec.dealerCID() {
+		if part2.CommitmentPublicKey != rec.CommitmentPublicKey || len(part2.ShareDeals) != 0 {
+			return nil, errors.Wrap(common.ErrInvalidShare, "commitments don't match the order")
+		}
+		for i, shareCommitment := range rec.ShareCommitments {
+			if !bytes.Equal(part2.ShareCommitments[i], shareCommitment) {
+				return nil, errors.Wrap(common.ErrInvalidShare, "commitments don't match the order")
+			}
+		}
+		return nil, nil
+	}
+
+	if len(part2.ShareDeals) != len(rec.Fiduciaries)-1 {
+		return nil, common.ErrInvalidShare
+	}
+	shareDeals := map[string]string{}
+	for _, deal := range part2.ShareDeals {
+		if _, ok := rec.Fiduciaries[deal.FiduciaryCID]; !ok || deal.FiduciaryCID == fiduciaryCID || shareDeals[deal.FiduciaryCID] != "" {
+			return nil, common.ErrInvalidShare
+		}
+		shareDeals[deal.FiduciaryCID] = deal.DealCID
+	}
+	return shareDeals, nil
+}
+
+// checkFiduciaryShare checks a fiduciary share against the commitment of the dealer
+func (rec *orderRecord) checkFiduciaryShare(fiduciaryCID string, share *documents.SecretShare) error {
+	for i, cid := range rec.fiduciaryCIDs() {
+		if cid == fiduciaryCID && i < len(rec.ShareCommitments) {
+			return common.VerifySecretShare(share, rec.ShareCommitments[i])
+		}
+	}
+	return common.ErrInvalidShare
+}
+
+// equalCIDs returns true if both lists have the same CIDs in the same order
+func equalCIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
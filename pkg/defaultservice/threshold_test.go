@@ -0,0 +1,229 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-milagro-dta/libs/cryptowallet"
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/libs/documents"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/pkg/errors"
+)
+
+func TestThresholdOrder(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciaries := []*Service{n.node("fiduciary1"), n.node("fiduciary2"), n.node("fiduciary3")}
+	principal := n.node("principal", withFiduciaries(2, fiduciaries...))
+
+	orderResponse, err := principal.Order(&api.OrderRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reference := orderResponse.OrderReference
+
+	//No node keeps the secret, each fiduciary keeps its share from the fulfilment
+	if _, err := common.RetrieveSecretShare(principal.Store, reference); err != datastore.ErrKeyNotFound {
+		t.Fatalf("order share kept by the principal: %v", err)
+	}
+	rec, err := principal.loadOrderRecord(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares := make([]*documents.SecretShare, len(fiduciaries))
+	for i, node := range append([]*Service{principal}, fiduciaries...) {
+		if err := node.Store.Get("keySeed", reference, new(string)); err != datastore.ErrKeyNotFound {
+			t.Fatalf("order seed kept by %s: %v", node.NodeID(), err)
+		}
+		if i == 0 {
+			continue
+		}
+		if shares[i-1], err = common.RetrieveSecretShare(node.Store, reference); err != nil {
+			t.Fatalf("order share of %s: %v", node.NodeID(), err)
+		}
+		if err := rec.checkFiduciaryShare(node.NodeID(), shares[i-1]); err != nil {
+			t.Fatalf("order share of %s: %v", node.NodeID(), err)
+		}
+	}
+
+	secretResponse, err := principal.OrderSecret(&api.OrderSecretRequest{OrderReference: reference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitment, _, err := cryptowallet.PublicKeyFromPrivate(secretResponse.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commitment != orderResponse.Commitment || secretResponse.Commitment != orderResponse.Commitment {
+		t.Fatalf("invalid secret commitment. Expected: %v, found: %v", orderResponse.Commitment, commitment)
+	}
+
+	//Any 2 of the fiduciary shares recover the secret
+	for _, pair := range [][]int{{0, 1}, {0, 2}, {2, 1}} {
+		secret, err := common.RecoverSecretShares(2, []*documents.SecretShare{shares[pair[0]], shares[pair[1]]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secret != secretResponse.Secret {
+			t.Fatalf("invalid secret recovered from shares %v", pair)
+		}
+	}
+	if _, err := common.RecoverSecretShares(2, shares[:1]); err == nil {
+		t.Fatal("secret recovered from a single share")
+	}
+	if _, err := common.RecoverSecretShares(2, []*documents.SecretShare{shares[0], shares[0]}); errors.Cause(err) != common.ErrInvalidShare {
+		t.Fatalf("invalid error. Expected: %v, found: %v", common.ErrInvalidShare, err)
+	}
+
+	//A tampered share doesn't match its commitment
+	tampered := *shares[1]
+	tampered.Y = append([]byte{}, tampered.Y...)
+	tampered.Y[31] ^= 1
+	if err := rec.checkFiduciaryShare(fiduciaries[1].NodeID(), &tampered); errors.Cause(err) != common.ErrInvalidShare {
+		t.Fatalf("invalid error. Expected: %v, found: %v", common.ErrInvalidShare, err)
+	}
+	if err := rec.checkFiduciaryShare(fiduciaries[0].NodeID(), shares[1]); errors.Cause(err) != common.ErrInvalidShare {
+		t.Fatalf("invalid error. Expected: %v, found: %v", common.ErrInvalidShare, err)
+	}
+}
+
+func TestThresholdShareDestroyed(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciaries := []*Service{n.node("fiduciary1"), n.node("fiduciary2"), n.node("fiduciary3")}
+	principal := n.node("principal", withFiduciaries(2, fiduciaries...))
+
+	orderResponse, err := principal.Order(&api.OrderRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reference := orderResponse.OrderReference
+	if err := common.DestroySecretShare(fiduciaries[0].Store, reference); err != nil {
+		t.Fatal(err)
+	}
+
+	//The other fiduciaries still reach the threshold
+	secretResponse, err := principal.OrderSecret(&api.OrderSecretRequest{OrderReference: reference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secretResponse.Commitment != orderResponse.Commitment {
+		t.Fatalf("invalid secret commitment. Expected: %v, found: %v", orderResponse.Commitment, secretResponse.Commitment)
+	}
+
+	//The destroyed share isn't dealt again from the order
+	rec, err := principal.loadOrderRecord(reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fiduciaries[0].FulfillOrderSecret(&api.FulfillOrderSecretRequest{
+		SenderDocumentCID: principal.NodeID(),
+		OrderPart3CID:     rec.Fiduciaries[fiduciaries[0].NodeID()].OrderPart3CID,
+	}); errors.Cause(err) != common.ErrInvalidShare {
+		t.Fatalf("invalid error. Expected: %v, found: %v", common.ErrInvalidShare, err)
+	}
+	if _, err := common.RetrieveSecretShare(fiduciaries[0].Store, reference); err != datastore.ErrKeyNotFound {
+		t.Fatalf("order share restored: %v", err)
+	}
+}
+
+func TestThresholdShareDeal(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciaries := []*Service{n.node("fiduciary1"), n.node("fiduciary2"), n.node("fiduciary3")}
+	principal := n.node("principal", withFiduciaries(2, fiduciaries...))
+
+	orderResponse, err := principal.Order(&api.OrderRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := principal.loadOrderRecord(orderResponse.OrderReference)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Only the dealer writes share deals, each readable by a single fiduciary
+	dealerCID := rec.dealerCID()
+	others := []*Service{}
+	for _, fiduciary := range fiduciaries {
+		if fiduciary.NodeID() != dealerCID {
+			others = append(others, fiduciary)
+		}
+		if len(rec.Fiduciaries[fiduciary.NodeID()].ShareDeals) != 0 && fiduciary.NodeID() != dealerCID {
+			t.Fatalf("share deals written by %s", fiduciary.NodeID())
+		}
+	}
+	if len(rec.Fiduciaries[dealerCID].ShareDeals) != len(others) {
+		t.Fatalf("invalid share deals: %v", rec.Fiduciaries[dealerCID].ShareDeals)
+	}
+	fiduciary := others[0]
+	principalIDDoc, sikeSK, _, _, err := fiduciary.fulfillOrderKeys(principal.NodeID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := common.RetrieveOrderFromIPFS(fiduciary.Ipfs, rec.Fiduciaries[fiduciary.NodeID()].OrderPart1CID, sikeSK, fiduciary.NodeID(), principalIDDoc.BLSPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := fiduciary.receiveOrderShare(order, rec.shareDealCID(others[1].NodeID()), sikeSK); err == nil {
+		t.Fatal("share dealt to another fiduciary accepted")
+	}
+	if _, _, err := fiduciary.receiveOrderShare(order, "", sikeSK); errors.Cause(err) != common.ErrInvalidShare {
+		t.Fatalf("invalid error. Expected: %v, found: %v", common.ErrInvalidShare, err)
+	}
+	if _, _, err := fiduciary.receiveOrderShare(order, rec.shareDealCID(fiduciary.NodeID()), sikeSK); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestThresholdOrderBeneficiary(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciaries := []*Service{n.node("fiduciary1"), n.node("fiduciary2"), n.node("fiduciary3")}
+	beneficiary := n.node("beneficiary")
+	principal := n.node("principal", withFiduciaries(3, fiduciaries...))
+
+	orderResponse, err := principal.Order(&api.OrderRequest{BeneficiaryIDDocumentCID: beneficiary.NodeID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secretResponse, err := principal.OrderSecret(&api.OrderSecretRequest{OrderReference: orderResponse.OrderReference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secretResponse.Secret != "" || len(secretResponse.OrderPart4CIDs) != len(fiduciaries) {
+		t.Fatalf("invalid beneficiary redemption: %+v", secretResponse)
+	}
+
+	redeemResponse, err := beneficiary.RedeemOrder(&api.RedeemOrderRequest{OrderPart4CIDs: secretResponse.OrderPart4CIDs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redeemResponse.Commitment != orderResponse.Commitment {
+		t.Fatalf("invalid secret commitment. Expected: %v, found: %v", orderResponse.Commitment, redeemResponse.Commitment)
+	}
+
+	//The shares of 2 fiduciaries don't recover a 3 of 3 secret
+	_, err = beneficiary.RedeemOrder(&api.RedeemOrderRequest{OrderPart4CIDs: secretResponse.OrderPart4CIDs[:2]})
+	if err == nil {
+		t.Fatal("secret recovered from 2 of 3 shares")
+	}
+}
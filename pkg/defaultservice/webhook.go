@@ -0,0 +1,480 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/crypto"
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/libs/transport"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/config"
+	"github.com/apache/incubator-milagro-dta/pkg/identity"
+	"github.com/apache/incubator-milagro-dta/pkg/service"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultWebhookTimeout = 10 * time.Second
+	// webhookMaxDrain is the size of the response body read to reuse the connection
+	webhookMaxDrain = 1024 * 1024
+
+	// Headers of the webhook notifications
+	webhookNodeHeader      = "X-Milagro-Node"
+	webhookSignatureHeader = "X-Milagro-Signature"
+	webhookHMACHeader      = "X-Milagro-HMAC-SHA256"
+)
+
+var (
+	errWebhookHost = errors.New("webhook host not allowed")
+
+	// webhookPrivateNets are the networks not reachable by the webhooks unless allowed
+	webhookPrivateNets = parseCIDRs(
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"172.16.0.0/12",
+		"192.168.0.0/16",
+		"198.18.0.0/15",
+		"fc00::/7",
+	)
+)
+
+// webhook is a registered receiver of the order events
+type webhook struct {
+	WebhookID string
+	URL       string
+	Events    []string
+	Secret    string
+	// Owner is the OIDC subject that registered the webhook
+	Owner string
+	// Subject restricts the notifications to the orders of an OIDC subject
+	Subject   string
+	CreatedAt int64
+}
+
+// webhookDelivery is a notification waiting in the webhook queue
+type webhookDelivery struct {
+	WebhookID string
+	Body      []byte
+}
+
+// CreateWebhook registers a receiver of the order events
+func (s *Service) CreateWebhook(req *api.CreateWebhookRequest) (*api.CreateWebhookResponse, error) {
+	if err := s.checkWebhookURL(req.URL); err != nil {
+		return nil, errors.Wrap(transport.ErrInvalidRequest, err.Error())
+	}
+
+	w := &webhook{
+		WebhookID: uuid.New().String(),
+		URL:       req.URL,
+		Events:    req.Events,
+		Secret:    req.Secret,
+		Owner:     req.Subject,
+		CreatedAt: time.Now().Unix(),
+	}
+	if req.PerSubject {
+		if req.Subject == "" {
+			return nil, errors.Wrap(transport.ErrInvalidRequest, "no OIDC subject for the webhook")
+		}
+		w.Subject = req.Subject
+	} else if !s.isNodeOperator(req.Subject) {
+		//The node-wide webhooks are notified of the orders of every subject
+		return nil, errors.Wrap(service.ErrNotNodeOperator, "node-wide webhook")
+	}
+
+	if err := s.Store.Set("webhook", w.WebhookID, w, map[string]string{"time": time.Unix(w.CreatedAt, 0).UTC().Format(time.RFC3339)}); err != nil {
+		return nil, errors.Wrap(err, "Save Webhook to store")
+	}
+
+	return &api.CreateWebhookResponse{
+		WebhookID: w.WebhookID,
+	}, nil
+}
+
+// WebhookList returns the webhooks registered by the caller
+func (s *Service) WebhookList(req *api.WebhookListRequest) (*api.WebhookListResponse, error) {
+	webhooks, err := s.webhooks()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &api.WebhookListResponse{
+		Webhooks: []api.Webhook{},
+	}
+	for _, w := range webhooks {
+		if w.Owner != req.Subject {
+			continue
+		}
+		response.Webhooks = append(response.Webhooks, api.Webhook{
+			WebhookID: w.WebhookID,
+			URL:       w.URL,
+			Events:    w.Events,
+			Subject:   w.Subject,
+			HMAC:      w.Secret != "",
+			CreatedAt: w.CreatedAt,
+		})
+	}
+	return response, nil
+}
+
+// DeleteWebhook removes a webhook registered by the caller
+// The notifications already queued are dropped
+func (s *Service) DeleteWebhook(req *api.DeleteWebhookRequest) error {
+	w := &webhook{}
+	if err := s.Store.Get("webhook", req.WebhookID, w); err != nil {
+		return err
+	}
+	// The webhooks of the other subjects are not disclosed
+	if w.Owner != req.Subject {
+		return datastore.ErrKeyNotFound
+	}
+	return s.Store.Del("webhook", req.WebhookID)
+}
+
+// isNodeOperator returns true if the OIDC subject operates the node
+// The subject is empty when the node runs without OIDC
+func (s *Service) isNodeOperator(subject string) bool {
+	return subject == "" || containsCID(s.operators, subject)
+}
+
+// checkWebhookURL accepts only the http and https URLs
+// The hosts resolving to private addresses are rejected again when the notification is delivered
+func (s *Service) checkWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.Errorf("invalid webhook URL scheme: %s", u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return errors.New("no webhook URL host")
+	}
+	if s.webhookConfig.AllowPrivateHosts {
+		return nil
+	}
+	if host == "localhost" {
+		return errWebhookHost
+	}
+	if ip := net.ParseIP(host); ip != nil && !webhookPublicIP(ip) {
+		return errWebhookHost
+	}
+	return nil
+}
+
+// webhookPublicIP returns false for the loopback, private, link-local and multicast addresses
+func webhookPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, n := range webhookPrivateNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// webhookDialControl rejects the connections to the addresses not allowed
+// It is checked after the DNS resolution so a public host name can't point to a private address
+func webhookDialControl(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !webhookPublicIP(ip) {
+		return errors.Wrap(errWebhookHost, address)
+	}
+	return nil
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
+
+// webhooks returns the registered webhooks
+func (s *Service) webhooks() ([]*webhook, error) {
+	keys, err := s.Store.ListKeys("webhook", "time", 0, 0, false)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks := []*webhook{}
+	for _, key := range keys {
+		w := &webhook{}
+		if err := s.Store.Get("webhook", key, w); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, nil
+}
+
+// webhooksQueue is the durable queue of the webhook notifications
+func (s *Service) webhooksQueue() *datastore.Queue {
+	return datastore.NewQueue(s.Store, "webhookQueue")
+}
+
+// notifyOrderEvent publishes the order state to the event streams and queues the
+// notifications to the webhooks
+// The order processing doesn't fail if the event can't be published
+func (s *Service) notifyOrderEvent(rec *orderRecord) {
+	var event string
+	switch rec.State {
+	case api.OrderStateCreated:
+		event = api.WebhookEventOrderCreated
+	case api.OrderStateFulfilled:
+		event = api.WebhookEventOrderFulfilled
+	case api.OrderStateRedeemed:
+		event = api.WebhookEventOrderRedeemed
+	case api.OrderStateFailed:
+		event = api.WebhookEventOrderFailed
+	default:
+		return
+	}
+
+	e := &api.WebhookEvent{
+		EventID:        uuid.New().String(),
+		Event:          event,
+		NodeID:         s.NodeID(),
+		OrderReference: rec.Reference,
+		State:          rec.State,
+		Commitment:     rec.Commitment,
+		Error:          rec.Error,
+		Timestamp:      time.Now().Unix(),
+	}
+
+	if s.orderEvents != nil {
+		if err := s.orderEvents.append(rec.Subject, e); err != nil {
+			s.Logger.Error("Order %s events: %v", rec.Reference, err)
+		}
+	}
+	if err := s.queueOrderEvent(rec.Subject, e); err != nil {
+		s.Logger.Error("Order %s webhooks: %v", rec.Reference, err)
+	}
+}
+
+func (s *Service) queueOrderEvent(subject string, e *api.WebhookEvent) error {
+	webhooks, err := s.webhooks()
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	queue := s.webhooksQueue()
+	for _, w := range webhooks {
+		if !webhookMatches(w, e.Event, subject) {
+			continue
+		}
+
+		if body == nil {
+			if body, err = json.Marshal(e); err != nil {
+				return err
+			}
+		}
+
+		payload, err := json.Marshal(&webhookDelivery{WebhookID: w.WebhookID, Body: body})
+		if err != nil {
+			return err
+		}
+		if err := queue.Push(e.EventID+"/"+w.WebhookID, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// webhookMatches returns true if the webhook is registered for the event of the subject orders
+func webhookMatches(w *webhook, event, subject string) bool {
+	if w.Subject != "" && w.Subject != subject {
+		return false
+	}
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// RunWebhookQueue delivers the queued notifications until stop is closed
+func (s *Service) RunWebhookQueue(stop <-chan struct{}) {
+	pollInterval := s.queueConfig.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultQueuePollInterval
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.processWebhookQueue()
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processWebhookQueue delivers the notifications due for another attempt
+func (s *Service) processWebhookQueue() {
+	queue := s.webhooksQueue()
+	items, err := queue.Due(time.Now(), 0)
+	if err != nil {
+		s.Logger.Error("Webhook queue: %v", err)
+		return
+	}
+	if len(items) == 0 {
+		return
+	}
+
+	// BLS key
+	keyseed, err := s.KeyStore.Get("seed")
+	if err != nil {
+		s.Logger.Error("Webhook queue: %v", err)
+		return
+	}
+	_, blsSK, err := identity.GenerateBLSKeys(keyseed)
+	if err != nil {
+		s.Logger.Error("Webhook queue: %v", err)
+		return
+	}
+
+	for _, item := range items {
+		if err := s.processQueuedWebhook(queue, item, blsSK); err != nil {
+			s.Logger.Error("Webhook queue %s: %v", item.Key, err)
+		}
+	}
+}
+
+func (s *Service) processQueuedWebhook(queue *datastore.Queue, item *datastore.QueueItem, blsSK []byte) error {
+	delivery := &webhookDelivery{}
+	if err := json.Unmarshal(item.Payload, delivery); err != nil {
+		return queue.Done(item.Key)
+	}
+
+	w := &webhook{}
+	switch err := s.Store.Get("webhook", delivery.WebhookID, w); err {
+	case nil:
+	case datastore.ErrKeyNotFound:
+		//The webhook was deleted
+		return queue.Done(item.Key)
+	default:
+		return err
+	}
+
+	if err := s.deliverWebhook(w, delivery.Body, blsSK); err != nil {
+		maxAttempts := s.webhookConfig.MaxAttempts
+		if maxAttempts > 0 && item.Attempts+1 >= maxAttempts {
+			s.Logger.Error("Webhook %s dropped after %v attempts: %v", item.Key, item.Attempts+1, err)
+			return queue.Done(item.Key)
+		}
+		return queue.Retry(item, s.queueRetryDelay(item.Attempts), err)
+	}
+	return queue.Done(item.Key)
+}
+
+// deliverWebhook posts the signed notification to the webhook
+func (s *Service) deliverWebhook(w *webhook, body, blsSK []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookNodeHeader, s.NodeID())
+
+	rc, signature := crypto.BLSSign(body, blsSK)
+	if rc != 0 {
+		return errors.Errorf("Failed to sign notification: %v", rc)
+	}
+	req.Header.Set(webhookSignatureHeader, hex.EncodeToString(signature))
+	if w.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.Secret))
+		mac.Write(body)
+		req.Header.Set(webhookHMACHeader, hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	//The body is drained so the connection is kept for the next notification
+	defer func() {
+		io.Copy(ioutil.Discard, io.LimitReader(resp.Body, webhookMaxDrain))
+		resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("Webhook response %s", resp.Status)
+	}
+	return nil
+}
+
+// newWebhookClient returns the HTTP client of the notifications
+// It's created once so the connections are reused between the deliveries
+// The redirections are dialed through the same checks
+func newWebhookClient(cfg config.WebhookConfig) *http.Client {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	if cfg.AllowPrivateHosts {
+		return &http.Client{Timeout: timeout}
+	}
+
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: webhookDialControl,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+	}
+}
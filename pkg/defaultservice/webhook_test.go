@@ -0,0 +1,307 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package defaultservice
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-milagro-dta/libs/crypto"
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
+	"github.com/apache/incubator-milagro-dta/libs/transport"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
+	"github.com/apache/incubator-milagro-dta/pkg/config"
+	"github.com/apache/incubator-milagro-dta/pkg/service"
+	"github.com/pkg/errors"
+)
+
+// withWebhooks sets the webhook settings and the node operators
+func withWebhooks(cfg config.WebhookConfig, operators ...string) ServiceOption {
+	return func(s *Service) error {
+		s.webhookConfig = cfg
+		s.operators = operators
+		return nil
+	}
+}
+
+func TestWebhookOwner(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	node := n.node("node")
+
+	create := func(subject string) string {
+		t.Helper()
+
+		res, err := node.CreateWebhook(&api.CreateWebhookRequest{URL: "https://example.com/" + subject, PerSubject: true, Subject: subject})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.WebhookID
+	}
+	aliceID := create("alice")
+	bobID := create("bob")
+
+	list, err := node.WebhookList(&api.WebhookListRequest{Subject: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Webhooks) != 1 || list.Webhooks[0].WebhookID != aliceID {
+		t.Fatalf("invalid webhooks of alice: %v", list.Webhooks)
+	}
+
+	//The webhooks of the other subjects can't be deleted
+	if err := node.DeleteWebhook(&api.DeleteWebhookRequest{WebhookID: bobID, Subject: "alice"}); err != datastore.ErrKeyNotFound {
+		t.Fatalf("invalid error. Expected: %v, found: %v", datastore.ErrKeyNotFound, err)
+	}
+	if err := node.DeleteWebhook(&api.DeleteWebhookRequest{WebhookID: bobID, Subject: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err = node.WebhookList(&api.WebhookListRequest{Subject: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Webhooks) != 0 {
+		t.Fatalf("invalid webhooks of bob: %v", list.Webhooks)
+	}
+}
+
+func TestWebhookNodeOperator(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	node := n.node("node", withWebhooks(config.WebhookConfig{}, "operator"))
+
+	//Only the operators are notified of the orders of every subject
+	_, err := node.CreateWebhook(&api.CreateWebhookRequest{URL: "https://example.com/events", Subject: "alice"})
+	if errors.Cause(err) != service.ErrNotNodeOperator {
+		t.Fatalf("invalid error. Expected: %v, found: %v", service.ErrNotNodeOperator, err)
+	}
+	for _, req := range []*api.CreateWebhookRequest{
+		{URL: "https://example.com/events", PerSubject: true, Subject: "alice"},
+		{URL: "https://example.com/events", Subject: "operator"},
+		{URL: "https://example.com/events"},
+	} {
+		if _, err := node.CreateWebhook(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWebhookURL(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	node := n.node("node")
+
+	for _, u := range []string{
+		"ftp://example.com/events",
+		"file:///etc/passwd",
+		"https:///events",
+		"http://localhost:8080/events",
+		"http://127.0.0.1/events",
+		"http://[::1]/events",
+		"http://10.1.2.3/events",
+		"http://172.16.0.1/events",
+		"http://192.168.1.1/events",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[fd00::1]/events",
+		"http://0.0.0.0/events",
+	} {
+		_, err := node.CreateWebhook(&api.CreateWebhookRequest{URL: u})
+		if errors.Cause(err) != transport.ErrInvalidRequest {
+			t.Fatalf("invalid error for %s. Expected: %v, found: %v", u, transport.ErrInvalidRequest, err)
+		}
+	}
+
+	if _, err := node.CreateWebhook(&api.CreateWebhookRequest{URL: "https://93.184.216.34/events"}); err != nil {
+		t.Fatal(err)
+	}
+
+	node.webhookConfig.AllowPrivateHosts = true
+	if _, err := node.CreateWebhook(&api.CreateWebhookRequest{URL: "http://127.0.0.1/events"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWebhookClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	//The host resolved to a private address is rejected when dialed
+	_, err := newWebhookClient(config.WebhookConfig{Timeout: time.Second}).Get(server.URL)
+	if err == nil {
+		t.Fatal("private address dialed")
+	}
+	if opErr, ok := err.(*url.Error).Err.(*net.OpError); !ok || errors.Cause(opErr.Err) != errWebhookHost {
+		t.Fatalf("invalid error. Expected: %v, found: %v", errWebhookHost, err)
+	}
+
+	resp, err := newWebhookClient(config.WebhookConfig{Timeout: time.Second, AllowPrivateHosts: true}).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary), withWebhooks(config.WebhookConfig{AllowPrivateHosts: true, MaxAttempts: 2}, "alice"))
+	principal.queueConfig.RetryInterval = time.Nanosecond
+
+	var mutex sync.Mutex
+	var requests []*http.Request
+	var bodies [][]byte
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	secret := "0123456789abcdef"
+	if _, err := principal.CreateWebhook(&api.CreateWebhookRequest{
+		URL:     server.URL,
+		Events:  []string{api.WebhookEventOrderFulfilled},
+		Secret:  secret,
+		Subject: "alice",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	//The orders of the other subjects are not notified
+	if _, err := principal.CreateWebhook(&api.CreateWebhookRequest{URL: server.URL, PerSubject: true, Subject: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+
+	orderResponse, err := principal.Order(&api.OrderRequest{Subject: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	principal.processWebhookQueue()
+
+	if len(requests) != 1 {
+		t.Fatalf("invalid notifications. Expected: 1, found: %v", len(requests))
+	}
+	r, body := requests[0], bodies[0]
+	event := &api.WebhookEvent{}
+	if err := json.Unmarshal(body, event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Event != api.WebhookEventOrderFulfilled || event.OrderReference != orderResponse.OrderReference || event.Commitment != orderResponse.Commitment {
+		t.Fatalf("invalid event: %v", event)
+	}
+	if r.Header.Get(webhookNodeHeader) != principal.NodeID() {
+		t.Fatalf("invalid node. Expected: %v, found: %v", principal.NodeID(), r.Header.Get(webhookNodeHeader))
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if r.Header.Get(webhookHMACHeader) != hex.EncodeToString(mac.Sum(nil)) {
+		t.Fatal("invalid HMAC")
+	}
+	idDoc, err := common.RetrieveIDDocFromIPFS(principal.Ipfs, principal.NodeID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := hex.DecodeString(r.Header.Get(webhookSignatureHeader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc := crypto.BLSVerify(body, idDoc.BLSPublicKey, signature); rc != 0 {
+		t.Fatalf("invalid signature: %v", rc)
+	}
+
+	//The failed notification is dropped after the last attempt
+	status = http.StatusInternalServerError
+	if _, err := principal.Order(&api.OrderRequest{Subject: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	for attempt := 1; attempt <= 2; attempt++ {
+		principal.processWebhookQueue()
+		if len(requests) != 1+attempt {
+			t.Fatalf("invalid notifications. Expected: %v, found: %v", 1+attempt, len(requests))
+		}
+	}
+	items, err := principal.webhooksQueue().Due(time.Now().Add(time.Hour), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 0 {
+		t.Fatalf("invalid queued notifications: %v", len(items))
+	}
+}
+
+func TestWebhookConnectionReused(t *testing.T) {
+	n := newTestNetwork(t)
+	defer n.close()
+	fiduciary := n.node("fiduciary")
+	principal := n.node("principal", withMasterFiduciary(fiduciary), withWebhooks(config.WebhookConfig{AllowPrivateHosts: true}))
+
+	var mutex sync.Mutex
+	var connections, notifications int
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		notifications++
+		mutex.Unlock()
+		//The body of the response is read by the node
+		w.Write(bytes.Repeat([]byte(" "), 512*1024))
+	}))
+	server.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mutex.Lock()
+			connections++
+			mutex.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	if _, err := principal.CreateWebhook(&api.CreateWebhookRequest{URL: server.URL, Events: []string{api.WebhookEventOrderFulfilled}}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := principal.Order(&api.OrderRequest{}); err != nil {
+			t.Fatal(err)
+		}
+		principal.processWebhookQueue()
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if notifications != 3 {
+		t.Fatalf("invalid notifications. Expected: 3, found: %v", notifications)
+	}
+	if connections != 1 {
+		t.Fatalf("invalid connections. Expected: 1, found: %v", connections)
+	}
+}
@@ -0,0 +1,140 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/apache/incubator-milagro-dta/libs/audit"
+	"github.com/apache/incubator-milagro-dta/libs/logger"
+	"github.com/apache/incubator-milagro-dta/libs/transport"
+	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/service"
+	"github.com/go-kit/kit/endpoint"
+)
+
+// auditEndpoint records every call of a custody operation in the audit log
+func auditEndpoint(svc service.Service, logger *logger.Logger, operation string, next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		response, err := next(ctx, request)
+
+		entry := &audit.Entry{
+			Operation: operation,
+			Outcome:   audit.OutcomeSuccess,
+		}
+		if userClaims, cerr := json.Marshal(transport.GetUserInfo(ctx)); cerr == nil {
+			entry.UserClaims = userClaims
+		}
+		entry.OrderReference, entry.CIDs = auditFields(request, response)
+		if err != nil {
+			entry.Outcome = audit.OutcomeFailure
+			entry.Error = err.Error()
+		}
+
+		if aerr := svc.RecordAudit(entry); aerr != nil {
+			logger.Error("Audit %s: %v", operation, aerr)
+		}
+		return response, err
+	}
+}
+
+// auditFields returns the order reference and the CIDs of the requests and responses
+func auditFields(values ...interface{}) (reference string, cids []string) {
+	setReference := func(r string) {
+		if reference == "" {
+			reference = r
+		}
+	}
+	addCIDs := func(c ...string) {
+		for _, cid := range c {
+			if cid != "" {
+				cids = append(cids, cid)
+			}
+		}
+	}
+
+	for _, v := range values {
+		switch v := v.(type) {
+		case *api.OrderRequest:
+			addCIDs(v.BeneficiaryIDDocumentCID, v.PolicyCID)
+		case *api.OrderResponse:
+			setReference(v.OrderReference)
+		case *api.BatchOrderRequest:
+			for _, o := range v.Orders {
+				addCIDs(o.BeneficiaryIDDocumentCID, o.PolicyCID)
+			}
+		case *api.BatchOrderResponse:
+			//The batch has no single order reference, the orders created are kept with the CIDs
+			for _, o := range v.Orders {
+				addCIDs(o.OrderReference)
+			}
+		case *api.OrderSecretRequest:
+			setReference(v.OrderReference)
+			addCIDs(v.BeneficiaryIDDocumentCID)
+		case *api.OrderSecretResponse:
+			setReference(v.OrderReference)
+			addCIDs(v.OrderPart4CIDs...)
+		case *api.ResumeOrderResponse:
+			setReference(v.OrderReference)
+			addCIDs(v.OrderPart4CIDs...)
+		case *api.CancelOrderRequest:
+			setReference(v.OrderReference)
+		case *api.CancelOrderResponse:
+			setReference(v.OrderReference)
+			addCIDs(v.OrderTombstoneCIDs...)
+		case *api.RedeemOrderRequest:
+			addCIDs(v.OrderPart4CIDs...)
+		case *api.RedeemOrderResponse:
+			setReference(v.OrderReference)
+		case *api.ApproveOrderRequest:
+			setReference(v.OrderReference)
+			addCIDs(v.OrderPart2CID, v.FiduciaryCID)
+		case *api.FulfillOrderRequest:
+			addCIDs(v.OrderPart1CID, v.DocumentCID)
+		case *api.FulfillOrderResponse:
+			setReference(v.OrderReference)
+			addCIDs(v.OrderPart2CID)
+		case *api.FulfillOrderBatchRequest:
+			addCIDs(v.DocumentCID)
+			for _, o := range v.Orders {
+				addCIDs(o.OrderPart1CID)
+			}
+		case *api.FulfillOrderBatchResponse:
+			for _, o := range v.Orders {
+				addCIDs(o.OrderReference, o.OrderPart2CID)
+			}
+		case *api.FulfillOrderSecretRequest:
+			addCIDs(v.OrderPart3CID, v.SenderDocumentCID)
+		case *api.FulfillOrderSecretResponse:
+			setReference(v.OrderReference)
+			addCIDs(v.OrderPart4CID)
+		case *api.FulfillOrderCancelRequest:
+			addCIDs(v.OrderCancelCID, v.SenderDocumentCID)
+		case *api.FulfillOrderCancelResponse:
+			setReference(v.OrderReference)
+			addCIDs(v.OrderTombstoneCID)
+		case *api.FulfillOrderApproveRequest:
+			setReference(v.OrderReference)
+			addCIDs(v.OrderPart2CID, v.ApproverCID)
+		case *api.CreatePolicyResponse:
+			addCIDs(v.PolicyCID)
+		}
+	}
+	return reference, cids
+}
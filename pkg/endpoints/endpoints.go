@@ -28,12 +28,15 @@ package endpoints
 import (
 	"context"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
+	"github.com/apache/incubator-milagro-dta/libs/datastore"
 	"github.com/apache/incubator-milagro-dta/libs/logger"
 	"github.com/apache/incubator-milagro-dta/libs/transport"
 	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/apache/incubator-milagro-dta/pkg/common"
 	"github.com/apache/incubator-milagro-dta/pkg/service"
 	"github.com/go-kit/kit/endpoint"
 	"github.com/pkg/errors"
@@ -45,12 +48,56 @@ var (
 )
 
 // Endpoints returns all the exported endpoints
-func Endpoints(svc service.Service, corsAllow string, authorizer transport.Authorizer, logger *logger.Logger, nodeType string, pluginEndpoints service.Endpoints) transport.HTTPEndpoints {
+// The fulfill endpoints called by the other nodes are authorized with nodeAuthorizer
+func Endpoints(svc service.Service, corsAllow string, authorizer transport.Authorizer, nodeAuthorizer transport.RequestAuthorizer, logger *logger.Logger, nodeType string, pluginEndpoints service.Endpoints) transport.HTTPEndpoints {
+	identityEndpoints := transport.HTTPEndpoints{
+		"CreateIdentity": {
+			Path:        "/" + apiVersion + "/identity",
+			Method:      http.MethodPost,
+			Endpoint:    MakeCreateIdentityEndpoint(svc),
+			NewRequest:  func() interface{} { return &api.CreateIdentityRequest{} },
+			NewResponse: func() interface{} { return &api.CreateIdentityResponse{} },
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeOIDC(authorizer, false),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+			},
+		},
+		"GetIdentity": {
+			Path:        "/" + apiVersion + "/identity/{IDDocumentCID}",
+			Method:      http.MethodGet,
+			Endpoint:    MakeGetIdentityEndpoint(svc),
+			NewResponse: func() interface{} { return &api.GetIdentityResponse{} },
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeOIDC(authorizer, false),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+			},
+		},
+		"IdentityList": {
+			Path:        "/" + apiVersion + "/identity",
+			Method:      http.MethodGet,
+			Endpoint:    MakeIdentityListEndpoint(svc),
+			NewResponse: func() interface{} { return &api.IdentityListResponse{} },
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeOIDC(authorizer, false),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+				datastore.ErrInvalidCursor:  http.StatusUnprocessableEntity,
+			},
+		},
+	}
 	principalEndpoints := transport.HTTPEndpoints{
 		"Order": {
 			Path:        "/" + apiVersion + "/order",
 			Method:      http.MethodPost,
-			Endpoint:    MakeOrderEndpoint(svc),
+			Endpoint:    auditEndpoint(svc, logger, "Order", MakeOrderEndpoint(svc)),
 			NewRequest:  func() interface{} { return &api.OrderRequest{} },
 			NewResponse: func() interface{} { return &api.OrderResponse{} },
 			Options: transport.ServerOptions(
@@ -59,12 +106,28 @@ func Endpoints(svc service.Service, corsAllow string, authorizer transport.Autho
 			),
 			ErrStatus: transport.ErrorStatus{
 				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+				service.ErrOrderExpired:     http.StatusUnprocessableEntity,
+				service.ErrPolicyViolation:  http.StatusForbidden,
 			},
 			// ErrStatus: transport.ErrorStatus{
 			// 	transport.ErrInvalidRequest:        http.StatusUnprocessableEntity,
 			// 	ErrCreatingOrderDoc: http.StatusInternalServerError,
 			// },
 		},
+		"BatchOrder": {
+			Path:        "/" + apiVersion + "/order/batch",
+			Method:      http.MethodPost,
+			Endpoint:    auditEndpoint(svc, logger, "BatchOrder", MakeBatchOrderEndpoint(svc)),
+			NewRequest:  func() interface{} { return &api.BatchOrderRequest{} },
+			NewResponse: func() interface{} { return &api.BatchOrderResponse{} },
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeOIDC(authorizer, false),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+			},
+		},
 		"GetOrder": {
 			Path:        "/" + apiVersion + "/order/{OrderReference}",
 			Method:      http.MethodGet,
@@ -89,18 +152,151 @@ func Endpoints(svc service.Service, corsAllow string, authorizer transport.Autho
 			),
 			ErrStatus: transport.ErrorStatus{
 				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+				datastore.ErrInvalidCursor:  http.StatusUnprocessableEntity,
 			},
 		},
 		"OrderSecret": {
 			Path:        "/" + apiVersion + "/order/secret",
 			Method:      http.MethodPost,
-			Endpoint:    MakeOrderSecretEndpoint(svc),
+			Endpoint:    auditEndpoint(svc, logger, "OrderSecret", MakeOrderSecretEndpoint(svc)),
 			NewRequest:  func() interface{} { return &api.OrderSecretRequest{} },
 			NewResponse: func() interface{} { return &api.OrderSecretResponse{} },
 			Options: transport.ServerOptions(
 				transport.SetCors(corsAllow),
 				transport.AuthorizeOIDC(authorizer, false),
 			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+				service.ErrOrderState:       http.StatusConflict,
+				service.ErrOrderNotYetValid: http.StatusForbidden,
+				service.ErrOrderExpired:     http.StatusGone,
+			},
+		},
+		"VerifyOrder": {
+			Path:        "/" + apiVersion + "/order/{OrderReference}/verify",
+			Method:      http.MethodGet,
+			Endpoint:    MakeVerifyOrderEndpoint(svc),
+			NewResponse: func() interface{} { return &api.VerifyOrderResponse{} },
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeOIDC(authorizer, false),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+				datastore.ErrKeyNotFound:    http.StatusNotFound,
+			},
+		},
+		"ResumeOrder": {
+			Path:        "/" + apiVersion + "/order/{OrderReference}/resume",
+			Method:      http.MethodPost,
+			Endpoint:    auditEndpoint(svc, logger, "ResumeOrder", MakeResumeOrderEndpoint(svc)),
+			NewResponse: func() interface{} { return &api.ResumeOrderResponse{} },
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeOIDC(authorizer, false),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+				service.ErrOrderState:       http.StatusConflict,
+			},
+		},
+		"CancelOrder": {
+			Path:        "/" + apiVersion + "/order/{OrderReference}/cancel",
+			Method:      http.MethodPost,
+			Endpoint:    auditEndpoint(svc, logger, "CancelOrder", MakeCancelOrderEndpoint(svc)),
+			NewRequest:  func() interface{} { return &api.CancelOrderRequest{} },
+			NewResponse: func() interface{} { return &api.CancelOrderResponse{} },
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeOIDC(authorizer, false),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+				service.ErrOrderState:       http.StatusConflict,
+			},
+		},
+		"RedeemOrder": {
+			Path:        "/" + apiVersion + "/order/redeem",
+			Method:      http.MethodPost,
+			Endpoint:    auditEndpoint(svc, logger, "RedeemOrder", MakeRedeemOrderEndpoint(svc)),
+			NewRequest:  func() interface{} { return &api.RedeemOrderRequest{} },
+			NewResponse: func() interface{} { return &api.RedeemOrderResponse{} },
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeOIDC(authorizer, false),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+			},
+		},
+		"ApproveOrder": {
+			Path:        "/" + apiVersion + "/order/approve",
+			Method:      http.MethodPost,
+			Endpoint:    auditEndpoint(svc, logger, "ApproveOrder", MakeApproveOrderEndpoint(svc)),
+			NewRequest:  func() interface{} { return &api.ApproveOrderRequest{} },
+			NewResponse: func() interface{} { return &api.ApproveOrderResponse{} },
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeOIDC(authorizer, false),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+			},
+		},
+	}
+	webhookEndpoints := transport.HTTPEndpoints{
+		"CreateWebhook": {
+			Path:        "/" + apiVersion + "/webhook",
+			Method:      http.MethodPost,
+			Endpoint:    MakeCreateWebhookEndpoint(svc),
+			NewRequest:  func() interface{} { return &api.CreateWebhookRequest{} },
+			NewResponse: func() interface{} { return &api.CreateWebhookResponse{} },
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeOIDC(authorizer, false),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+				service.ErrNotNodeOperator:  http.StatusForbidden,
+			},
+		},
+		"WebhookList": {
+			Path:        "/" + apiVersion + "/webhook",
+			Method:      http.MethodGet,
+			Endpoint:    MakeWebhookListEndpoint(svc),
+			NewResponse: func() interface{} { return &api.WebhookListResponse{} },
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeOIDC(authorizer, false),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+			},
+		},
+		"DeleteWebhook": {
+			Path:     "/" + apiVersion + "/webhook/{WebhookID}",
+			Method:   http.MethodDelete,
+			Endpoint: MakeDeleteWebhookEndpoint(svc),
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeOIDC(authorizer, false),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+				datastore.ErrKeyNotFound:    http.StatusNotFound,
+			},
+		},
+	}
+	eventEndpoints := transport.HTTPEndpoints{
+		"OrderEvents": {
+			Path:     "/" + apiVersion + "/events",
+			Method:   http.MethodGet,
+			Endpoint: MakeOrderEventsEndpoint(svc),
+			Stream:   true,
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeOIDC(authorizer, false),
+			),
 			ErrStatus: transport.ErrorStatus{
 				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
 			},
@@ -110,12 +306,32 @@ func Endpoints(svc service.Service, corsAllow string, authorizer transport.Autho
 		"FulfillOrder": {
 			Path:        "/" + apiVersion + "/fulfill/order",
 			Method:      http.MethodPost,
-			Endpoint:    MakeFulfillOrderEndpoint(svc),
+			Endpoint:    auditEndpoint(svc, logger, "FulfillOrder", MakeFulfillOrderEndpoint(svc)),
 			NewRequest:  func() interface{} { return &api.FulfillOrderRequest{} },
 			NewResponse: func() interface{} { return &api.FulfillOrderResponse{} },
 			Options: transport.ServerOptions(
 				transport.SetCors(corsAllow),
-				transport.AuthorizeOIDC(authorizer, false),
+				transport.AuthorizeSignedRequest(nodeAuthorizer),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+				service.ErrOrderCancelled:   http.StatusGone,
+				service.ErrOrderExpired:     http.StatusGone,
+				service.ErrPolicyViolation:  http.StatusForbidden,
+				service.ErrEnvelopeExpired:  http.StatusUnprocessableEntity,
+				service.ErrEnvelopeReplay:   http.StatusConflict,
+				service.ErrOrderConflict:    http.StatusConflict,
+			},
+		},
+		"FulfillOrderBatch": {
+			Path:        "/" + apiVersion + "/fulfill/order/batch",
+			Method:      http.MethodPost,
+			Endpoint:    auditEndpoint(svc, logger, "FulfillOrderBatch", MakeFulfillOrderBatchEndpoint(svc)),
+			NewRequest:  func() interface{} { return &api.FulfillOrderBatchRequest{} },
+			NewResponse: func() interface{} { return &api.FulfillOrderBatchResponse{} },
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeSignedRequest(nodeAuthorizer),
 			),
 			ErrStatus: transport.ErrorStatus{
 				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
@@ -124,9 +340,91 @@ func Endpoints(svc service.Service, corsAllow string, authorizer transport.Autho
 		"FulfillOrderSecret": {
 			Path:        "/" + apiVersion + "/fulfill/order/secret",
 			Method:      http.MethodPost,
-			Endpoint:    MakeFulfillOrderSecretEndpoint(svc),
+			Endpoint:    auditEndpoint(svc, logger, "FulfillOrderSecret", MakeFulfillOrderSecretEndpoint(svc)),
 			NewRequest:  func() interface{} { return &api.FulfillOrderSecretRequest{} },
 			NewResponse: func() interface{} { return &api.FulfillOrderSecretResponse{} },
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeSignedRequest(nodeAuthorizer),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+				service.ErrOrderCancelled:   http.StatusGone,
+				service.ErrOrderNotYetValid: http.StatusForbidden,
+				service.ErrOrderExpired:     http.StatusGone,
+				service.ErrPolicyViolation:  http.StatusForbidden,
+				service.ErrEnvelopeExpired:  http.StatusUnprocessableEntity,
+				service.ErrEnvelopeReplay:   http.StatusConflict,
+				service.ErrOrderConflict:    http.StatusConflict,
+			},
+		},
+		"FulfillOrderCancel": {
+			Path:        "/" + apiVersion + "/fulfill/order/cancel",
+			Method:      http.MethodPost,
+			Endpoint:    auditEndpoint(svc, logger, "FulfillOrderCancel", MakeFulfillOrderCancelEndpoint(svc)),
+			NewRequest:  func() interface{} { return &api.FulfillOrderCancelRequest{} },
+			NewResponse: func() interface{} { return &api.FulfillOrderCancelResponse{} },
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeSignedRequest(nodeAuthorizer),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+			},
+		},
+		"FulfillOrderApprove": {
+			Path:        "/" + apiVersion + "/fulfill/order/approve",
+			Method:      http.MethodPost,
+			Endpoint:    auditEndpoint(svc, logger, "FulfillOrderApprove", MakeFulfillOrderApproveEndpoint(svc)),
+			NewRequest:  func() interface{} { return &api.FulfillOrderApproveRequest{} },
+			NewResponse: func() interface{} { return &api.FulfillOrderApproveResponse{} },
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeSignedRequest(nodeAuthorizer),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+				common.ErrInvalidApproval:   http.StatusUnprocessableEntity,
+				service.ErrPolicyViolation:  http.StatusForbidden,
+			},
+		},
+		"CreatePolicy": {
+			Path:        "/" + apiVersion + "/policy",
+			Method:      http.MethodPost,
+			Endpoint:    auditEndpoint(svc, logger, "CreatePolicy", MakeCreatePolicyEndpoint(svc)),
+			NewRequest:  func() interface{} { return &api.CreatePolicyRequest{} },
+			NewResponse: func() interface{} { return &api.CreatePolicyResponse{} },
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeOIDC(authorizer, false),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+			},
+		},
+	}
+	policyEndpoints := transport.HTTPEndpoints{
+		"GetPolicy": {
+			Path:        "/" + apiVersion + "/policy/{PolicyCID}",
+			Method:      http.MethodGet,
+			Endpoint:    MakeGetPolicyEndpoint(svc),
+			NewResponse: func() interface{} { return &api.GetPolicyResponse{} },
+			Options: transport.ServerOptions(
+				transport.SetCors(corsAllow),
+				transport.AuthorizeOIDC(authorizer, false),
+			),
+			ErrStatus: transport.ErrorStatus{
+				transport.ErrInvalidRequest: http.StatusUnprocessableEntity,
+			},
+		},
+	}
+
+	auditEndpoints := transport.HTTPEndpoints{
+		"ExportAudit": {
+			Path:        "/" + apiVersion + "/audit",
+			Method:      http.MethodGet,
+			Endpoint:    MakeExportAuditEndpoint(svc),
+			NewResponse: func() interface{} { return &api.ExportAuditResponse{} },
 			Options: transport.ServerOptions(
 				transport.SetCors(corsAllow),
 				transport.AuthorizeOIDC(authorizer, false),
@@ -156,11 +454,11 @@ func Endpoints(svc service.Service, corsAllow string, authorizer transport.Autho
 	endpoints := transport.HTTPEndpoints{}
 	switch strings.ToLower(nodeType) {
 	case "multi":
-		endpoints = concatEndpoints(masterFiduciaryEndpoints, principalEndpoints, statusEndPoints)
+		endpoints = concatEndpoints(masterFiduciaryEndpoints, principalEndpoints, webhookEndpoints, eventEndpoints, identityEndpoints, policyEndpoints, auditEndpoints, statusEndPoints)
 	case "principal":
-		endpoints = concatEndpoints(principalEndpoints, statusEndPoints)
+		endpoints = concatEndpoints(principalEndpoints, webhookEndpoints, eventEndpoints, identityEndpoints, policyEndpoints, auditEndpoints, statusEndPoints)
 	case "fiduciary", "masterfiduciary":
-		endpoints = concatEndpoints(masterFiduciaryEndpoints, statusEndPoints)
+		endpoints = concatEndpoints(masterFiduciaryEndpoints, identityEndpoints, policyEndpoints, auditEndpoints, statusEndPoints)
 	}
 
 	plugNamespace, plugEndpoints := pluginEndpoints.Endpoints()
@@ -190,23 +488,87 @@ func concatPluginEndpoints(logger *logger.Logger, dst transport.HTTPEndpoints, n
 	return dst
 }
 
+//MakeCreateIdentityEndpoint -
+func MakeCreateIdentityEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*api.CreateIdentityRequest)
+		if !ok {
+			return nil, transport.ErrInvalidRequest
+		}
+		if err := validateRequest(req); err != nil {
+			return "", err
+		}
+		return m.CreateIdentity(req)
+	}
+}
+
+//MakeGetIdentityEndpoint -
+func MakeGetIdentityEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		params := transport.GetURLParams(ctx)
+		idDocumentCID := params.Get("IDDocumentCID")
+
+		req := &api.GetIdentityRequest{
+			IDDocumentCID: idDocumentCID,
+		}
+		if err := validateRequest(req); err != nil {
+			return "", err
+		}
+		return m.GetIdentity(req)
+	}
+}
+
+//MakeIdentityListEndpoint -
+func MakeIdentityListEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		params := transport.GetParams(ctx)
+		req := &api.IdentityListRequest{
+			SortBy: params.Get("sortBy"),
+			Cursor: params.Get("cursor"),
+		}
+		if req.PerPage, err = intParam(params, "perPage"); err != nil {
+			return nil, err
+		}
+		if req.Page, err = intParam(params, "page"); err != nil {
+			return nil, err
+		}
+		if req.Count, err = boolParam(params, "count"); err != nil {
+			return nil, err
+		}
+		if err := validateRequest(req); err != nil {
+			return "", err
+		}
+		return m.IdentityList(req)
+	}
+}
+
 //MakeOrderListEndpoint -
 func MakeOrderListEndpoint(m service.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		params := transport.GetParams(ctx)
-		sortBy := params.Get("sortBy")
-		perPage, err := strconv.Atoi(params.Get("perPage"))
-		if err != nil {
+		req := &api.OrderListRequest{
+			SortBy:         params.Get("sortBy"),
+			BeneficiaryCID: params.Get("beneficiaryCID"),
+			Type:           params.Get("type"),
+			Coin:           params.Get("coin"),
+			State:          params.Get("state"),
+			Role:           params.Get("role"),
+			Cursor:         params.Get("cursor"),
+		}
+		if req.PerPage, err = intParam(params, "perPage"); err != nil {
 			return nil, err
 		}
-		page, err := strconv.Atoi(params.Get("page"))
-		if err != nil {
+		if req.Page, err = intParam(params, "page"); err != nil {
 			return nil, err
 		}
-		req := &api.OrderListRequest{
-			Page:    page,
-			PerPage: perPage,
-			SortBy:  sortBy,
+		if req.From, err = int64Param(params, "from"); err != nil {
+			return nil, err
+		}
+		if req.To, err = int64Param(params, "to"); err != nil {
+			return nil, err
+		}
+		if req.Count, err = boolParam(params, "count"); err != nil {
+			return nil, err
 		}
 		if err := validateRequest(req); err != nil {
 			return "", err
@@ -238,10 +600,29 @@ func MakeOrderEndpoint(m service.Service) endpoint.Endpoint {
 		if err := validateRequest(req); err != nil {
 			return "", err
 		}
+		req.Subject = transport.GetUserInfo(ctx).GetString("sub")
 		return m.Order(req)
 	}
 }
 
+//MakeBatchOrderEndpoint -
+func MakeBatchOrderEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*api.BatchOrderRequest)
+		if !ok {
+			return nil, transport.ErrInvalidRequest
+		}
+		if err := validateRequest(req); err != nil {
+			return "", err
+		}
+		subject := transport.GetUserInfo(ctx).GetString("sub")
+		for _, o := range req.Orders {
+			o.Subject = subject
+		}
+		return m.BatchOrder(req)
+	}
+}
+
 //MakeOrderSecretEndpoint -
 func MakeOrderSecretEndpoint(m service.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
@@ -256,6 +637,191 @@ func MakeOrderSecretEndpoint(m service.Service) endpoint.Endpoint {
 	}
 }
 
+//MakeVerifyOrderEndpoint -
+func MakeVerifyOrderEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		params := transport.GetURLParams(ctx)
+		req := &api.VerifyOrderRequest{
+			OrderReference: params.Get("OrderReference"),
+		}
+		if err := validateRequest(req); err != nil {
+			return "", err
+		}
+		return m.VerifyOrder(req)
+	}
+}
+
+//MakeResumeOrderEndpoint -
+func MakeResumeOrderEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		params := transport.GetURLParams(ctx)
+		req := &api.ResumeOrderRequest{
+			OrderReference: params.Get("OrderReference"),
+		}
+		if err := validateRequest(req); err != nil {
+			return "", err
+		}
+		return m.ResumeOrder(req)
+	}
+}
+
+//MakeCancelOrderEndpoint -
+func MakeCancelOrderEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*api.CancelOrderRequest)
+		if !ok {
+			return nil, transport.ErrInvalidRequest
+		}
+		params := transport.GetURLParams(ctx)
+		req.OrderReference = params.Get("OrderReference")
+		if err := validateRequest(req); err != nil {
+			return "", err
+		}
+		return m.CancelOrder(req)
+	}
+}
+
+//MakeRedeemOrderEndpoint -
+func MakeRedeemOrderEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*api.RedeemOrderRequest)
+		if !ok {
+			return nil, transport.ErrInvalidRequest
+		}
+		if err := validateRequest(req); err != nil {
+			return "", err
+		}
+		return m.RedeemOrder(req)
+	}
+}
+
+//MakeCreatePolicyEndpoint -
+func MakeCreatePolicyEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*api.CreatePolicyRequest)
+		if !ok {
+			return nil, transport.ErrInvalidRequest
+		}
+		if err := validateRequest(req); err != nil {
+			return "", err
+		}
+		if req.ApprovalQuorum > int64(len(req.RequiredApproverCIDs)) {
+			return "", errors.Wrap(transport.ErrInvalidRequest, "approval quorum exceeds the approvers")
+		}
+		return m.CreatePolicy(req)
+	}
+}
+
+//MakeGetPolicyEndpoint -
+func MakeGetPolicyEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		params := transport.GetURLParams(ctx)
+		req := &api.GetPolicyRequest{
+			PolicyCID: params.Get("PolicyCID"),
+		}
+		if err := validateRequest(req); err != nil {
+			return "", err
+		}
+		return m.GetPolicy(req)
+	}
+}
+
+//MakeApproveOrderEndpoint -
+func MakeApproveOrderEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*api.ApproveOrderRequest)
+		if !ok {
+			return nil, transport.ErrInvalidRequest
+		}
+		if err := validateRequest(req); err != nil {
+			return "", err
+		}
+		return m.ApproveOrder(req)
+	}
+}
+
+//MakeFulfillOrderApproveEndpoint -
+func MakeFulfillOrderApproveEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*api.FulfillOrderApproveRequest)
+		if !ok {
+			return nil, transport.ErrInvalidRequest
+		}
+		if err := validateRequest(req); err != nil {
+			return "", err
+		}
+		if err := checkSenderNode(ctx, req.ApproverCID); err != nil {
+			return nil, err
+		}
+		return m.FulfillOrderApprove(req)
+	}
+}
+
+//MakeExportAuditEndpoint -
+func MakeExportAuditEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		return m.ExportAudit()
+	}
+}
+
+//MakeCreateWebhookEndpoint -
+func MakeCreateWebhookEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*api.CreateWebhookRequest)
+		if !ok {
+			return nil, transport.ErrInvalidRequest
+		}
+		if err := validateRequest(req); err != nil {
+			return "", err
+		}
+		req.Subject = transport.GetUserInfo(ctx).GetString("sub")
+		return m.CreateWebhook(req)
+	}
+}
+
+//MakeWebhookListEndpoint -
+func MakeWebhookListEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := &api.WebhookListRequest{
+			Subject: transport.GetUserInfo(ctx).GetString("sub"),
+		}
+		return m.WebhookList(req)
+	}
+}
+
+//MakeDeleteWebhookEndpoint -
+func MakeDeleteWebhookEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		params := transport.GetURLParams(ctx)
+		req := &api.DeleteWebhookRequest{
+			WebhookID: params.Get("WebhookID"),
+			Subject:   transport.GetUserInfo(ctx).GetString("sub"),
+		}
+		if err := validateRequest(req); err != nil {
+			return "", err
+		}
+		return nil, m.DeleteWebhook(req)
+	}
+}
+
+//MakeOrderEventsEndpoint -
+func MakeOrderEventsEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := &api.OrderEventsRequest{
+			Cursor:  transport.LastEventID(ctx),
+			Subject: transport.GetUserInfo(ctx).GetString("sub"),
+		}
+		if err := validateRequest(req); err != nil {
+			return "", err
+		}
+		return transport.StreamFunc(func(ctx context.Context, send func(e *transport.Event) error) error {
+			return m.StreamOrderEvents(ctx.Done(), req, func(cursor string, event *api.WebhookEvent) error {
+				return send(&transport.Event{ID: cursor, Event: event.Event, Data: event})
+			})
+		}), nil
+	}
+}
+
 //MakeFulfillOrderEndpoint -
 func MakeFulfillOrderEndpoint(m service.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
@@ -266,10 +832,30 @@ func MakeFulfillOrderEndpoint(m service.Service) endpoint.Endpoint {
 		if err := validateRequest(req); err != nil {
 			return "", err
 		}
+		if err := checkSenderNode(ctx, req.DocumentCID); err != nil {
+			return nil, err
+		}
 		return m.FulfillOrder(req)
 	}
 }
 
+//MakeFulfillOrderBatchEndpoint -
+func MakeFulfillOrderBatchEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*api.FulfillOrderBatchRequest)
+		if !ok {
+			return nil, transport.ErrInvalidRequest
+		}
+		if err := validateRequest(req); err != nil {
+			return "", err
+		}
+		if err := checkSenderNode(ctx, req.DocumentCID); err != nil {
+			return nil, err
+		}
+		return m.FulfillOrderBatch(req)
+	}
+}
+
 //MakeFulfillOrderSecretEndpoint -
 func MakeFulfillOrderSecretEndpoint(m service.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
@@ -280,10 +866,30 @@ func MakeFulfillOrderSecretEndpoint(m service.Service) endpoint.Endpoint {
 		if err := validateRequest(req); err != nil {
 			return "", err
 		}
+		if err := checkSenderNode(ctx, req.SenderDocumentCID); err != nil {
+			return nil, err
+		}
 		return m.FulfillOrderSecret(req)
 	}
 }
 
+//MakeFulfillOrderCancelEndpoint -
+func MakeFulfillOrderCancelEndpoint(m service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req, ok := request.(*api.FulfillOrderCancelRequest)
+		if !ok {
+			return nil, transport.ErrInvalidRequest
+		}
+		if err := validateRequest(req); err != nil {
+			return "", err
+		}
+		if err := checkSenderNode(ctx, req.SenderDocumentCID); err != nil {
+			return nil, err
+		}
+		return m.FulfillOrderCancel(req)
+	}
+}
+
 //MakeStatusEndpoint -
 func MakeStatusEndpoint(m service.Service, nodeType string) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
@@ -291,6 +897,46 @@ func MakeStatusEndpoint(m service.Service, nodeType string) endpoint.Endpoint {
 	}
 }
 
+// checkSenderNode returns an error if the request isn't signed by the sender node
+func checkSenderNode(ctx context.Context, senderCID string) error {
+	if nodeID := transport.GetUserInfo(ctx).GetString("sub"); nodeID != senderCID {
+		return errors.Wrapf(transport.ErrUnauthorized, "request of %s signed by node %s", senderCID, nodeID)
+	}
+	return nil
+}
+
+// intParam returns the integer value of an optional query param
+func intParam(params url.Values, name string) (int, error) {
+	v, err := int64Param(params, name)
+	return int(v), err
+}
+
+// int64Param returns the int64 value of an optional query param
+func int64Param(params url.Values, name string) (int64, error) {
+	s := params.Get(name)
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(transport.ErrInvalidRequest, "invalid %s", name)
+	}
+	return v, nil
+}
+
+// boolParam returns the bool value of an optional query param
+func boolParam(params url.Values, name string) (bool, error) {
+	s := params.Get(name)
+	if s == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, errors.Wrapf(transport.ErrInvalidRequest, "invalid %s", name)
+	}
+	return v, nil
+}
+
 func validateRequest(req interface{}) error {
 	validate := validator.New()
 	validate.RegisterAlias("IPFS", "min=46,max=46,startswith=Q")
@@ -21,29 +21,80 @@ Package service - defines core Milagro D-TA interface
 package service
 
 import (
+	"github.com/apache/incubator-milagro-dta/libs/audit"
 	"github.com/apache/incubator-milagro-dta/libs/transport"
 	"github.com/apache/incubator-milagro-dta/pkg/api"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrOrderState is returned when the order is not in a state to be processed
+	ErrOrderState = errors.New("invalid order state")
+	// ErrOrderCancelled is returned by the fiduciary for a cancelled order
+	ErrOrderCancelled = errors.New("order cancelled")
+	// ErrOrderNotYetValid is returned when the order secret is requested before the not-before time
+	ErrOrderNotYetValid = errors.New("order not yet valid")
+	// ErrOrderExpired is returned when the order secret is requested after the expiry time
+	ErrOrderExpired = errors.New("order expired")
+	// ErrPolicyViolation is returned by the fiduciary when the request breaks the order policy
+	ErrPolicyViolation = errors.New("order policy violation")
+	// ErrEnvelopeExpired is returned by the fiduciary when the envelope is too old
+	ErrEnvelopeExpired = errors.New("envelope expired")
+	// ErrEnvelopeReplay is returned by the fiduciary when the nonce of the envelope was already processed
+	ErrEnvelopeReplay = errors.New("envelope replay")
+	// ErrOrderConflict is returned by the fiduciary when the request conflicts with the fulfilment of the order
+	ErrOrderConflict = errors.New("conflicting order request")
+	// ErrNotNodeOperator is returned when the caller isn't an operator of the node
+	ErrNotNodeOperator = errors.New("not a node operator")
 )
 
 // Service is the CustodyService interface
 type Service interface {
+	//Identity
+	CreateIdentity(req *api.CreateIdentityRequest) (*api.CreateIdentityResponse, error)
+	GetIdentity(req *api.GetIdentityRequest) (*api.GetIdentityResponse, error)
+	IdentityList(req *api.IdentityListRequest) (*api.IdentityListResponse, error)
+
+	//Policy
+	CreatePolicy(req *api.CreatePolicyRequest) (*api.CreatePolicyResponse, error)
+	GetPolicy(req *api.GetPolicyRequest) (*api.GetPolicyResponse, error)
+
 	//Order
 	GetOrder(req *api.GetOrderRequest) (*api.GetOrderResponse, error)
 	OrderList(req *api.OrderListRequest) (*api.OrderListResponse, error)
+	VerifyOrder(req *api.VerifyOrderRequest) (*api.VerifyOrderResponse, error)
 
 	//Order processing
 	OrderSecret(req *api.OrderSecretRequest) (*api.OrderSecretResponse, error)
 	Order(req *api.OrderRequest) (*api.OrderResponse, error)
+	BatchOrder(req *api.BatchOrderRequest) (*api.BatchOrderResponse, error)
+	ResumeOrder(req *api.ResumeOrderRequest) (*api.ResumeOrderResponse, error)
+	CancelOrder(req *api.CancelOrderRequest) (*api.CancelOrderResponse, error)
+	RedeemOrder(req *api.RedeemOrderRequest) (*api.RedeemOrderResponse, error)
+	ApproveOrder(req *api.ApproveOrderRequest) (*api.ApproveOrderResponse, error)
 
 	//Fullfill processing
 	FulfillOrder(req *api.FulfillOrderRequest) (*api.FulfillOrderResponse, error)
+	FulfillOrderBatch(req *api.FulfillOrderBatchRequest) (*api.FulfillOrderBatchResponse, error)
 	FulfillOrderSecret(req *api.FulfillOrderSecretRequest) (*api.FulfillOrderSecretResponse, error)
+	FulfillOrderCancel(req *api.FulfillOrderCancelRequest) (*api.FulfillOrderCancelResponse, error)
+	FulfillOrderApprove(req *api.FulfillOrderApproveRequest) (*api.FulfillOrderApproveResponse, error)
 
 	NodeID() string
 	MasterFiduciaryNodeID() string
 	SetNodeID(nodeID string)
 	SetMasterFiduciaryNodeID(masterFiduciaryNodeID string)
 
+	//Webhooks
+	CreateWebhook(req *api.CreateWebhookRequest) (*api.CreateWebhookResponse, error)
+	WebhookList(req *api.WebhookListRequest) (*api.WebhookListResponse, error)
+	DeleteWebhook(req *api.DeleteWebhookRequest) error
+	StreamOrderEvents(stop <-chan struct{}, req *api.OrderEventsRequest, send func(cursor string, event *api.WebhookEvent) error) error
+
+	//Audit
+	RecordAudit(entry *audit.Entry) error
+	ExportAudit() (*api.ExportAuditResponse, error)
+
 	//System
 	Status(apiVersion, nopdeType string) (*api.StatusResponse, error)
 }
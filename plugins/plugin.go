@@ -40,6 +40,10 @@ type ServicePlugin interface {
 	service.Endpoints
 
 	Init(plugin defaultservice.Plugable, options ...defaultservice.ServiceOption) error
+	RunOrderQueue(stop <-chan struct{})
+	RunOrderReaper(stop <-chan struct{})
+	RunAuditSigner(stop <-chan struct{})
+	RunWebhookQueue(stop <-chan struct{})
 }
 
 func registerPlugin(p Plugin) {